@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"io"
+	"strings"
 	"text/template"
 
 	"github.com/appgate/sdpctl/pkg/api"
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
@@ -16,7 +18,7 @@ type adminMessageOpts struct {
 	config  *configuration.Config
 	factory *factory.Factory
 	out     io.Writer
-	json    bool
+	output  *cmdutil.OutputOptions
 }
 
 // NewAdminMessageCmd return a new admin message command
@@ -33,7 +35,7 @@ func NewAdminMessageCmd(f *factory.Factory) *cobra.Command {
 			return adminMessagesRun(&opts)
 		},
 	}
-	cmd.Flags().BoolVar(&opts.json, "json", false, "Display in JSON format")
+	opts.output = cmdutil.AddOutputFlags(cmd, cmdutil.OutputTable)
 
 	return cmd
 }
@@ -47,6 +49,9 @@ const messageTemplate = `Messages:
 `
 
 func adminMessagesRun(opts *adminMessageOpts) error {
+	if err := opts.output.Validate(); err != nil {
+		return err
+	}
 	cfg := opts.config
 	client, err := opts.factory.APIClient(cfg)
 	if err != nil {
@@ -62,13 +67,14 @@ func adminMessagesRun(opts *adminMessageOpts) error {
 	if err != nil {
 		return api.HTTPErrorResponse(response, err)
 	}
-	if opts.json {
-		return util.PrintJSON(opts.out, list)
+	// the messages can span several lines, so the default presentation is a list rather than a table
+	if opts.output.IsDefaultTable() {
+		t := template.Must(template.New("").Parse(messageTemplate))
+		return t.Execute(opts.out, list.GetData())
 	}
-
-	t := template.Must(template.New("").Parse(messageTemplate))
-	if err := t.Execute(opts.out, list.GetData()); err != nil {
-		return err
+	t := cmdutil.NewTable("Level", "Sources", "Created", "Count", "Message")
+	for _, m := range list.GetData() {
+		t.AddRow(m.GetLevel(), strings.Join(m.GetSources(), ","), m.GetCreated(), m.GetCount(), m.GetMessage())
 	}
-	return nil
+	return opts.output.Print(opts.out, list, t)
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/appgate/sdpctl/cmd/appliance/backup"
 	"github.com/appgate/sdpctl/cmd/appliance/files"
 	"github.com/appgate/sdpctl/cmd/appliance/functions"
+	"github.com/appgate/sdpctl/cmd/appliance/group"
 	"github.com/appgate/sdpctl/cmd/appliance/maintenance"
 	"github.com/appgate/sdpctl/cmd/appliance/upgrade"
 	"github.com/appgate/sdpctl/pkg/docs"
@@ -13,8 +14,14 @@ import (
 
 var (
 	filterHelp string = `Filter appliances using a comma separated list of key-value pairs. Regex syntax is used for matching strings. Example: '--exclude name=controller,site=<site-id> etc.'.
-Available keywords to filter on are: name, id, tags|tag, version, hostname|host, active|activated, site|site-id, function`
-	orderByHelp string = `Order appliance lists by keywords, i.e. 'name', 'id' etc. Accepts a comma separated list of keywords, where first mentioned has priority. Applies to the 'appliance list' and 'appliance stats' commands.`
+Available keywords to filter on are: name, id, tags|tag, version, hostname|host, active|activated, site|site-id, function.
+The live stats keywords cpu, memory|mem, disk and sessions take a number with an optional comparison, for example 'sessions=<10' or 'disk=>80',
+and version also takes a version range with the constraints separated by ';', for example 'version=>=6.2;<6.4'`
+	filterExpressionHelp string = `Filter appliances with an expression, for example: function == "gateway" && site == "Stockholm" && !tag("canary").
+Comparisons use ==, !=, =~ (regex), !~, <, <=, > and >=, and are combined with &&, || and !. Live stats can be used, such as 'sessions < 10',
+'disk > 80' or 'version >= 6.2 && version < 6.4'. Applied together with '--include' and '--exclude'`
+	orderByHelp string = `Order appliance lists by keywords, i.e. 'name', 'id' etc. Accepts a comma separated list of keywords, where first mentioned has priority. Applies to the 'appliance list' and 'appliance stats' commands.
+The live stats keywords 'cpu', 'memory', 'disk', 'sessions' and 'version' are also available when listing appliances.`
 )
 
 // NewApplianceCmd return a new appliance command
@@ -29,6 +36,9 @@ func NewApplianceCmd(f *factory.Factory) *cobra.Command {
 	pFlags := cmd.PersistentFlags()
 	pFlags.StringToStringP("include", "i", map[string]string{}, "Include appliances. Adheres to the same syntax and key-value pairs as '--exclude'")
 	pFlags.StringToStringP("exclude", "e", map[string]string{}, filterHelp)
+	pFlags.String("filter", "", filterExpressionHelp)
+	pFlags.String("group", "", "Select the appliances of a named appliance group, see 'sdpctl appliance group'. Combined with '--include', '--exclude' and '--filter'")
+	cmd.RegisterFlagCompletionFunc("group", group.TabCompletion(f.Config))
 	pFlags.StringSlice("order-by", []string{"name"}, orderByHelp)
 	pFlags.Bool("descending", false, "Change the direction of sort order when using the '--order-by' flag. Using this will reverse the sort order for all keywords specified in the '--order-by' flag.")
 
@@ -37,6 +47,7 @@ func NewApplianceCmd(f *factory.Factory) *cobra.Command {
 		backup.NewCmdBackup(f),
 		NewListCmd(f),
 		NewStatsCmd(f),
+		NewHealthCmd(f),
 		NewMetricCmd(f),
 		NewResolveNameCmd(f),
 		NewResolveNameStatusCmd(f),
@@ -47,6 +58,7 @@ func NewApplianceCmd(f *factory.Factory) *cobra.Command {
 		NewSeedCmd(f),
 		NewForceDisableControllerCmd(f),
 		functions.NewApplianceFunctionsCmd(f),
+		group.NewGroupCmd(f),
 		NewSwitchPartitionCmd(f),
 	)
 
@@ -22,14 +22,17 @@ import (
 )
 
 type apiOptions struct {
-	Config        *configuration.Config
-	Out           io.Writer
-	In            io.ReadCloser
-	APIClient     func(c *configuration.Config) (*openapi.APIClient, error)
-	CustomClient  func() (*http.Client, error)
-	debug         bool
-	disable       bool
-	NoInteractive bool
+	Config         *configuration.Config
+	Out            io.Writer
+	In             io.ReadCloser
+	APIClient      func(c *configuration.Config) (*openapi.APIClient, error)
+	CustomClient   func() (*http.Client, error)
+	debug          bool
+	disable        bool
+	rotate         bool
+	json           bool
+	passphraseFile string
+	NoInteractive  bool
 }
 
 // NewBackupAPICmd return a new Backup API command
@@ -55,14 +58,73 @@ func NewBackupAPICmd(f *factory.Factory) *cobra.Command {
 			if !f.CanPrompt() {
 				opts.NoInteractive = true
 			}
+			if opts.disable && (opts.rotate || len(opts.passphraseFile) > 0) {
+				return errors.New("the '--disable' flag can't be combined with '--rotate' or '--passphrase-file'")
+			}
 			return backupAPIrun(c, args, &opts)
 		},
 	}
 	cmd.Flags().BoolVar(&opts.disable, "disable", false, "Disable the Backup API")
+	cmd.Flags().BoolVar(&opts.rotate, "rotate", false, "Set a new backup passphrase even if the Backup API is already enabled")
+	cmd.Flags().StringVar(&opts.passphraseFile, "passphrase-file", "", "Read the backup passphrase from a file instead of prompting. Implies '--rotate' if the Backup API is already enabled")
+	cmd.Flags().BoolVar(&opts.json, "json", false, "Display the resulting Backup API state in JSON format")
+	cmd.AddCommand(NewBackupAPIStatusCmd(f))
+	return cmd
+}
 
+// NewBackupAPIStatusCmd return a new Backup API status command
+func NewBackupAPIStatusCmd(f *factory.Factory) *cobra.Command {
+	opts := apiOptions{
+		Config:    f.Config,
+		APIClient: f.APIClient,
+		Out:       f.IOOutWriter,
+	}
+	var cmd = &cobra.Command{
+		Use:     "status",
+		Short:   docs.ApplianceBackupAPIStatusDoc.Short,
+		Long:    docs.ApplianceBackupAPIStatusDoc.Long,
+		Example: docs.ApplianceBackupAPIStatusDoc.ExampleString(),
+		Args:    cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			return backupAPIStatusRun(c, args, &opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.json, "json", false, "Display in JSON format")
 	return cmd
 }
 
+// backupAPIState is the Backup API configuration as printed with the '--json' flag.
+// PassphraseUpdated is left out unless the passphrase was changed, since the status can't tell.
+type backupAPIState struct {
+	Enabled           bool `json:"enabled"`
+	PassphraseUpdated bool `json:"passphraseUpdated,omitempty"`
+}
+
+func backupAPIStatusRun(cmd *cobra.Command, args []string, opts *apiOptions) error {
+	apiClient, err := opts.APIClient(opts.Config)
+	if err != nil {
+		return err
+	}
+	token, err := opts.Config.GetBearTokenHeaderValue()
+	if err != nil {
+		return err
+	}
+	settings, response, err := apiClient.GlobalSettingsApi.GlobalSettingsGet(util.BaseAuthContext(token)).Execute()
+	if err != nil {
+		return api.HTTPErrorResponse(response, err)
+	}
+	state := backupAPIState{Enabled: settings.GetBackupApiEnabled()}
+	if opts.json {
+		return util.PrintJSON(opts.Out, state)
+	}
+	if state.Enabled {
+		fmt.Fprintln(opts.Out, "The Backup API is enabled")
+	} else {
+		fmt.Fprintln(opts.Out, "The Backup API is disabled")
+	}
+	return nil
+}
+
 func backupAPIrun(cmd *cobra.Command, args []string, opts *apiOptions) error {
 	apiClient, err := opts.APIClient(opts.Config)
 	if err != nil {
@@ -77,29 +139,71 @@ func backupAPIrun(cmd *cobra.Command, args []string, opts *apiOptions) error {
 	if err != nil {
 		return api.HTTPErrorResponse(response, err)
 	}
-	if v, ok := settings.GetBackupApiEnabledOk(); ok && *v && !opts.disable {
+	setPassphrase := opts.rotate || len(opts.passphraseFile) > 0
+	if v, ok := settings.GetBackupApiEnabledOk(); ok && *v && !opts.disable && !setPassphrase {
+		if opts.json {
+			return util.PrintJSON(opts.Out, backupAPIState{Enabled: true})
+		}
 		fmt.Fprintln(opts.Out, "The Backup API is already enabled")
 		return nil
 	}
 	var message string
+	state := backupAPIState{}
 	if opts.disable {
 		settings.SetBackupApiEnabled(false)
 		message = "The Backup API has been disabled"
 	} else {
-		hasStdin := false
-		stat, err := os.Stdin.Stat()
-		if err == nil && (stat.Mode()&os.ModeCharDevice) == 0 {
-			hasStdin = true
+		var answer string
+		if len(opts.passphraseFile) > 0 {
+			answer, err = readPassphraseFile(opts.passphraseFile)
+		} else {
+			hasStdin := false
+			stat, statErr := os.Stdin.Stat()
+			if statErr == nil && (stat.Mode()&os.ModeCharDevice) == 0 {
+				hasStdin = true
+			}
+			answer, err = prompt.GetBackupPassphrase(opts.In, !opts.NoInteractive, hasStdin, "The passphrase to encrypt the appliance backups when the Backup API is used:")
 		}
-		answer, err := prompt.GetBackupPassphrase(opts.In, !opts.NoInteractive, hasStdin, "The passphrase to encrypt the appliance backups when the Backup API is used:")
 		if err != nil {
 			return err
 		}
 		settings.SetBackupApiEnabled(true)
 		settings.SetBackupPassphrase(answer)
+		state.Enabled = true
+		state.PassphraseUpdated = true
 		message = "The Backup API and the passphrase have been updated"
 	}
+	customClient, err := opts.CustomClient()
+	if err != nil {
+		return err
+	}
+	if err := updateGlobalSettings(ctx, apiClient, customClient, opts.Config.Version, settings); err != nil {
+		return err
+	}
+	if opts.json {
+		return util.PrintJSON(opts.Out, state)
+	}
+	fmt.Fprintln(opts.Out, message)
+	return nil
+}
+
+// readPassphraseFile reads and validates a backup passphrase from a file
+func readPassphraseFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read passphrase file: %w", err)
+	}
+	defer file.Close()
+	passphrase, err := prompt.GetBackupPassphrase(file, false, true, "")
+	if err != nil {
+		return "", fmt.Errorf("invalid passphrase in %s: %w", path, err)
+	}
+	return passphrase, nil
+}
 
+// updateGlobalSettings uses a custom request instead of the generated GlobalSettingsPut,
+// so that special characters in the passphrase are not escaped.
+func updateGlobalSettings(ctx context.Context, apiClient *openapi.APIClient, customClient *http.Client, version int, settings *openapi.GlobalSettings) error {
 	cfg := apiClient.GetConfig()
 	url, err := cfg.ServerURLWithContext(ctx, "GlobalSettingsApiService.GlobalSettingsPut")
 	if err != nil {
@@ -111,25 +215,19 @@ func backupAPIrun(cmd *cobra.Command, args []string, opts *apiOptions) error {
 		return err
 	}
 	body := bytes.NewBuffer(b)
-	ctx = context.WithValue(ctx, api.ContextAcceptValue, fmt.Sprintf("application/vnd.appgate.peer-v%d+json", opts.Config.Version))
+	ctx = context.WithValue(ctx, api.ContextAcceptValue, fmt.Sprintf("application/vnd.appgate.peer-v%d+json", version))
 	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url+"/global-settings", body)
 	if err != nil {
 		return err
 	}
 	req.Header.Add("Content-Type", "application/json")
-	customClient, err := opts.CustomClient()
-	if err != nil {
-		return err
-	}
-
-	response, err = customClient.Do(req)
+	response, err := customClient.Do(req)
 	if err != nil {
 		return api.HTTPErrorResponse(response, err)
 	}
 	if response.StatusCode >= 400 {
 		return api.HTTPErrorResponse(response, errors.New("response does not indicate success"))
 	}
-	fmt.Fprintln(opts.Out, message)
 	return nil
 }
 
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/Netflix/go-expect"
@@ -189,3 +192,88 @@ func TestBackupAPICommand(t *testing.T) {
 		t.Fatalf("Expected output\n%s\ngot\n%s\n", want, got)
 	}
 }
+
+func backupAPITestFactory(t *testing.T, enabled bool) (*factory.Factory, *bytes.Buffer, func()) {
+	registry := httpmock.NewRegistry(t)
+	registry.Register(
+		"/admin/global-settings",
+		func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusOK)
+			if r.Method == http.MethodGet {
+				fmt.Fprintf(rw, `{"backupApiEnabled": %t, "collectiveId": "4c07bc69-57ea-42dd-b702-c2d6c45419fc"}`, enabled)
+			}
+		},
+	)
+	registry.Serve()
+	stdout := &bytes.Buffer{}
+	f := &factory.Factory{
+		Config: &configuration.Config{
+			Debug: false,
+			URL:   fmt.Sprintf("http://localhost:%d", registry.Port),
+		},
+		IOOutWriter: stdout,
+		Stdin:       io.NopCloser(&bytes.Buffer{}),
+		StdErr:      io.Discard,
+	}
+	f.APIClient = func(c *configuration.Config) (*openapi.APIClient, error) {
+		return registry.Client, nil
+	}
+	f.CustomHTTPClient = func() (*http.Client, error) {
+		return registry.Client.GetConfig().HTTPClient, nil
+	}
+	return f, stdout, registry.Teardown
+}
+
+func TestBackupAPIStatusCommand(t *testing.T) {
+	f, stdout, teardown := backupAPITestFactory(t, true)
+	defer teardown()
+	cmd := NewBackupAPIStatusCmd(f)
+	cmd.SetArgs([]string{"--json"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if _, err := cmd.ExecuteC(); err != nil {
+		t.Fatalf("executeC %s", err)
+	}
+	want := regexp.MustCompile(`"enabled": true`)
+	if !want.MatchString(stdout.String()) {
+		t.Fatalf("Expected output\n%s\ngot\n%s\n", want, stdout.String())
+	}
+	if strings.Contains(stdout.String(), "passphraseUpdated") {
+		t.Fatalf("Expected no passphraseUpdated in the status output, got\n%s\n", stdout.String())
+	}
+}
+
+func TestBackupAPICommandPassphraseFile(t *testing.T) {
+	f, stdout, teardown := backupAPITestFactory(t, true)
+	defer teardown()
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(passphraseFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := NewBackupAPICmd(f)
+	cmd.PersistentFlags().Bool("no-interactive", false, "")
+	cmd.SetArgs([]string{"--passphrase-file", passphraseFile, "--json"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if _, err := cmd.ExecuteC(); err != nil {
+		t.Fatalf("executeC %s", err)
+	}
+	want := regexp.MustCompile(`"passphraseUpdated": true`)
+	if !want.MatchString(stdout.String()) {
+		t.Fatalf("Expected output\n%s\ngot\n%s\n", want, stdout.String())
+	}
+}
+
+func TestBackupAPICommandDisableWithRotate(t *testing.T) {
+	f, _, teardown := backupAPITestFactory(t, true)
+	defer teardown()
+	cmd := NewBackupAPICmd(f)
+	cmd.PersistentFlags().Bool("no-interactive", false, "")
+	cmd.SetArgs([]string{"--disable", "--rotate"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if _, err := cmd.ExecuteC(); err == nil {
+		t.Fatal("expected error when combining --disable and --rotate")
+	}
+}
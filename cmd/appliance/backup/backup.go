@@ -57,7 +57,10 @@ func NewCmdBackup(f *factory.Factory) *cobra.Command {
 	flags.StringSliceVar(&opts.With, "with", []string{}, "include extra data in backup (audit, logs)")
 	flags.BoolVar(&opts.Quiet, "quiet", false, "backup summary will not be printed if setting this flag")
 
-	cmd.AddCommand(NewBackupAPICmd(f))
+	cmd.AddCommand(
+		NewBackupAPICmd(f),
+		NewBackupScheduleCmd(f),
+	)
 
 	return cmd
 }
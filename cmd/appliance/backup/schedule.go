@@ -0,0 +1,340 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/appgate/sdpctl/pkg/api"
+	"github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/schedule"
+	"github.com/appgate/sdpctl/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type scheduleOptions struct {
+	backup         appliance.BackupOpts
+	f              *factory.Factory
+	cron           string
+	every          time.Duration
+	retention      int
+	statusFile     string
+	healthAddr     string
+	runOnStart     bool
+	passphraseFile string
+	status         *scheduleStatus
+}
+
+// NewBackupScheduleCmd return a new backup schedule command
+func NewBackupScheduleCmd(f *factory.Factory) *cobra.Command {
+	opts := scheduleOptions{
+		backup: appliance.BackupOpts{
+			Config:      f.Config,
+			Out:         f.IOOutWriter,
+			SpinnerOut:  f.GetSpinnerOutput(),
+			Appliance:   f.Appliance,
+			Destination: appliance.DefaultBackupDestination,
+		},
+		f: f,
+	}
+	cmd := &cobra.Command{
+		Use:     "schedule",
+		Short:   docs.ApplianceBackupScheduleDoc.Short,
+		Long:    docs.ApplianceBackupScheduleDoc.Long,
+		Example: docs.ApplianceBackupScheduleDoc.ExampleString(),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(opts.cron) > 0 && opts.every > 0 {
+				return errors.New("the '--cron' and '--every' flags are mutually exclusive")
+			}
+			if len(opts.cron) <= 0 && opts.every <= 0 {
+				return errors.New("one of the '--cron' or '--every' flags is required")
+			}
+			if opts.retention < 0 {
+				return errors.New("'--retention' must be zero or a positive number")
+			}
+			if len(opts.passphraseFile) > 0 {
+				if _, err := readPassphraseFile(opts.passphraseFile); err != nil {
+					return err
+				}
+			}
+			// a scheduled backup can never stop and wait for user input
+			opts.backup.NoInteractive = true
+			return appliance.PrepareBackup(&opts.backup)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return scheduleRun(cmd, args, &opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.cron, "cron", "", "cron expression for when to take backups, for example \"0 2 * * *\"")
+	flags.DurationVar(&opts.every, "every", 0, "take backups with a fixed interval, for example 24h")
+	flags.IntVar(&opts.retention, "retention", 0, "number of backup files to keep per appliance in the destination directory, 0 keeps all")
+	flags.StringVar(&opts.statusFile, "status-file", "", "write the status of the last backup run as JSON to this file")
+	flags.StringVar(&opts.healthAddr, "health-listen", "", "serve the status of the last backup run on http://<address>/healthz, for example 127.0.0.1:9851")
+	flags.BoolVar(&opts.runOnStart, "run-on-start", false, "take a backup immediately when started, before the first scheduled time")
+	flags.StringVar(&opts.passphraseFile, "passphrase-file", "", "file with the backup passphrase, validated before each run and used to enable the Backup API if it is disabled")
+	flags.StringVarP(&opts.backup.Destination, "destination", "d", appliance.DefaultBackupDestination, "backup destination directory")
+	flags.BoolVar(&opts.backup.AllFlag, "all", false, "backup all appliances in the Collective")
+	flags.BoolVar(&opts.backup.PrimaryFlag, "primary", false, "backup the primary Controller")
+	flags.BoolVar(&opts.backup.CurrentFlag, "current", false, "backup the current peer Controller")
+	flags.StringSliceVar(&opts.backup.With, "with", []string{}, "include extra data in backup (audit, logs)")
+	flags.BoolVar(&opts.backup.Quiet, "quiet", false, "backup summary will not be printed if setting this flag")
+
+	return cmd
+}
+
+func (opts *scheduleOptions) schedule() (schedule.Schedule, error) {
+	if len(opts.cron) > 0 {
+		return schedule.ParseCron(opts.cron)
+	}
+	return schedule.Every(opts.every)
+}
+
+func scheduleRun(cmd *cobra.Command, args []string, opts *scheduleOptions) error {
+	s, err := opts.schedule()
+	if err != nil {
+		return err
+	}
+	opts.status = &scheduleStatus{
+		Schedule:    s.String(),
+		Destination: opts.backup.Destination,
+		Started:     time.Now(),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(opts.healthAddr) > 0 {
+		listener, err := net.Listen("tcp", opts.healthAddr)
+		if err != nil {
+			return fmt.Errorf("could not start health endpoint: %w", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/healthz", opts.status)
+		server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.WithError(err).Error("health endpoint stopped")
+			}
+		}()
+		defer server.Shutdown(context.Background())
+		log.WithField("address", listener.Addr().String()).Info("serving backup schedule health endpoint")
+	}
+
+	next := s.Next(time.Now())
+	if opts.runOnStart {
+		next = time.Now()
+	}
+	for {
+		if next.IsZero() {
+			return fmt.Errorf("the schedule %q will never run", s.String())
+		}
+		opts.status.setNextRun(next)
+		if err := opts.status.writeFile(opts.statusFile); err != nil {
+			log.WithError(err).Warn("failed to write status file")
+		}
+		fmt.Fprintf(opts.backup.Out, "Next backup scheduled at %s\n", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			fmt.Fprintln(opts.backup.Out, "Stopping scheduled backups")
+			return nil
+		case <-timer.C:
+		}
+
+		if err := opts.runOnce(cmd, args); err != nil {
+			fmt.Fprintf(opts.backup.Out, "[%s] Scheduled backup failed: %s\n", time.Now().Format(time.RFC3339), err)
+		}
+		next = s.Next(time.Now())
+	}
+}
+
+// runOnce takes a backup, applies the retention policy and records the result in the status
+func (opts *scheduleOptions) runOnce(cmd *cobra.Command, args []string) error {
+	started := time.Now()
+	logger := log.WithField("schedule", opts.status.Schedule)
+	logger.Info("starting scheduled backup")
+
+	// the factory signs in again when the bearer token expires between runs
+	var backupIDs map[string]string
+	err := opts.ensureBackupAPI()
+	if err == nil {
+		backupIDs, err = opts.performBackup(cmd, args)
+	}
+	var removed []string
+	if err == nil {
+		removed, err = appliance.BackupRetention(opts.backup.Destination, opts.retention)
+	}
+	opts.status.update(started, backupIDs, removed, err)
+	if err := opts.status.writeFile(opts.statusFile); err != nil {
+		logger.WithError(err).Warn("failed to write status file")
+	}
+	if err != nil {
+		logger.WithError(err).Error("scheduled backup failed")
+		return err
+	}
+	logger.WithField("appliances", len(backupIDs)).Info("scheduled backup done")
+	return nil
+}
+
+// ensureBackupAPI validates the passphrase file, if any, and enables the Backup API with it
+// if it has been disabled. The file is read before each run, so that a missing or invalid
+// passphrase fails the run before any backup is initiated.
+func (opts *scheduleOptions) ensureBackupAPI() error {
+	if len(opts.passphraseFile) <= 0 {
+		return nil
+	}
+	passphrase, err := readPassphraseFile(opts.passphraseFile)
+	if err != nil {
+		return err
+	}
+	cfg := opts.backup.Config
+	apiClient, err := opts.f.APIClient(cfg)
+	if err != nil {
+		return err
+	}
+	token, err := cfg.GetBearTokenHeaderValue()
+	if err != nil {
+		return err
+	}
+	ctx := util.BaseAuthContext(token)
+	settings, response, err := apiClient.GlobalSettingsApi.GlobalSettingsGet(ctx).Execute()
+	if err != nil {
+		return api.HTTPErrorResponse(response, err)
+	}
+	if settings.GetBackupApiEnabled() {
+		return nil
+	}
+	log.Warn("Backup API is disabled, enabling it with the passphrase from file")
+	settings.SetBackupApiEnabled(true)
+	settings.SetBackupPassphrase(passphrase)
+	customClient, err := opts.f.CustomHTTPClient()
+	if err != nil {
+		return err
+	}
+	return updateGlobalSettings(ctx, apiClient, customClient, cfg.Version, settings)
+}
+
+func (opts *scheduleOptions) performBackup(cmd *cobra.Command, args []string) (map[string]string, error) {
+	// Each run gets a fresh copy of the options, since PerformBackup will modify the filter
+	b := opts.backup
+	b.FilterFlag = nil
+	backupIDs, err := appliance.PerformBackup(cmd, args, &b)
+	if b.CleanupCancelFunc != nil {
+		defer b.CleanupCancelFunc()
+	}
+	if len(backupIDs) > 0 {
+		if err := appliance.CleanupBackup(&b, backupIDs); err != nil {
+			log.WithError(err).Warn("backup cleanup failed")
+		}
+	}
+	if err != nil {
+		return backupIDs, err
+	}
+	if len(backupIDs) <= 0 {
+		return backupIDs, errors.New("no appliances were backed up")
+	}
+	return backupIDs, nil
+}
+
+type scheduleStatus struct {
+	mu                  sync.Mutex
+	Schedule            string            `json:"schedule"`
+	Destination         string            `json:"destination"`
+	Started             time.Time         `json:"started"`
+	NextRun             *time.Time        `json:"next_run,omitempty"`
+	LastRun             *time.Time        `json:"last_run,omitempty"`
+	LastSuccess         *time.Time        `json:"last_success,omitempty"`
+	LastError           string            `json:"last_error,omitempty"`
+	ConsecutiveFailures int               `json:"consecutive_failures"`
+	Backups             map[string]string `json:"backups,omitempty"`
+	Removed             []string          `json:"removed_by_retention,omitempty"`
+}
+
+func (s *scheduleStatus) setNextRun(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.NextRun = &t
+}
+
+func (s *scheduleStatus) update(started time.Time, backupIDs map[string]string, removed []string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.LastRun = &started
+	s.Backups = backupIDs
+	s.Removed = removed
+	if err != nil {
+		s.LastError = err.Error()
+		s.ConsecutiveFailures++
+		return
+	}
+	now := time.Now()
+	s.LastSuccess = &now
+	s.LastError = ""
+	s.ConsecutiveFailures = 0
+}
+
+func (s *scheduleStatus) healthy() bool {
+	return s.ConsecutiveFailures == 0
+}
+
+func (s *scheduleStatus) marshal() ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := json.MarshalIndent(s, "", "  ")
+	return b, s.healthy(), err
+}
+
+// writeFile writes the status to a temporary file first and then renames it,
+// so that readers never see a partially written file
+func (s *scheduleStatus) writeFile(path string) error {
+	if len(path) <= 0 {
+		return nil
+	}
+	b, _, err := s.marshal()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sdpctl-backup-status-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ServeHTTP responds with the current status, using HTTP 503 if the last backup run failed
+func (s *scheduleStatus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, healthy, err := s.marshal()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(b)
+}
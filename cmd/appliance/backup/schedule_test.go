@@ -0,0 +1,180 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/httpmock"
+	"github.com/spf13/cobra"
+)
+
+func TestBackupScheduleRunOnce(t *testing.T) {
+	applianceUUID := "4c07bc67-57ea-42dd-b702-c2d6c45419fc"
+	backupUUID := "fd5ea380-496b-41eb-8bc8-2c84eb36b605"
+	registry := httpmock.NewRegistry(t)
+	registry.Register(
+		"/admin/appliances",
+		httpmock.JSONResponse("../../../pkg/appliance/fixtures/appliance_list.json"),
+	)
+	registry.Register(
+		"/admin/appliances/status",
+		httpmock.JSONResponse("../../../pkg/appliance/fixtures/stats_appliance.json"),
+	)
+	registry.Register(
+		"/admin/global-settings",
+		httpmock.JSONResponse("../../../pkg/appliance/fixtures/appliance_global_options.json"),
+	)
+	registry.Register(
+		fmt.Sprintf("/admin/appliances/%s/backup", applianceUUID),
+		httpmock.JSONResponse("../../../pkg/appliance/fixtures/appliance_backup_initiated.json"),
+	)
+	registry.Register(
+		fmt.Sprintf("/admin/appliances/%s/backup/%s/status", applianceUUID, backupUUID),
+		httpmock.JSONResponse("../../../pkg/appliance/fixtures/appliance_backup_status_done.json"),
+	)
+	registry.Register(
+		fmt.Sprintf("/admin/appliances/%s/backup/%s", applianceUUID, backupUUID),
+		httpmock.FileResponse(),
+	)
+	defer registry.Teardown()
+	registry.Serve()
+
+	dir := t.TempDir()
+	// an old backup that should be removed by the retention policy
+	oldBackup := filepath.Join(dir, "appgate_backup_controller-4c07bc67-57ea-42dd-b702-c2d6c45419fc-site1_20200101_020000.bkp")
+	if err := os.WriteFile(oldBackup, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &factory.Factory{
+		Config: &configuration.Config{
+			Debug: false,
+			URL:   fmt.Sprintf("http://appgate.test:%d", registry.Port),
+		},
+		IOOutWriter: io.Discard,
+	}
+	f.APIClient = func(c *configuration.Config) (*openapi.APIClient, error) {
+		return registry.Client, nil
+	}
+	f.Appliance = func(c *configuration.Config) (*appliance.Appliance, error) {
+		api, _ := f.APIClient(c)
+		a := &appliance.Appliance{
+			APIClient:  api,
+			HTTPClient: api.GetConfig().HTTPClient,
+			Token:      "",
+		}
+		return a, nil
+	}
+
+	opts := &scheduleOptions{
+		backup: appliance.BackupOpts{
+			Config:        f.Config,
+			Out:           io.Discard,
+			SpinnerOut:    func() io.Writer { return io.Discard },
+			Appliance:     f.Appliance,
+			Destination:   dir,
+			PrimaryFlag:   true,
+			NoInteractive: true,
+			Quiet:         true,
+		},
+		f:          f,
+		retention:  1,
+		statusFile: filepath.Join(dir, "status.json"),
+		status:     &scheduleStatus{Schedule: "@daily", Destination: dir},
+	}
+	cmd := &cobra.Command{}
+	cmd.Flags().Bool("ci-mode", false, "")
+
+	if err := opts.runOnce(cmd, nil); err != nil {
+		t.Fatalf("runOnce() unexpected error %s", err)
+	}
+	if _, err := os.Stat(oldBackup); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed by retention", oldBackup)
+	}
+
+	b, err := os.ReadFile(opts.statusFile)
+	if err != nil {
+		t.Fatalf("status file not written %s", err)
+	}
+	status := map[string]interface{}{}
+	if err := json.Unmarshal(b, &status); err != nil {
+		t.Fatalf("invalid status file %s", err)
+	}
+	if _, ok := status["last_success"]; !ok {
+		t.Errorf("expected last_success in status file, got %s", string(b))
+	}
+	if v := status["consecutive_failures"]; v != float64(0) {
+		t.Errorf("expected 0 consecutive failures, got %v", v)
+	}
+}
+
+func TestBackupScheduleHealthEndpoint(t *testing.T) {
+	status := &scheduleStatus{Schedule: "every 24h0m0s"}
+
+	rec := httptest.NewRecorder()
+	status.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected HTTP 200 before any failures, got %d", rec.Code)
+	}
+
+	status.update(status.Started, nil, nil, fmt.Errorf("controller unreachable"))
+	rec = httptest.NewRecorder()
+	status.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected HTTP 503 after a failed run, got %d", rec.Code)
+	}
+	if !bytes.Contains(rec.Body.Bytes(), []byte("controller unreachable")) {
+		t.Fatalf("expected last error in response, got %s", rec.Body.String())
+	}
+}
+
+func TestBackupScheduleFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "no schedule",
+			args: []string{},
+			want: "one of the '--cron' or '--every' flags is required",
+		},
+		{
+			name: "both cron and every",
+			args: []string{"--cron", "0 2 * * *", "--every", "24h"},
+			want: "the '--cron' and '--every' flags are mutually exclusive",
+		},
+		{
+			name: "negative retention",
+			args: []string{"--every", "24h", "--retention", "-1"},
+			want: "'--retention' must be zero or a positive number",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &factory.Factory{
+				Config:      &configuration.Config{},
+				IOOutWriter: io.Discard,
+			}
+			cmd := NewBackupScheduleCmd(f)
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			_, err := cmd.ExecuteC()
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("expected error %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -2,36 +2,65 @@ package appliance
 
 import (
 	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/tui"
 	"github.com/hashicorp/go-multierror"
 	"github.com/klauspost/compress/zstd"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/appgate/journaldreader/journaldreader"
 )
 
 type logextractOpts struct {
-	Path      string
-	UnzipOnly bool
+	Path        string
+	UnzipOnly   bool
+	Since       string
+	Until       string
+	Identifiers []string
+	Priority    string
+	Grep        string
+	Hostname    string
+	Format      string
+	Fields      []string
+	Merge       bool
+	Workers     int
+	SpinnerOut  func() io.Writer
+	ciMode      bool
 }
 
 func NewExtractLogsCmd(f *factory.Factory) *cobra.Command {
-	opts := logextractOpts{}
+	opts := logextractOpts{
+		SpinnerOut: f.GetSpinnerOutput(),
+	}
 	cmd := &cobra.Command{
 		Use:     "extract-logs",
 		Short:   docs.ApplianceExtractLogsDoc.Short,
 		Long:    docs.ApplianceExtractLogsDoc.Long,
 		Example: docs.ApplianceExtractLogsDoc.ExampleString(),
 		RunE: func(c *cobra.Command, args []string) error {
+			var err error
+			if opts.ciMode, err = c.Flags().GetBool("ci-mode"); err != nil {
+				return err
+			}
 			return logsExtractRun(args, &opts)
 		},
 		Annotations: map[string]string{
@@ -40,154 +69,373 @@ func NewExtractLogsCmd(f *factory.Factory) *cobra.Command {
 	}
 	cmd.Flags().StringVar(&opts.Path, "path", ".", "Optional path to write to")
 	cmd.Flags().BoolVar(&opts.UnzipOnly, "unzip-only", false, "Unzip the archive without processing the journal files")
+	addJournalFilterFlags(cmd.Flags(), &opts)
+	addJournalFormatFlags(cmd.Flags(), &opts)
+	cmd.Flags().BoolVar(&opts.Merge, "merge", false, "Write all journal entries into a single file instead of one file per daemon")
+	cmd.Flags().IntVar(&opts.Workers, "workers", runtime.NumCPU(), "Number of journal files to process in parallel")
 	return cmd
 }
 
+// addJournalFilterFlags adds the flags used to filter journal entries
+func addJournalFilterFlags(flags *pflag.FlagSet, opts *logextractOpts) {
+	flags.StringVar(&opts.Since, "since", "", "Only include entries on or after this time, for example '2024-03-10 14:00:00' (local time), '2024-03-10T14:00:00Z' or '24h' (relative to now)")
+	flags.StringVar(&opts.Until, "until", "", "Only include entries on or before this time, same format as '--since'")
+	flags.StringArrayVar(&opts.Identifiers, "identifier", []string{}, "Only include entries with a matching SYSLOG_IDENTIFIER, can be repeated and accepts regular expressions")
+	flags.StringVar(&opts.Priority, "priority", "", "Only include entries with this priority or higher, for example 'warning' or '4'")
+	flags.StringVar(&opts.Grep, "grep", "", "Only include entries where MESSAGE matches the regular expression")
+	flags.StringVar(&opts.Hostname, "hostname", "", "Only include entries with a matching hostname, accepts regular expressions")
+}
+
+// addJournalFormatFlags adds the flags used to select the output format of journal entries
+func addJournalFormatFlags(flags *pflag.FlagSet, opts *logextractOpts) {
+	flags.StringVar(&opts.Format, "format", journalFormatText, "Output format of the journal entries: "+strings.Join(journalFormats, ", "))
+	flags.StringSliceVar(&opts.Fields, "fields", []string{}, "Comma-separated journal fields to include with '--format csv'")
+}
+
 func logsExtractRun(args []string, opts *logextractOpts) error {
+	if opts.Workers < 1 {
+		return fmt.Errorf("--workers must be at least 1, got %d", opts.Workers)
+	}
+	filter, err := newJournalFilter(opts)
+	if err != nil {
+		return err
+	}
+	format, err := newJournalOutputFormat(opts)
+	if err != nil {
+		return err
+	}
+	e := &journalExtractor{
+		path:      opts.Path,
+		unzipOnly: opts.UnzipOnly,
+		workers:   opts.Workers,
+		filter:    filter,
+		format:    format,
+	}
+	if !opts.ciMode && opts.SpinnerOut != nil {
+		e.progress = tui.New(context.Background(), opts.SpinnerOut())
+		defer e.progress.Wait()
+	}
 	var errs *multierror.Error
 	for i := 0; i < len(args); i++ {
 		log.Infof("Starting processing %s", args[i])
-		if err := processJournalFile(args[i], opts.Path, opts.UnzipOnly); err != nil {
+		if err := e.processJournalFile(args[i]); err != nil {
 			errs = multierror.Append(errs, err)
 		}
 	}
+	if err := e.Close(); err != nil {
+		errs = multierror.Append(errs, err)
+	}
 	return errs.ErrorOrNil()
 }
 
-func processJournalFile(file string, path string, unzipOnly bool) error {
-	const zipfileZstandard uint16 = 93 // Magic number for zstd in zip format
-	r, err := zip.OpenReader(file)
+const (
+	// zipfileZstandard is the magic number for zstd in zip format
+	zipfileZstandard uint16 = 93
+	// journalRecordBuffer is the number of entries each worker can parse ahead of the writer
+	journalRecordBuffer = 4096
+)
+
+// journalExtractor unpacks log bundles and converts the journal files in them
+type journalExtractor struct {
+	path      string
+	unzipOnly bool
+	workers   int
+	filter    *journalFilter
+	format    *journalOutputFormat
+	progress  *tui.Progress
+	// output is shared by all the log bundles, so the entries of each bundle are added to the same files
+	output *journalWriter
+}
+
+// Close closes the files written by all the log bundles
+func (e *journalExtractor) Close() error {
+	if e.output == nil {
+		return nil
+	}
+	err := e.output.Close()
+	e.output = nil
+	return err
+}
+
+// journalRecord is a formatted journal entry, ready to be written
+type journalRecord struct {
+	identifier string
+	line       []byte
+}
+
+// journalHeader is the part of the journal file header used to sort the journal files,
+// in the same way as journaldreader.SortJournalFiles
+type journalHeader struct {
+	file            *zip.File
+	seqnumID        [16]byte
+	headEntrySeqnum uint64
+	// headEntryRealtime is the time of the first entry in the file, zero if the file is empty
+	headEntryRealtime time.Time
+}
+
+// readJournalHeader reads the header of a journal file in the zip archive, without extracting the whole file
+func readJournalHeader(f *zip.File) (journalHeader, error) {
+	h := journalHeader{file: f}
+	rc, err := f.Open()
 	if err != nil {
-		return err
+		return h, err
 	}
-	defer r.Close()
+	defer rc.Close()
+	buf := make([]byte, journaldreader.HEADER_SIZE)
+	if _, err := io.ReadFull(rc, buf); err != nil {
+		return h, fmt.Errorf("could not read journal header: %w", err)
+	}
+	if string(buf[0:8]) != "LPKSHHRH" {
+		return h, errors.New("not a journal file")
+	}
+	for i := range h.seqnumID {
+		h.seqnumID[i] = buf[72+i]
+	}
+	h.headEntrySeqnum = binary.LittleEndian.Uint64(buf[168:176])
+	if usec := binary.LittleEndian.Uint64(buf[184:192]); usec > 0 {
+		h.headEntryRealtime = time.UnixMicro(int64(usec)).UTC()
+	}
+	return h, nil
+}
+
+func sortJournalHeaders(headers []journalHeader) {
+	sort.SliceStable(headers, func(i, j int) bool {
+		if c := bytes.Compare(headers[i].seqnumID[:], headers[j].seqnumID[:]); c != 0 {
+			return c < 0
+		}
+		return headers[i].headEntrySeqnum < headers[j].headEntrySeqnum
+	})
+}
 
+// openLogBundle opens a log bundle .zip file, which may be compressed with zstd
+func openLogBundle(file string) (*zip.ReadCloser, error) {
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
 	r.RegisterDecompressor(zipfileZstandard, func(in io.Reader) io.ReadCloser {
 		dec, _ := zstd.NewReader(in)
-		return io.NopCloser(dec)
+		return dec.IOReadCloser()
 	})
+	return r, nil
+}
 
-	var extractedFiles []string
+// processJournalFile unpacks the log bundle and converts the journal files in it.
+// The journal files are read by a bounded number of workers in parallel, while the entries are written
+// in the same order as the journal files are sorted. Only the journal files that are being read are
+// extracted to temporary files, since journaldreader needs a file to read from.
+func (e *journalExtractor) processJournalFile(file string) error {
+	r, err := openLogBundle(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
 
-	// Iterate through the files in the archive,
-	// printing some of their contents.
+	var journals []journalHeader
 	for _, f := range r.File {
-		if strings.HasSuffix(f.Name, ".journal") && !unzipOnly {
-			log.Infof("Extracting journal file %s", f.Name)
-
-			rc, err := f.Open()
-			if err != nil {
-				return err
-			}
-
-			extracted, err := os.CreateTemp("", "")
+		if strings.HasSuffix(f.Name, ".journal") && !e.unzipOnly {
+			h, err := readJournalHeader(f)
 			if err != nil {
-				return err
+				log.WithError(err).Warnf("Skipping journal file %s", f.Name)
+				continue
 			}
-			extractedFiles = append(extractedFiles, extracted.Name())
-
-			_, err = io.Copy(extracted, rc)
-			if err != nil {
-				return err
-			}
-			rc.Close()
-			extracted.Close()
-		} else {
-			log.Infof("Extracting file %s", f.Name)
-			rc, err := f.Open()
-			if err != nil {
-				return err
+			journals = append(journals, h)
+			continue
+		}
+		log.Infof("Extracting file %s", f.Name)
+		if err := extractZipFile(f, e.path); err != nil {
+			return err
+		}
+	}
+	if len(journals) == 0 {
+		return nil
+	}
+	sortJournalHeaders(journals)
+
+	log.Infof("Processing %d journal files with %d workers...", len(journals), e.workers)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		total   atomic.Int64
+		written int
+		sem     = make(chan struct{}, e.workers)
+		results = make([]chan journalRecord, len(journals))
+		errs    = make([]error, len(journals))
+	)
+	for i := range journals {
+		results[i] = make(chan journalRecord, journalRecordBuffer)
+	}
+	// The workers are started in the same order as the entries are written,
+	// so the writer never waits for a journal file that has no worker.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, h := range journals {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
 			}
+			wg.Add(1)
+			go func(i int, h journalHeader) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				defer close(results[i])
+				errs[i] = e.readJournal(ctx, h.file, results[i], &total)
+			}(i, h)
+		}
+	}()
 
-			// Create the subdirectory tree
-			if strings.Contains(f.Name, "/") {
-				parts := strings.Split(f.Name, "/")
-				parts = parts[:len(parts)-1]
-				prevpath := path
-
-				for _, part := range parts {
-					dirpath := filepath.Join(prevpath, part)
-
-					err = os.Mkdir(dirpath, os.ModePerm)
-					if err != nil && !os.IsExist(err) {
-						return err
-					}
+	if e.output == nil {
+		e.output = newJournalWriter(e.path, e.format)
+	}
+	output := e.output
+	err = func() error {
+		for i := range journals {
+			for record := range results[i] {
+				if err := output.write(record.identifier, record.line); err != nil {
+					return err
 				}
+				written++
 			}
-			extracted, err := os.Create(filepath.Join(path, f.Name))
-			if err != nil {
-				return err
-			}
-
-			_, err = io.Copy(extracted, rc)
-			if err != nil {
-				return err
+			if errs[i] != nil {
+				return errs[i]
 			}
-			rc.Close()
-			extracted.Close()
 		}
+		return nil
+	}()
+	// stop the workers and wait for them to remove their temporary files before returning
+	cancel()
+	wg.Wait()
+	if err != nil {
+		return err
 	}
+	if e.filter != nil {
+		log.Infof("Wrote %d of %d journal entries matching the filters", written, total.Load())
+	}
+	return nil
+}
 
-	// Sort the extracted journald files
-	extractedFiles = journaldreader.SortJournalFiles(extractedFiles)
-
-	log.Infof("Decompressing complete. Processing...")
-
-	textlogs := make(map[string]*os.File)
-
-	// Parse the extracted files
-	for _, journalfile := range extractedFiles {
-		j := journaldreader.SdjournalReader{}
+// readJournal sends the formatted entries of a journal file that match the filter to out
+func (e *journalExtractor) readJournal(ctx context.Context, f *zip.File, out chan<- journalRecord, total *atomic.Int64) error {
+	return walkJournal(f, e.progress, func(entry map[string]string) error {
+		total.Add(1)
+		if !e.filter.match(entry) {
+			return nil
+		}
+		line, err := e.format.encode(entry)
+		if err != nil {
+			return err
+		}
+		select {
+		case out <- journalRecord{identifier: entry["SYSLOG_IDENTIFIER"], line: line}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
 
-		log.Infof("Processing %s...", journalfile)
+// walkJournal extracts a single journal file to a temporary file, since journaldreader needs a file
+// to read from, and calls fn for each entry. The temporary file is always removed before returning.
+func walkJournal(f *zip.File, progress *tui.Progress, fn func(entry map[string]string) error) error {
+	log.Infof("Extracting journal file %s", f.Name)
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
 
-		err := j.Open(journalfile)
+	var tracker *tui.ProcessTracker
+	var reader io.Reader = rc
+	if progress != nil {
+		reader, tracker = progress.FileProcessProgress(filepath.Base(f.Name), "entries", "done", int64(f.UncompressedSize64), rc)
+	}
+	err = func() error {
+		extracted, err := os.CreateTemp("", "sdpctl-*.journal")
 		if err != nil {
-			continue
+			return err
+		}
+		defer os.Remove(extracted.Name())
+		if _, err := io.Copy(extracted, reader); err != nil {
+			extracted.Close()
+			return err
+		}
+		if err := extracted.Close(); err != nil {
+			return err
 		}
 
+		log.Infof("Processing %s...", f.Name)
+		j := journaldreader.SdjournalReader{}
+		if err := j.Open(extracted.Name()); err != nil {
+			log.WithError(err).Warnf("Could not open journal file %s", f.Name)
+			return nil
+		}
+		defer j.Close()
 		for {
 			entry, hasnext, err := j.Next()
 			if err != nil {
-				// We just move to the next log file in case of error
-				break
+				// We just move to the next log file in case of error,
+				// which is also how journaldreader signals the end of the file
+				log.WithError(err).Debugf("Stopped reading journal file %s", f.Name)
+				return nil
 			}
 			if !hasnext {
-				break
+				return nil
 			}
-
-			identifier, exists := entry["SYSLOG_IDENTIFIER"]
-			if !exists {
-				identifier = "uncategorised_entries"
+			if tracker != nil {
+				tracker.Increment()
 			}
-
-			logfile, exists := textlogs[identifier]
-			if !exists {
-				// Write per-daemon logs into a logs_by_daemon/ subdirectory,
-				// matching the layout the server produces with --process-logs.
-				daemonDir := filepath.Join(path, "logs_by_daemon")
-				if err := os.MkdirAll(daemonDir, os.ModePerm); err != nil {
-					return err
-				}
-				logfile, err := os.Create(filepath.Join(daemonDir, identifier+".log"))
-				if err != nil {
-					return err
-				}
-				defer logfile.Close()
-				textlogs[identifier] = logfile
+			if err := fn(entry); err != nil {
+				return err
 			}
-
-			logfile.WriteString(formatEntry(entry))
 		}
-
-		j.Close()
-		err = os.Remove(journalfile)
+	}()
+	if tracker != nil {
 		if err != nil {
-			return err
+			tracker.Abort()
+		} else {
+			tracker.Done()
 		}
 	}
+	return err
+}
 
-	return nil
+// extractZipFile writes a file from the zip archive to path, creating the subdirectory tree
+func extractZipFile(f *zip.File, path string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// Create the subdirectory tree
+	if strings.Contains(f.Name, "/") {
+		parts := strings.Split(f.Name, "/")
+		parts = parts[:len(parts)-1]
+		prevpath := path
+
+		for _, part := range parts {
+			dirpath := filepath.Join(prevpath, part)
+
+			err = os.Mkdir(dirpath, os.ModePerm)
+			if err != nil && !os.IsExist(err) {
+				return err
+			}
+		}
+	}
+	extracted, err := os.Create(filepath.Join(path, f.Name))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(extracted, rc); err != nil {
+		extracted.Close()
+		return err
+	}
+	return extracted.Close()
 }
 
 /*
@@ -0,0 +1,214 @@
+package appliance
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// journalPriorities are the syslog priority levels, in the same order as journalctl
+var journalPriorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// journalFilter decides which journal entries are written by extract-logs.
+// A nil *journalFilter matches all entries.
+type journalFilter struct {
+	since, until time.Time
+	identifiers  []*regexp.Regexp
+	priority     int
+	grep         *regexp.Regexp
+	hostname     *regexp.Regexp
+}
+
+// newJournalFilter returns a journalFilter from the extract-logs flags,
+// or nil if no filter flags are set.
+func newJournalFilter(opts *logextractOpts) (*journalFilter, error) {
+	f := &journalFilter{priority: -1}
+	active := false
+	var err error
+	if len(opts.Since) > 0 {
+		if f.since, err = parseJournalTime(opts.Since); err != nil {
+			return nil, fmt.Errorf("invalid --since value: %w", err)
+		}
+		active = true
+	}
+	if len(opts.Until) > 0 {
+		if f.until, err = parseJournalTime(opts.Until); err != nil {
+			return nil, fmt.Errorf("invalid --until value: %w", err)
+		}
+		active = true
+	}
+	if !f.since.IsZero() && !f.until.IsZero() && f.until.Before(f.since) {
+		return nil, fmt.Errorf("--until %s is before --since %s", opts.Until, opts.Since)
+	}
+	for _, identifier := range opts.Identifiers {
+		regex, err := anchoredRegexp(identifier)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --identifier value: %w", err)
+		}
+		f.identifiers = append(f.identifiers, regex)
+		active = true
+	}
+	if len(opts.Priority) > 0 {
+		if f.priority, err = parseJournalPriority(opts.Priority); err != nil {
+			return nil, err
+		}
+		active = true
+	}
+	if len(opts.Grep) > 0 {
+		if f.grep, err = regexp.Compile(opts.Grep); err != nil {
+			return nil, fmt.Errorf("invalid --grep value: %w", err)
+		}
+		active = true
+	}
+	if len(opts.Hostname) > 0 {
+		if f.hostname, err = anchoredRegexp(opts.Hostname); err != nil {
+			return nil, fmt.Errorf("invalid --hostname value: %w", err)
+		}
+		active = true
+	}
+	if !active {
+		return nil, nil
+	}
+	return f, nil
+}
+
+// anchoredRegexp compiles a regular expression that must match the whole value,
+// so that '--identifier sshd' does not match 'sshd-keygen'
+func anchoredRegexp(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+// parseJournalTime accepts RFC3339, a date and time, a date or a duration relative to now, such as '24h'.
+// Timestamps without a zone are in the local time zone, like journalctl.
+func parseJournalTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d.Abs()), nil
+	}
+	layouts := []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not parse %q, expected a timestamp like '2006-01-02 15:04:05' or a duration like '24h'", value)
+}
+
+// parseJournalPriority parses a priority name or number, for example 'warning' or '4'
+func parseJournalPriority(value string) (int, error) {
+	for i, name := range journalPriorities {
+		if strings.EqualFold(value, name) {
+			return i, nil
+		}
+	}
+	if i, err := strconv.Atoi(value); err == nil && i >= 0 && i < len(journalPriorities) {
+		return i, nil
+	}
+	return -1, fmt.Errorf("invalid --priority value %q, expected one of %s or 0-7", value, strings.Join(journalPriorities, ", "))
+}
+
+// entryTime returns the time of a journal entry, see journalEntryTime.
+// The year of the time range is used for entries that only have a SYSLOG_TIMESTAMP.
+func (f *journalFilter) entryTime(entry map[string]string) (time.Time, bool) {
+	year := f.since.Year()
+	if f.since.IsZero() {
+		year = f.until.Year()
+	}
+	return journalEntryTime(entry, year)
+}
+
+// journalEntryTime returns the time of a journal entry. journaldreader does not expose the
+// realtime timestamp of the entry object, so _SOURCE_REALTIME_TIMESTAMP is used and
+// SYSLOG_TIMESTAMP is the fallback, which does not include the year.
+func journalEntryTime(entry map[string]string, year int) (time.Time, bool) {
+	if t, ok := sourceRealtime(entry); ok {
+		return t, true
+	}
+	if v, ok := entry["SYSLOG_TIMESTAMP"]; ok {
+		t, err := time.ParseInLocation(time.Stamp, strings.TrimSpace(v), time.UTC)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t.AddDate(year, 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// sourceRealtime returns the _SOURCE_REALTIME_TIMESTAMP of a journal entry, if it has one
+func sourceRealtime(entry map[string]string) (time.Time, bool) {
+	v, ok := entry["_SOURCE_REALTIME_TIMESTAMP"]
+	if !ok {
+		return time.Time{}, false
+	}
+	usec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMicro(usec).UTC(), true
+}
+
+// match reports whether the entry passes all the filters
+func (f *journalFilter) match(entry map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	if f.hasTimeRange() {
+		t, ok := f.entryTime(entry)
+		if !ok || !f.inRange(t) {
+			return false
+		}
+	}
+	return f.matchFields(entry)
+}
+
+func (f *journalFilter) hasTimeRange() bool {
+	return f != nil && (!f.since.IsZero() || !f.until.IsZero())
+}
+
+// inRange reports whether t is within --since and --until
+func (f *journalFilter) inRange(t time.Time) bool {
+	if f == nil {
+		return true
+	}
+	if !f.since.IsZero() && t.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && t.After(f.until) {
+		return false
+	}
+	return true
+}
+
+// matchFields reports whether the entry passes all the filters, except the time range
+func (f *journalFilter) matchFields(entry map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.identifiers) > 0 {
+		identifier := entry["SYSLOG_IDENTIFIER"]
+		matched := false
+		for _, regex := range f.identifiers {
+			if regex.MatchString(identifier) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.priority >= 0 {
+		priority, err := strconv.Atoi(entry["PRIORITY"])
+		if err != nil || priority > f.priority {
+			return false
+		}
+	}
+	if f.grep != nil && !f.grep.MatchString(entry["MESSAGE"]) {
+		return false
+	}
+	if f.hostname != nil && !f.hostname.MatchString(entry["_HOSTNAME"]) {
+		return false
+	}
+	return true
+}
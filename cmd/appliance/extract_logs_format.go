@@ -0,0 +1,268 @@
+package appliance
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	journalFormatText  = "text"
+	journalFormatJSONL = "jsonl"
+	journalFormatCSV   = "csv"
+	journalFormatECS   = "ecs"
+
+	// ecsVersion is the Elastic Common Schema version of the documents written with --format ecs
+	ecsVersion = "8.11.0"
+)
+
+var (
+	journalFormats = []string{journalFormatText, journalFormatJSONL, journalFormatCSV, journalFormatECS}
+
+	defaultJournalCSVFields = []string{"_SOURCE_REALTIME_TIMESTAMP", "SYSLOG_TIMESTAMP", "_HOSTNAME", "SYSLOG_IDENTIFIER", "_PID", "PRIORITY", "MESSAGE"}
+
+	journalFileExtensions = map[string]string{
+		journalFormatText:  ".log",
+		journalFormatJSONL: ".jsonl",
+		journalFormatCSV:   ".csv",
+		journalFormatECS:   ".ndjson",
+	}
+)
+
+// journalOutputFormat describes how extract-logs writes the journal entries.
+// A nil *journalOutputFormat writes journalctl-like text, one file per daemon.
+type journalOutputFormat struct {
+	format string
+	fields []string
+	merge  bool
+}
+
+// newJournalOutputFormat validates the --format, --fields and --merge flags
+func newJournalOutputFormat(opts *logextractOpts) (*journalOutputFormat, error) {
+	o := &journalOutputFormat{
+		format: strings.ToLower(opts.Format),
+		fields: opts.Fields,
+		merge:  opts.Merge,
+	}
+	if len(o.format) == 0 {
+		o.format = journalFormatText
+	}
+	if _, ok := journalFileExtensions[o.format]; !ok {
+		return nil, fmt.Errorf("invalid --format %q, expected one of %s", opts.Format, strings.Join(journalFormats, ", "))
+	}
+	if len(o.fields) > 0 && o.format != journalFormatCSV {
+		return nil, fmt.Errorf("--fields can only be used with --format %s", journalFormatCSV)
+	}
+	if len(o.fields) == 0 {
+		o.fields = defaultJournalCSVFields
+	}
+	return o, nil
+}
+
+// journalWriter writes formatted journal entries, either into one file per daemon in logs_by_daemon/
+// or into a single merged file.
+type journalWriter struct {
+	path   string
+	format *journalOutputFormat
+	files  map[string]*os.File
+}
+
+func newJournalWriter(path string, format *journalOutputFormat) *journalWriter {
+	if format == nil {
+		format = &journalOutputFormat{format: journalFormatText}
+	}
+	return &journalWriter{
+		path:   path,
+		format: format,
+		files:  make(map[string]*os.File),
+	}
+}
+
+// write appends a formatted journal entry to the file it belongs to
+func (w *journalWriter) write(identifier string, line []byte) error {
+	if len(identifier) == 0 {
+		identifier = "uncategorised_entries"
+	}
+	key := identifier
+	if w.format.merge {
+		key = ""
+	}
+	logfile, exists := w.files[key]
+	if !exists {
+		var err error
+		if logfile, err = w.create(key); err != nil {
+			return err
+		}
+		w.files[key] = logfile
+	}
+	_, err := logfile.Write(line)
+	return err
+}
+
+func (w *journalWriter) create(identifier string) (*os.File, error) {
+	extension := journalFileExtensions[w.format.format]
+	name := filepath.Join(w.path, "journal"+extension)
+	if !w.format.merge {
+		// Write per-daemon logs into a logs_by_daemon/ subdirectory,
+		// matching the layout the server produces with --process-logs.
+		daemonDir := filepath.Join(w.path, "logs_by_daemon")
+		if err := os.MkdirAll(daemonDir, os.ModePerm); err != nil {
+			return nil, err
+		}
+		name = filepath.Join(daemonDir, identifier+extension)
+	}
+	logfile, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	if w.format.format == journalFormatCSV {
+		header, err := csvLine(w.format.fields)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := logfile.Write(header); err != nil {
+			logfile.Close()
+			return nil, err
+		}
+	}
+	log.WithField("file", name).Debug("Created log file")
+	return logfile, nil
+}
+
+// Close closes all the files that have been written to
+func (w *journalWriter) Close() error {
+	var errs *multierror.Error
+	for _, f := range w.files {
+		if err := f.Close(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+func (o *journalOutputFormat) encode(entry map[string]string) ([]byte, error) {
+	if o == nil {
+		return []byte(formatEntry(entry)), nil
+	}
+	switch o.format {
+	case journalFormatJSONL:
+		return jsonLine(entry)
+	case journalFormatCSV:
+		record := make([]string, 0, len(o.fields))
+		for _, field := range o.fields {
+			record = append(record, entry[field])
+		}
+		return csvLine(record)
+	case journalFormatECS:
+		return jsonLine(newECSEntry(entry))
+	}
+	return []byte(formatEntry(entry)), nil
+}
+
+// jsonLine encodes v as a single line of JSON, without escaping '<', '>' and '&' in the log messages
+func jsonLine(v interface{}) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func csvLine(record []string) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	w := csv.NewWriter(buffer)
+	if err := w.Write(record); err != nil {
+		return nil, err
+	}
+	w.Flush()
+	return buffer.Bytes(), w.Error()
+}
+
+// ecsEntry is a journal entry mapped to the Elastic Common Schema.
+// All the original journal fields are kept in the journald object.
+type ecsEntry struct {
+	Timestamp string            `json:"@timestamp,omitempty"`
+	Message   string            `json:"message"`
+	ECS       ecsVersionField   `json:"ecs"`
+	Log       ecsLog            `json:"log"`
+	Host      *ecsHost          `json:"host,omitempty"`
+	Process   *ecsProcess       `json:"process,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Journald  map[string]string `json:"journald"`
+}
+
+type ecsVersionField struct {
+	Version string `json:"version"`
+}
+
+type ecsLog struct {
+	Level  string     `json:"level,omitempty"`
+	Logger string     `json:"logger,omitempty"`
+	Syslog *ecsSyslog `json:"syslog,omitempty"`
+}
+
+type ecsSyslog struct {
+	AppName  string      `json:"appname,omitempty"`
+	Severity ecsSeverity `json:"severity"`
+}
+
+type ecsSeverity struct {
+	Code int    `json:"code"`
+	Name string `json:"name"`
+}
+
+type ecsHost struct {
+	Hostname string `json:"hostname"`
+}
+
+type ecsProcess struct {
+	PID         int    `json:"pid,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Executable  string `json:"executable,omitempty"`
+	CommandLine string `json:"command_line,omitempty"`
+}
+
+func newECSEntry(entry map[string]string) ecsEntry {
+	e := ecsEntry{
+		Message:  entry["MESSAGE"],
+		ECS:      ecsVersionField{Version: ecsVersion},
+		Log:      ecsLog{Logger: entry["SYSLOG_IDENTIFIER"]},
+		Journald: entry,
+	}
+	if t, ok := sourceRealtime(entry); ok {
+		e.Timestamp = t.Format(time.RFC3339Nano)
+	}
+	if priority, err := strconv.Atoi(entry["PRIORITY"]); err == nil && priority >= 0 && priority < len(journalPriorities) {
+		e.Log.Level = journalPriorities[priority]
+		e.Log.Syslog = &ecsSyslog{
+			AppName:  entry["SYSLOG_IDENTIFIER"],
+			Severity: ecsSeverity{Code: priority, Name: journalPriorities[priority]},
+		}
+	}
+	if hostname, ok := entry["_HOSTNAME"]; ok {
+		e.Host = &ecsHost{Hostname: hostname}
+	}
+	process := &ecsProcess{
+		Name:        entry["_COMM"],
+		Executable:  entry["_EXE"],
+		CommandLine: entry["_CMDLINE"],
+	}
+	if pid, err := strconv.Atoi(entry["_PID"]); err == nil {
+		process.PID = pid
+	}
+	if *process != (ecsProcess{}) {
+		e.Process = process
+	}
+	return e
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/binary"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestExtractLogsPutsDaemonLogsInSubdir verifies that processJournalFile writes
@@ -48,7 +50,7 @@ func TestExtractLogsPutsDaemonLogsInSubdir(t *testing.T) {
 	zw.Close()
 	zf.Close()
 
-	if err := processJournalFile(zipPath, out, false); err != nil {
+	if err := extractBundles(&journalExtractor{path: out, workers: 1}, zipPath); err != nil {
 		t.Fatalf("processJournalFile: %v", err)
 	}
 
@@ -72,22 +74,437 @@ func TestExtractLogsPutsDaemonLogsInSubdir(t *testing.T) {
 	}
 }
 
+// setLocalTimeZone sets the local time zone used for timestamps without a zone until the test ends
+func setLocalTimeZone(t *testing.T, loc *time.Location) {
+	t.Helper()
+	local := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = local })
+}
+
+func TestJournalFilterMatch(t *testing.T) {
+	setLocalTimeZone(t, time.UTC)
+	entry := map[string]string{
+		"SYSLOG_IDENTIFIER":          "cz-sessiond",
+		"_HOSTNAME":                  "controller.devops",
+		"PRIORITY":                   "3",
+		"MESSAGE":                    "session 42 rejected by policy",
+		"_SOURCE_REALTIME_TIMESTAMP": "1710079200000000", // 2024-03-10 14:00:00 UTC
+	}
+	tests := []struct {
+		name string
+		opts logextractOpts
+		want bool
+	}{
+		{
+			name: "no filters",
+			opts: logextractOpts{},
+			want: true,
+		},
+		{
+			name: "inside time range",
+			opts: logextractOpts{Since: "2024-03-10 13:00", Until: "2024-03-10T15:00:00Z"},
+			want: true,
+		},
+		{
+			name: "before since",
+			opts: logextractOpts{Since: "2024-03-11"},
+			want: false,
+		},
+		{
+			name: "identifier regex",
+			opts: logextractOpts{Identifiers: []string{"sshd", "cz-.*"}},
+			want: true,
+		},
+		{
+			name: "identifier is anchored",
+			opts: logextractOpts{Identifiers: []string{"cz"}},
+			want: false,
+		},
+		{
+			name: "priority threshold",
+			opts: logextractOpts{Priority: "warning"},
+			want: true,
+		},
+		{
+			name: "priority below threshold",
+			opts: logextractOpts{Priority: "crit"},
+			want: false,
+		},
+		{
+			name: "grep message",
+			opts: logextractOpts{Grep: "rejected"},
+			want: true,
+		},
+		{
+			name: "hostname mismatch",
+			opts: logextractOpts{Hostname: "gateway.*"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newJournalFilter(&tt.opts)
+			if err != nil {
+				t.Fatalf("newJournalFilter() unexpected error %s", err)
+			}
+			if got := filter.match(entry); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJournalTimeLocalZone(t *testing.T) {
+	setLocalTimeZone(t, time.FixedZone("EST", -5*60*60))
+	want := time.Date(2024, time.March, 10, 14, 0, 0, 0, time.UTC)
+	for _, value := range []string{"2024-03-10 09:00", "2024-03-10T09:00:00", "2024-03-10T14:00:00Z", "2024-03-10T09:00:00-05:00"} {
+		got, err := parseJournalTime(value)
+		if err != nil {
+			t.Fatalf("parseJournalTime(%q) unexpected error %s", value, err)
+		}
+		if !got.Equal(want) {
+			t.Errorf("parseJournalTime(%q) = %s, want %s", value, got.UTC(), want)
+		}
+	}
+
+	// the entry at 14:00 UTC is before 09:30 in the local time zone
+	filter, err := newJournalFilter(&logextractOpts{Since: "2024-03-10 09:30"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.match(map[string]string{"_SOURCE_REALTIME_TIMESTAMP": "1710079200000000"}) {
+		t.Error("expected the entry to be before --since in the local time zone")
+	}
+}
+
+func TestJournalFilterInvalid(t *testing.T) {
+	tests := []logextractOpts{
+		{Since: "yesterday"},
+		{Since: "2024-03-11", Until: "2024-03-10"},
+		{Priority: "loud"},
+		{Priority: "8"},
+		{Grep: "("},
+	}
+	for _, opts := range tests {
+		if _, err := newJournalFilter(&opts); err == nil {
+			t.Errorf("newJournalFilter(%+v) expected error", opts)
+		}
+	}
+}
+
+func TestExtractLogsFiltersEntries(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+	journalPath := filepath.Join(src, "test.journal")
+	buildMinimalJournal(t, journalPath, "sshd", "accepted publickey")
+	journalBytes, err := os.ReadFile(journalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Join(src, "bundle.zip")
+	zf, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zf)
+	jw, err := zw.Create("abc123/system.journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jw.Write(journalBytes)
+	zw.Close()
+	zf.Close()
+
+	filter, err := newJournalFilter(&logextractOpts{Grep: "publickey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := extractBundles(&journalExtractor{path: out, workers: 1, filter: filter}, zipPath); err != nil {
+		t.Fatalf("processJournalFile: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(out, "logs_by_daemon", "sshd.log"))
+	if err != nil {
+		t.Fatalf("expected logs_by_daemon/sshd.log: %v", err)
+	}
+	if !strings.Contains(string(b), "accepted publickey") {
+		t.Errorf("expected matching entry in sshd.log, got %q", string(b))
+	}
+
+	out = t.TempDir()
+	filter, err = newJournalFilter(&logextractOpts{Identifiers: []string{"cz-.*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := extractBundles(&journalExtractor{path: out, workers: 1, filter: filter}, zipPath); err != nil {
+		t.Fatalf("processJournalFile: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "logs_by_daemon", "sshd.log")); err == nil {
+		t.Errorf("sshd.log should not be written when no entries match the filters")
+	}
+}
+
+func TestExtractLogsFormats(t *testing.T) {
+	src := t.TempDir()
+	journalPath := filepath.Join(src, "test.journal")
+	buildMinimalJournal(t, journalPath, "sshd", "accepted <publickey>")
+	journalBytes, err := os.ReadFile(journalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Join(src, "bundle.zip")
+	zf, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zf)
+	jw, err := zw.Create("abc123/system.journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jw.Write(journalBytes)
+	zw.Close()
+	zf.Close()
+
+	tests := []struct {
+		name string
+		opts logextractOpts
+		file string
+		want string
+	}{
+		{
+			name: "jsonl per daemon",
+			opts: logextractOpts{Format: "jsonl"},
+			file: filepath.Join("logs_by_daemon", "sshd.jsonl"),
+			want: `{"MESSAGE":"accepted <publickey>","SYSLOG_IDENTIFIER":"sshd"}` + "\n",
+		},
+		{
+			name: "csv with selected fields",
+			opts: logextractOpts{Format: "csv", Fields: []string{"SYSLOG_IDENTIFIER", "MESSAGE"}},
+			file: filepath.Join("logs_by_daemon", "sshd.csv"),
+			want: "SYSLOG_IDENTIFIER,MESSAGE\nsshd,accepted <publickey>\n",
+		},
+		{
+			name: "merged text",
+			opts: logextractOpts{Format: "text", Merge: true},
+			file: "journal.log",
+			want: "  sshd[]: accepted <publickey>\n",
+		},
+		{
+			name: "merged ecs",
+			opts: logextractOpts{Format: "ecs", Merge: true},
+			file: "journal.ndjson",
+			want: `{"message":"accepted <publickey>","ecs":{"version":"8.11.0"},"log":{"logger":"sshd"},"journald":{"MESSAGE":"accepted <publickey>","SYSLOG_IDENTIFIER":"sshd"}}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := t.TempDir()
+			format, err := newJournalOutputFormat(&tt.opts)
+			if err != nil {
+				t.Fatalf("newJournalOutputFormat() unexpected error %s", err)
+			}
+			if err := extractBundles(&journalExtractor{path: out, workers: 1, format: format}, zipPath); err != nil {
+				t.Fatalf("processJournalFile: %v", err)
+			}
+			got, err := os.ReadFile(filepath.Join(out, tt.file))
+			if err != nil {
+				t.Fatalf("expected %s: %v", tt.file, err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", string(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractLogsMergesBundles(t *testing.T) {
+	src := t.TempDir()
+	var bundles []string
+	for _, message := range []string{"first", "second"} {
+		journalPath := filepath.Join(src, message+".journal")
+		buildMinimalJournal(t, journalPath, "sshd", message)
+		journalBytes, err := os.ReadFile(journalPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zipPath := filepath.Join(src, message+".zip")
+		zf, err := os.Create(zipPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw := zip.NewWriter(zf)
+		jw, err := zw.Create("abc123/system.journal")
+		if err != nil {
+			t.Fatal(err)
+		}
+		jw.Write(journalBytes)
+		zw.Close()
+		zf.Close()
+		bundles = append(bundles, zipPath)
+	}
+
+	out := t.TempDir()
+	format, err := newJournalOutputFormat(&logextractOpts{Format: "text", Merge: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := extractBundles(&journalExtractor{path: out, workers: 1, format: format}, bundles...); err != nil {
+		t.Fatalf("processJournalFile: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(out, "journal.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "  sshd[]: first\n  sshd[]: second\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", string(got), want)
+	}
+}
+
+func TestJournalOutputFormatInvalid(t *testing.T) {
+	tests := []logextractOpts{
+		{Format: "xml"},
+		{Format: "jsonl", Fields: []string{"MESSAGE"}},
+	}
+	for _, opts := range tests {
+		if _, err := newJournalOutputFormat(&opts); err == nil {
+			t.Errorf("newJournalOutputFormat(%+v) expected error", opts)
+		}
+	}
+}
+
+func TestNewECSEntry(t *testing.T) {
+	entry := map[string]string{
+		"SYSLOG_IDENTIFIER":          "cz-sessiond",
+		"_HOSTNAME":                  "controller.devops",
+		"PRIORITY":                   "3",
+		"MESSAGE":                    "session rejected",
+		"_PID":                       "1234",
+		"_COMM":                      "cz-sessiond",
+		"_SOURCE_REALTIME_TIMESTAMP": "1710079200000000",
+	}
+	got := newECSEntry(entry)
+	if got.Timestamp != "2024-03-10T14:00:00Z" {
+		t.Errorf("@timestamp = %q", got.Timestamp)
+	}
+	if got.Log.Level != "err" || got.Log.Syslog == nil || got.Log.Syslog.Severity.Code != 3 {
+		t.Errorf("unexpected log level %+v", got.Log)
+	}
+	if got.Host == nil || got.Host.Hostname != "controller.devops" {
+		t.Errorf("unexpected host %+v", got.Host)
+	}
+	if got.Process == nil || got.Process.PID != 1234 || got.Process.Name != "cz-sessiond" {
+		t.Errorf("unexpected process %+v", got.Process)
+	}
+}
+
+func TestExtractLogsParallelKeepsJournalOrder(t *testing.T) {
+	src := t.TempDir()
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	// The journal files are added to the zip out of order, and must be processed
+	// in the order of their head entry sequence number.
+	zipPath := filepath.Join(src, "bundle.zip")
+	zf, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zf)
+	for _, j := range []struct {
+		seqnum  uint64
+		message string
+	}{{3, "third"}, {1, "first"}, {2, "second"}} {
+		journalPath := filepath.Join(src, j.message+".journal")
+		buildMinimalJournal(t, journalPath, "sshd", j.message)
+		journalBytes, err := os.ReadFile(journalPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		binary.LittleEndian.PutUint64(journalBytes[168:], j.seqnum) // head_entry_seqnum
+		w, err := zw.Create("abc123/" + j.message + ".journal")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(journalBytes)
+	}
+	zw.Close()
+	zf.Close()
+
+	out := t.TempDir()
+	if err := extractBundles(&journalExtractor{path: out, workers: 3}, zipPath); err != nil {
+		t.Fatalf("processJournalFile: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(out, "logs_by_daemon", "sshd.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "  sshd[]: first\n  sshd[]: second\n  sshd[]: third\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", string(got), want)
+	}
+	assertEmptyDir(t, tmp)
+
+	// writing to a path that is a file fails, but must not leave temporary files behind
+	notADir := filepath.Join(src, "file")
+	if err := os.WriteFile(notADir, []byte("file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := extractBundles(&journalExtractor{path: notADir, workers: 2}, zipPath); err == nil {
+		t.Fatal("expected error when the output path is not a directory")
+	}
+	assertEmptyDir(t, tmp)
+}
+
+// extractBundles processes the log bundles in order and closes the output files, like logsExtractRun
+func extractBundles(e *journalExtractor, bundles ...string) error {
+	defer e.Close()
+	for _, bundle := range bundles {
+		if err := e.processJournalFile(bundle); err != nil {
+			return err
+		}
+	}
+	return e.Close()
+}
+
+func assertEmptyDir(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		t.Errorf("unexpected temporary file left behind: %s", e.Name())
+	}
+}
+
 // buildMinimalJournal creates a minimal valid systemd journal binary file
 // containing a single entry with two fields: SYSLOG_IDENTIFIER and MESSAGE.
+func buildMinimalJournal(t *testing.T, path, identifier, message string) {
+	t.Helper()
+	buildJournal(t, path, "SYSLOG_IDENTIFIER="+identifier, "MESSAGE="+message)
+}
+
+// buildJournal creates a valid systemd journal binary file containing
+// a single entry with the given "NAME=value" fields.
+func buildJournal(t *testing.T, path string, fields ...string) {
+	t.Helper()
+	buildJournalEntries(t, path, fields)
+}
+
+// buildJournalEntries creates a valid systemd journal binary file containing
+// one entry for each list of "NAME=value" fields.
 //
 // The binary layout follows the systemd journal format used by journaldreader:
 //
 //	[  0, 208)  Header
-//	[208, 296)  DataObject  "SYSLOG_IDENTIFIER=<identifier>"
-//	[296, 392)  DataObject  "MESSAGE=<message>"
-//	[392, 488)  EntryObject (2 data items, non-compact 16-byte refs)
-//	[488, 520)  EntryArrayObject (1 entry item, non-compact 8-byte ref)
-func buildMinimalJournal(t *testing.T, path, identifier, message string) {
+//	[208, ...)  One DataObject per field of each entry
+//	[..., ...)  One EntryObject per entry (n data items, non-compact 16-byte refs)
+//	[..., ...)  EntryArrayObject (one item per entry, non-compact 8-byte refs)
+func buildJournalEntries(t *testing.T, path string, entries ...[]string) {
 	t.Helper()
 
-	field1 := []byte("SYSLOG_IDENTIFIER=" + identifier)
-	field2 := []byte("MESSAGE=" + message)
-
 	align8 := func(n int) int { return (n + 7) &^ 7 }
 
 	const (
@@ -101,17 +518,28 @@ func buildMinimalJournal(t *testing.T, path, identifier, message string) {
 	)
 
 	// Calculate sizes and offsets (all 8-byte aligned).
-	data1Off := headerSize
-	data1Padded := align8(dataObjectSize + len(field1))
-
-	data2Off := data1Off + data1Padded
-	data2Padded := align8(dataObjectSize + len(field2))
+	dataOffsets := make([][]int, len(entries))
+	offset := headerSize
+	nObjects := len(entries) + 1
+	for i, fields := range entries {
+		dataOffsets[i] = make([]int, len(fields))
+		for j, field := range fields {
+			dataOffsets[i][j] = offset
+			offset += align8(dataObjectSize + len(field))
+		}
+		nObjects += len(fields)
+	}
 
-	entryOff := data2Off + data2Padded
-	entrySize := entryObjectSize + 2*16 // 2 data refs, 16 bytes each (non-compact)
+	entryOffsets := make([]int, len(entries))
+	entrySizes := make([]int, len(entries))
+	for i, fields := range entries {
+		entryOffsets[i] = offset
+		entrySizes[i] = entryObjectSize + len(fields)*16 // data refs, 16 bytes each (non-compact)
+		offset += align8(entrySizes[i])
+	}
 
-	eaOff := entryOff + entrySize
-	eaSize := entryArrayObjSize + 8 // 1 entry ref, 8 bytes (non-compact)
+	eaOff := offset
+	eaSize := entryArrayObjSize + len(entries)*8 // entry refs, 8 bytes each (non-compact)
 
 	totalSize := eaOff + align8(eaSize)
 	buf := make([]byte, totalSize)
@@ -130,35 +558,38 @@ func buildMinimalJournal(t *testing.T, path, identifier, message string) {
 	binary.LittleEndian.PutUint64(buf[88:], headerSize)                   // header_size
 	binary.LittleEndian.PutUint64(buf[96:], uint64(totalSize-headerSize)) // arena_size
 	binary.LittleEndian.PutUint64(buf[136:], uint64(eaOff))               // tail_object_offset
-	binary.LittleEndian.PutUint64(buf[144:], 4)                           // n_objects
-	binary.LittleEndian.PutUint64(buf[152:], 1)                           // n_entries
-	binary.LittleEndian.PutUint64(buf[160:], 1)                           // tail_entry_seqnum
+	binary.LittleEndian.PutUint64(buf[144:], uint64(nObjects))            // n_objects
+	binary.LittleEndian.PutUint64(buf[152:], uint64(len(entries)))        // n_entries
+	binary.LittleEndian.PutUint64(buf[160:], uint64(len(entries)))        // tail_entry_seqnum
 	binary.LittleEndian.PutUint64(buf[168:], 1)                           // head_entry_seqnum
 	binary.LittleEndian.PutUint64(buf[176:], uint64(eaOff))               // entry_array_offset
 
-	// ---- DataObject 1: SYSLOG_IDENTIFIER ----
-	buf[data1Off] = typeData
-	binary.LittleEndian.PutUint64(buf[data1Off+8:], uint64(dataObjectSize+len(field1)))
-	bytecopy(buf[data1Off+dataObjectSize:], field1)
-
-	// ---- DataObject 2: MESSAGE ----
-	buf[data2Off] = typeData
-	binary.LittleEndian.PutUint64(buf[data2Off+8:], uint64(dataObjectSize+len(field2)))
-	bytecopy(buf[data2Off+dataObjectSize:], field2)
-
-	// ---- EntryObject (2 data items) ----
-	buf[entryOff] = typeEntry
-	binary.LittleEndian.PutUint64(buf[entryOff+8:], uint64(entrySize))
-	binary.LittleEndian.PutUint64(buf[entryOff+16:], 1) // seqnum
-	// Items at entryOff+64: each is [offset uint64, hash uint64]
-	binary.LittleEndian.PutUint64(buf[entryOff+64:], uint64(data1Off))
-	binary.LittleEndian.PutUint64(buf[entryOff+80:], uint64(data2Off))
-
-	// ---- EntryArrayObject (1 entry) ----
+	for i, fields := range entries {
+		// ---- DataObjects ----
+		for j, field := range fields {
+			buf[dataOffsets[i][j]] = typeData
+			binary.LittleEndian.PutUint64(buf[dataOffsets[i][j]+8:], uint64(dataObjectSize+len(field)))
+			bytecopy(buf[dataOffsets[i][j]+dataObjectSize:], []byte(field))
+		}
+
+		// ---- EntryObject ----
+		entryOff := entryOffsets[i]
+		buf[entryOff] = typeEntry
+		binary.LittleEndian.PutUint64(buf[entryOff+8:], uint64(entrySizes[i]))
+		binary.LittleEndian.PutUint64(buf[entryOff+16:], uint64(i+1)) // seqnum
+		// Items at entryOff+64: each is [offset uint64, hash uint64]
+		for j := range fields {
+			binary.LittleEndian.PutUint64(buf[entryOff+64+j*16:], uint64(dataOffsets[i][j]))
+		}
+	}
+
+	// ---- EntryArrayObject ----
 	buf[eaOff] = typeEntryArray
 	binary.LittleEndian.PutUint64(buf[eaOff+8:], uint64(eaSize))
 	// next_entry_array_offset at eaOff+16: 0 (no continuation)
-	binary.LittleEndian.PutUint64(buf[eaOff+24:], uint64(entryOff))
+	for i, entryOff := range entryOffsets {
+		binary.LittleEndian.PutUint64(buf[eaOff+24+i*8:], uint64(entryOff))
+	}
 
 	if err := os.WriteFile(path, buf, 0644); err != nil {
 		t.Fatal(err)
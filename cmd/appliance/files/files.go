@@ -4,6 +4,7 @@ import (
 	"io"
 
 	"github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
@@ -14,7 +15,7 @@ type FilesOptions struct {
 	Config     *configuration.Config
 	Appliance  func(c *configuration.Config) (*appliance.Appliance, error)
 	Out        io.Writer
-	JSON       bool
+	Output     *cmdutil.OutputOptions
 	OrderBy    []string
 	Descending bool
 }
@@ -1,6 +1,7 @@
 package files
 
 import (
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
 	"github.com/appgate/sdpctl/pkg/util"
@@ -31,6 +32,9 @@ func NewFilesListCmd(f *factory.Factory) *cobra.Command {
 			if err != nil {
 				errs = multierror.Append(errs, err)
 			}
+			if err := opts.Output.Validate(); err != nil {
+				errs = multierror.Append(errs, err)
+			}
 			return errs.ErrorOrNil()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -45,22 +49,15 @@ func NewFilesListCmd(f *factory.Factory) *cobra.Command {
 				return err
 			}
 
-			if opts.JSON {
-				return util.PrintJSON(opts.Out, files)
-			}
-
-			p := util.NewPrinter(opts.Out, 4)
-			p.AddHeader("Name", "Status", "Created", "Modified", "Failure Reason")
+			t := cmdutil.NewTable("Name", "Status", "Created", "Modified", "Failure Reason")
 			for _, file := range files {
-				p.AddLine(file.GetName(), file.GetStatus(), file.GetCreationTime(), file.GetLastModifiedTime(), file.GetFailureReason())
+				t.AddRow(file.GetName(), file.GetStatus(), file.GetCreationTime(), file.GetLastModifiedTime(), file.GetFailureReason())
 			}
-			p.Print()
-
-			return nil
+			return opts.Output.Print(opts.Out, files, t)
 		},
 	}
 
-	listCmd.Flags().BoolVar(&opts.JSON, "json", false, "output in json format")
+	opts.Output = cmdutil.AddOutputFlags(listCmd, cmdutil.OutputTable)
 
 	return listCmd
 }
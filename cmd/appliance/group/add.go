@@ -0,0 +1,65 @@
+package group
+
+import (
+	"fmt"
+
+	appliancepkg "github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// NewAddCmd return a new appliance group add command
+func NewAddCmd(opts *commandOpts) *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:     "add <name>",
+		Short:   docs.ApplianceGroupAddDoc.Short,
+		Long:    docs.ApplianceGroupAddDoc.Long,
+		Example: docs.ApplianceGroupAddDoc.ExampleString(),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			return addRun(c, args, opts, force)
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "Replace the appliance group if it already exists")
+	return cmd
+}
+
+func addRun(cmd *cobra.Command, args []string, opts *commandOpts, force bool) error {
+	if cmd.Flags().Changed("group") {
+		return fmt.Errorf("'--group' can't be used when adding an appliance group")
+	}
+	filter, _, _ := util.ParseFilteringFlags(cmd.Flags(), appliancepkg.DefaultCommandFilter)
+	group := configuration.ApplianceGroup{
+		Name:    args[0],
+		Include: filter["include"],
+		Exclude: filter["exclude"],
+		Filter:  filter[util.FilterExpressionKey][util.FilterExpressionKey],
+	}
+	if err := validateGroup(group); err != nil {
+		return err
+	}
+
+	groups := make([]configuration.ApplianceGroup, 0, len(opts.Config.ApplianceGroups)+1)
+	replaced := false
+	for _, g := range opts.Config.ApplianceGroups {
+		if g.Name == group.Name {
+			if !force {
+				return fmt.Errorf("appliance group '%s' already exists, use '--force' to replace it", group.Name)
+			}
+			g = group
+			replaced = true
+		}
+		groups = append(groups, g)
+	}
+	if !replaced {
+		groups = append(groups, group)
+	}
+	if err := opts.SaveGroups(groups); err != nil {
+		return err
+	}
+	fmt.Fprintf(opts.Out, "Saved appliance group '%s'\n", group.Name)
+	return nil
+}
@@ -0,0 +1,47 @@
+package group
+
+import (
+	"fmt"
+
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// NewDeleteCmd return a new appliance group delete command
+func NewDeleteCmd(opts *commandOpts) *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete <name>...",
+		Aliases: []string{"rm"},
+		Short:   docs.ApplianceGroupDeleteDoc.Short,
+		Long:    docs.ApplianceGroupDeleteDoc.Long,
+		Example: docs.ApplianceGroupDeleteDoc.ExampleString(),
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			return deleteRun(args, opts)
+		},
+		ValidArgsFunction: TabCompletion(opts.Config),
+	}
+}
+
+func deleteRun(args []string, opts *commandOpts) error {
+	for _, name := range args {
+		if _, err := opts.Config.GetApplianceGroup(name); err != nil {
+			return err
+		}
+	}
+	groups := make([]configuration.ApplianceGroup, 0, len(opts.Config.ApplianceGroups))
+	for _, g := range opts.Config.ApplianceGroups {
+		if !util.InSlice(g.Name, args) {
+			groups = append(groups, g)
+		}
+	}
+	if err := opts.SaveGroups(groups); err != nil {
+		return err
+	}
+	for _, name := range args {
+		fmt.Fprintf(opts.Out, "Deleted appliance group '%s'\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package group
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// NewExportCmd return a new appliance group export command
+func NewExportCmd(opts *commandOpts) *cobra.Command {
+	var path string
+	cmd := &cobra.Command{
+		Use:     "export [name...]",
+		Short:   docs.ApplianceGroupExportDoc.Short,
+		Long:    docs.ApplianceGroupExportDoc.Long,
+		Example: docs.ApplianceGroupExportDoc.ExampleString(),
+		RunE: func(c *cobra.Command, args []string) error {
+			return exportRun(args, opts, path)
+		},
+		ValidArgsFunction: TabCompletion(opts.Config),
+	}
+	cmd.Flags().StringVar(&path, "file", "", "Path of the JSON file to write the appliance groups to. The groups are printed if it's not set")
+	return cmd
+}
+
+func exportRun(args []string, opts *commandOpts, path string) error {
+	groups := make([]configuration.ApplianceGroup, 0, len(opts.Config.ApplianceGroups))
+	if len(args) == 0 {
+		groups = append(groups, opts.Config.ApplianceGroups...)
+	}
+	for _, name := range args {
+		g, err := opts.Config.GetApplianceGroup(name)
+		if err != nil {
+			return err
+		}
+		groups = append(groups, *g)
+	}
+
+	var out io.Writer = opts.Out
+	if len(path) > 0 {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := util.PrintJSON(out, groups); err != nil {
+		return err
+	}
+	if len(path) > 0 {
+		fmt.Fprintf(opts.Out, "Exported %d appliance groups to %s\n", len(groups), path)
+	}
+	return nil
+}
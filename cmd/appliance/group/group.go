@@ -0,0 +1,73 @@
+package group
+
+import (
+	"fmt"
+	"io"
+
+	appliancepkg "github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/spf13/cobra"
+)
+
+type commandOpts struct {
+	Config *configuration.Config
+	Out    io.Writer
+	// SaveGroups writes the appliance groups to the profile configuration
+	SaveGroups func(groups []configuration.ApplianceGroup) error
+}
+
+// NewGroupCmd return a new appliance group subcommand
+func NewGroupCmd(f *factory.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "group",
+		Aliases: []string{"groups"},
+		Annotations: map[string]string{
+			configuration.SkipAuthCheck: "true",
+		},
+		TraverseChildren: true,
+		Short:            docs.ApplianceGroupRootDoc.Short,
+		Long:             docs.ApplianceGroupRootDoc.Long,
+	}
+	opts := &commandOpts{
+		Config: f.Config,
+		Out:    f.IOOutWriter,
+		SaveGroups: func(groups []configuration.ApplianceGroup) error {
+			return f.Config.SaveApplianceGroups(groups)
+		},
+	}
+	cmd.AddCommand(NewAddCmd(opts))
+	cmd.AddCommand(NewListCmd(opts))
+	cmd.AddCommand(NewDeleteCmd(opts))
+	cmd.AddCommand(NewExportCmd(opts))
+	cmd.AddCommand(NewImportCmd(opts))
+
+	return cmd
+}
+
+// TabCompletion completes the names of the appliance groups in the profile
+func TabCompletion(cfg *configuration.Config) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if cfg == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return cfg.ApplianceGroupNames(), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// validateGroup checks that the group has a name, selects something and has a valid filter expression
+func validateGroup(g configuration.ApplianceGroup) error {
+	if len(g.Name) == 0 {
+		return fmt.Errorf("appliance group name is required")
+	}
+	if g.IsEmpty() {
+		return fmt.Errorf("appliance group '%s' needs at least one of '--include', '--exclude' or '--filter'", g.Name)
+	}
+	if len(g.Filter) > 0 {
+		if _, err := appliancepkg.ParseFilterExpression(g.Filter); err != nil {
+			return fmt.Errorf("appliance group '%s': %w", g.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package group
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+)
+
+func testOpts(groups ...configuration.ApplianceGroup) (*commandOpts, *bytes.Buffer) {
+	stdout := &bytes.Buffer{}
+	cfg := &configuration.Config{ApplianceGroups: groups}
+	return &commandOpts{
+		Config: cfg,
+		Out:    stdout,
+		SaveGroups: func(groups []configuration.ApplianceGroup) error {
+			cfg.ApplianceGroups = groups
+			return nil
+		},
+	}, stdout
+}
+
+// withFilterFlags adds the persistent flags of the appliance command
+func withFilterFlags(cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().StringToStringP("include", "i", map[string]string{}, "")
+	cmd.Flags().StringToStringP("exclude", "e", map[string]string{}, "")
+	cmd.Flags().String("filter", "", "")
+	cmd.Flags().String("group", "", "")
+	return cmd
+}
+
+func execute(cmd *cobra.Command, args ...string) error {
+	cmd.SetArgs(args)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	_, err := cmd.ExecuteC()
+	return err
+}
+
+func TestGroupAdd(t *testing.T) {
+	existing := configuration.ApplianceGroup{Name: "canaries", Include: map[string]string{"tag": "canary"}}
+	tests := []struct {
+		name    string
+		args    []string
+		want    []configuration.ApplianceGroup
+		wantErr string
+	}{
+		{
+			name: "add group",
+			args: []string{"gateways", "--include", "function=gateway", "--exclude", "name=gw2", "--filter", "sessions < 10"},
+			want: []configuration.ApplianceGroup{
+				existing,
+				{
+					Name:    "gateways",
+					Include: map[string]string{"function": "gateway"},
+					Exclude: map[string]string{"name": "gw2"},
+					Filter:  "sessions < 10",
+				},
+			},
+		},
+		{
+			name: "replace group",
+			args: []string{"canaries", "--include", "tag=canary&beta", "--force"},
+			want: []configuration.ApplianceGroup{
+				{Name: "canaries", Include: map[string]string{"tag": "canary&beta"}, Exclude: map[string]string{}},
+			},
+		},
+		{
+			name:    "group exists",
+			args:    []string{"canaries", "--include", "tag=beta"},
+			wantErr: "appliance group 'canaries' already exists, use '--force' to replace it",
+		},
+		{
+			name:    "empty group",
+			args:    []string{"empty"},
+			wantErr: "appliance group 'empty' needs at least one of '--include', '--exclude' or '--filter'",
+		},
+		{
+			name:    "invalid filter",
+			args:    []string{"invalid", "--filter", "cpu = 10"},
+			wantErr: "appliance group 'invalid': invalid filter expression: unexpected character '=', did you mean '=='? at position 5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, _ := testOpts(existing)
+			err := execute(withFilterFlags(NewAddCmd(opts)), tt.args...)
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, opts.Config.ApplianceGroups); diff != "" {
+				t.Errorf("groups mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGroupListAndDelete(t *testing.T) {
+	opts, stdout := testOpts(
+		configuration.ApplianceGroup{Name: "canaries", Include: map[string]string{"tag": "canary"}},
+		configuration.ApplianceGroup{Name: "gateways", Include: map[string]string{"site": "stockholm", "function": "gateway"}, Filter: "cpu > 50"},
+	)
+	if err := execute(NewListCmd(opts), "--output", "csv"); err != nil {
+		t.Fatal(err)
+	}
+	want := `Name,Include,Exclude,Filter
+canaries,tag=canary,,
+gateways,"function=gateway,site=stockholm",,cpu > 50
+`
+	if diff := cmp.Diff(want, stdout.String()); diff != "" {
+		t.Errorf("list output mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := execute(NewDeleteCmd(opts), "portals"); err == nil {
+		t.Fatal("expected an error when deleting an unknown group")
+	}
+	if err := execute(NewDeleteCmd(opts), "canaries"); err != nil {
+		t.Fatal(err)
+	}
+	if names := opts.Config.ApplianceGroupNames(); len(names) != 1 || names[0] != "gateways" {
+		t.Errorf("expected only 'gateways' to remain, got %v", names)
+	}
+}
+
+func TestGroupExportImport(t *testing.T) {
+	groups := []configuration.ApplianceGroup{
+		{Name: "canaries", Include: map[string]string{"tag": "canary"}},
+		{Name: "gateways", Include: map[string]string{"function": "gateway"}, Exclude: map[string]string{"name": "gw2"}},
+	}
+	source, _ := testOpts(groups...)
+	path := filepath.Join(t.TempDir(), "groups.json")
+	if err := execute(NewExportCmd(source), "--file", path); err != nil {
+		t.Fatal(err)
+	}
+
+	target, stdout := testOpts(configuration.ApplianceGroup{Name: "busy", Filter: "sessions > 1000"})
+	if err := execute(NewImportCmd(target), path); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), "Imported 2 appliance groups") {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+	want := append([]configuration.ApplianceGroup{{Name: "busy", Filter: "sessions > 1000"}}, groups...)
+	if diff := cmp.Diff(want, target.Config.ApplianceGroups); diff != "" {
+		t.Errorf("imported groups mismatch (-want +got):\n%s", diff)
+	}
+
+	err := execute(NewImportCmd(target), path)
+	if err == nil || !strings.Contains(err.Error(), "appliance group 'canaries' already exists, use '--force' to replace it") {
+		t.Fatalf("expected an error for existing groups, got %v", err)
+	}
+	if err := execute(NewImportCmd(target), path, "--force"); err != nil {
+		t.Fatal(err)
+	}
+	if len(target.Config.ApplianceGroups) != 3 {
+		t.Errorf("expected 3 groups after replacing, got %d", len(target.Config.ApplianceGroups))
+	}
+}
@@ -0,0 +1,72 @@
+package group
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+)
+
+// NewImportCmd return a new appliance group import command
+func NewImportCmd(opts *commandOpts) *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:     "import <file>",
+		Short:   docs.ApplianceGroupImportDoc.Short,
+		Long:    docs.ApplianceGroupImportDoc.Long,
+		Example: docs.ApplianceGroupImportDoc.ExampleString(),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			return importRun(args[0], opts, force)
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "Replace the appliance groups that already exist with the imported ones")
+	return cmd
+}
+
+func importRun(path string, opts *commandOpts, force bool) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read appliance groups: %w", err)
+	}
+	var imported []configuration.ApplianceGroup
+	if err := json.Unmarshal(b, &imported); err != nil {
+		return fmt.Errorf("failed to parse appliance groups from %s, expected the format of 'sdpctl appliance group export': %w", path, err)
+	}
+
+	var errs *multierror.Error
+	seen := make(map[string]bool, len(imported))
+	for _, g := range imported {
+		if err := validateGroup(g); err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		if seen[g.Name] {
+			errs = multierror.Append(errs, fmt.Errorf("appliance group '%s' is defined more than once in %s", g.Name, path))
+		}
+		seen[g.Name] = true
+		if _, err := opts.Config.GetApplianceGroup(g.Name); err == nil && !force {
+			errs = multierror.Append(errs, fmt.Errorf("appliance group '%s' already exists, use '--force' to replace it", g.Name))
+		}
+	}
+	if err := errs.ErrorOrNil(); err != nil {
+		return err
+	}
+
+	groups := make([]configuration.ApplianceGroup, 0, len(opts.Config.ApplianceGroups)+len(imported))
+	for _, g := range opts.Config.ApplianceGroups {
+		if !seen[g.Name] {
+			groups = append(groups, g)
+		}
+	}
+	groups = append(groups, imported...)
+	if err := opts.SaveGroups(groups); err != nil {
+		return err
+	}
+	fmt.Fprintf(opts.Out, "Imported %d appliance groups\n", len(imported))
+	return nil
+}
@@ -0,0 +1,53 @@
+package group
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/appgate/sdpctl/pkg/cmdutil"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/spf13/cobra"
+)
+
+// NewListCmd return a new appliance group list command
+func NewListCmd(opts *commandOpts) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   docs.ApplianceGroupListDoc.Short,
+		Long:    docs.ApplianceGroupListDoc.Long,
+		Example: docs.ApplianceGroupListDoc.ExampleString(),
+		Args:    cobra.NoArgs,
+	}
+	output := cmdutil.AddOutputFlags(cmd, cmdutil.OutputTable)
+	cmd.RunE = func(c *cobra.Command, args []string) error {
+		return listRun(opts, output)
+	}
+	return cmd
+}
+
+func listRun(opts *commandOpts, output *cmdutil.OutputOptions) error {
+	if err := output.Validate(); err != nil {
+		return err
+	}
+	groups := opts.Config.ApplianceGroups
+	if groups == nil {
+		groups = []configuration.ApplianceGroup{}
+	}
+	table := cmdutil.NewTable("Name", "Include", "Exclude", "Filter")
+	for _, g := range groups {
+		table.AddRow(g.Name, formatKeywords(g.Include), formatKeywords(g.Exclude), g.Filter)
+	}
+	return output.Print(opts.Out, groups, table)
+}
+
+// formatKeywords formats the keywords of a group like the value of the '--include' flag
+func formatKeywords(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
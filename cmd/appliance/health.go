@@ -0,0 +1,237 @@
+package appliance
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	appliancepkg "github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/cmdutil"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type healthOptions struct {
+	Config         *configuration.Config
+	Out            io.Writer
+	Appliance      func(c *configuration.Config) (*appliancepkg.Appliance, error)
+	warnCPU        float32
+	critCPU        float32
+	warnMemory     float32
+	critMemory     float32
+	warnDisk       float32
+	critDisk       float32
+	minControllers int
+	versionDrift   bool
+}
+
+// NewHealthCmd return a new appliance health command
+func NewHealthCmd(f *factory.Factory) *cobra.Command {
+	opts := healthOptions{
+		Config:    f.Config,
+		Out:       f.IOOutWriter,
+		Appliance: f.Appliance,
+	}
+	cmd := &cobra.Command{
+		Use:     "health",
+		Short:   docs.ApplianceHealthDoc.Short,
+		Long:    docs.ApplianceHealthDoc.Long,
+		Example: docs.ApplianceHealthDoc.ExampleString(),
+		Args:    cobra.NoArgs,
+		Annotations: map[string]string{
+			cmdutil.PluginOutput: healthPluginName,
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			return healthRun(c, args, &opts)
+		},
+	}
+	flags := cmd.Flags()
+	flags.Float32Var(&opts.warnCPU, "warn-cpu", 80, "CPU usage in percent that results in a WARNING, 0 disables the check")
+	flags.Float32Var(&opts.critCPU, "crit-cpu", 95, "CPU usage in percent that results in a CRITICAL, 0 disables the check")
+	flags.Float32Var(&opts.warnMemory, "warn-memory", 85, "Memory usage in percent that results in a WARNING, 0 disables the check")
+	flags.Float32Var(&opts.critMemory, "crit-memory", 95, "Memory usage in percent that results in a CRITICAL, 0 disables the check")
+	flags.Float32Var(&opts.warnDisk, "warn-disk", appliancepkg.LowDiskSpaceThreshold, "Disk usage in percent that results in a WARNING, 0 disables the check")
+	flags.Float32Var(&opts.critDisk, "crit-disk", 90, "Disk usage in percent that results in a CRITICAL, 0 disables the check")
+	flags.IntVar(&opts.minControllers, "min-controllers", 1, "Minimum number of online Controllers, fewer results in a CRITICAL")
+	flags.BoolVar(&opts.versionDrift, "version-drift", true, "Result in a WARNING if the appliances are running different versions")
+	return cmd
+}
+
+// healthPluginName is the prefix of the plugin output line
+const healthPluginName = "APPLIANCE HEALTH"
+
+var healthStateNames = map[cmdutil.ExitCode]string{
+	cmdutil.ExitPluginOK:       "OK",
+	cmdutil.ExitPluginWarning:  "WARNING",
+	cmdutil.ExitPluginCritical: "CRITICAL",
+	cmdutil.ExitPluginUnknown:  "UNKNOWN",
+}
+
+// healthSeverity orders the plugin states, CRITICAL is worse than UNKNOWN although its exit code is lower
+var healthSeverity = map[cmdutil.ExitCode]int{
+	cmdutil.ExitPluginOK:       0,
+	cmdutil.ExitPluginWarning:  1,
+	cmdutil.ExitPluginUnknown:  2,
+	cmdutil.ExitPluginCritical: 3,
+}
+
+// healthReport is the result of the health checks, printed on a single line in the plugin output format
+type healthReport struct {
+	state    cmdutil.ExitCode
+	summary  string
+	problems []string
+	perfdata []string
+}
+
+func (r *healthReport) add(state cmdutil.ExitCode, format string, a ...interface{}) {
+	if healthSeverity[state] > healthSeverity[r.state] {
+		r.state = state
+	}
+	r.problems = append(r.problems, fmt.Sprintf("%s: %s", healthStateNames[state], fmt.Sprintf(format, a...)))
+}
+
+func (r *healthReport) addPerfdata(label, value, warn, crit, min, max string) {
+	r.perfdata = append(r.perfdata, strings.TrimRight(fmt.Sprintf("'%s'=%s;%s;%s;%s;%s", label, value, warn, crit, min, max), ";"))
+}
+
+func (r *healthReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s - %s", healthPluginName, healthStateNames[r.state], r.summary)
+	if len(r.problems) > 0 {
+		fmt.Fprintf(&b, ", %s", strings.Join(r.problems, ", "))
+	}
+	if len(r.perfdata) > 0 {
+		fmt.Fprintf(&b, " | %s", strings.Join(r.perfdata, " "))
+	}
+	return b.String()
+}
+
+func healthRun(cmd *cobra.Command, args []string, opts *healthOptions) error {
+	report, err := healthCheck(cmd, opts)
+	if err != nil {
+		report = &healthReport{state: cmdutil.ExitPluginUnknown, summary: err.Error()}
+	}
+	fmt.Fprintln(opts.Out, report.String())
+	if report.state != cmdutil.ExitPluginOK {
+		return &cmdutil.ExitCodeError{Code: report.state}
+	}
+	return nil
+}
+
+func healthCheck(cmd *cobra.Command, opts *healthOptions) (*healthReport, error) {
+	thresholds := [][2]float32{{opts.warnCPU, opts.critCPU}, {opts.warnMemory, opts.critMemory}, {opts.warnDisk, opts.critDisk}}
+	for _, t := range thresholds {
+		if t[0] > 0 && t[1] > 0 && t[0] > t[1] {
+			return nil, fmt.Errorf("invalid thresholds, warning %g%% is above critical %g%%", t[0], t[1])
+		}
+	}
+	a, err := opts.Appliance(opts.Config)
+	if err != nil {
+		return nil, err
+	}
+	filter, orderBy, descending := util.ParseFilteringFlags(cmd.Flags(), appliancepkg.DefaultCommandFilter)
+	stats, _, err := a.ApplianceStatus(util.BaseAuthContext(a.Token), filter, orderBy, descending)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats.GetData()) == 0 {
+		return nil, fmt.Errorf("no appliances matched the filters")
+	}
+	return evaluateHealth(stats.GetData(), opts), nil
+}
+
+// evaluateHealth checks the status, resource usage and versions of the appliances and the number of online Controllers
+func evaluateHealth(stats []openapi.ApplianceWithStatus, opts *healthOptions) *healthReport {
+	report := &healthReport{state: cmdutil.ExitPluginOK}
+	online := make([]openapi.ApplianceWithStatus, 0, len(stats))
+	offline, controllers := 0, 0
+	for _, s := range stats {
+		switch s.GetStatus() {
+		case "healthy", "busy":
+		case "offline":
+			offline++
+			report.add(cmdutil.ExitPluginCritical, "%s is offline", s.GetName())
+			continue
+		case "error":
+			report.add(cmdutil.ExitPluginCritical, "%s status is %s", s.GetName(), s.GetStatus())
+		default:
+			report.add(cmdutil.ExitPluginWarning, "%s status is %s", s.GetName(), s.GetStatus())
+		}
+		online = append(online, s)
+		if c, ok := s.GetControllerOk(); ok && c.GetEnabled() {
+			controllers++
+		}
+		checkHealthThreshold(report, s.GetName(), "cpu", s.GetCpu(), opts.warnCPU, opts.critCPU)
+		checkHealthThreshold(report, s.GetName(), "memory", s.GetMemory(), opts.warnMemory, opts.critMemory)
+	}
+
+	// the disk checks use the same helpers as the upgrade pre-checks
+	critDisk := map[string]bool{}
+	if opts.critDisk > 0 {
+		for _, s := range appliancepkg.DiskUsageAbove(online, opts.critDisk) {
+			critDisk[s.GetName()] = true
+			report.add(cmdutil.ExitPluginCritical, "%s disk %g%% >= %g%%", s.GetName(), s.GetDisk(), opts.critDisk)
+		}
+	}
+	if opts.warnDisk > 0 {
+		for _, s := range appliancepkg.DiskUsageAbove(online, opts.warnDisk) {
+			if !critDisk[s.GetName()] {
+				report.add(cmdutil.ExitPluginWarning, "%s disk %g%% >= %g%%", s.GetName(), s.GetDisk(), opts.warnDisk)
+			}
+		}
+	}
+
+	if opts.versionDrift && len(online) > 1 {
+		if diff, versions := appliancepkg.HasDiffVersions(online); diff {
+			names := make([]string, 0, len(versions))
+			for name := range versions {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			list := make([]string, 0, len(names))
+			for _, name := range names {
+				list = append(list, fmt.Sprintf("%s=%s", name, versions[name]))
+			}
+			report.add(cmdutil.ExitPluginWarning, "versions differ (%s)", strings.Join(list, " "))
+		}
+	}
+
+	if controllers < opts.minControllers {
+		report.add(cmdutil.ExitPluginCritical, "%d of minimum %d Controllers online", controllers, opts.minControllers)
+	}
+
+	report.summary = fmt.Sprintf("%d appliances, %d offline, %d Controllers online", len(stats), offline, controllers)
+	report.addPerfdata("appliances", fmt.Sprint(len(stats)), "", "", "0", "")
+	report.addPerfdata("offline", fmt.Sprint(offline), "", "0", "0", fmt.Sprint(len(stats)))
+	report.addPerfdata("controllers", fmt.Sprint(controllers), "", fmt.Sprintf("%d:", opts.minControllers), "0", "")
+	for _, s := range online {
+		name := s.GetName()
+		report.addPerfdata(name+"_cpu", fmt.Sprintf("%g%%", s.GetCpu()), thresholdString(opts.warnCPU), thresholdString(opts.critCPU), "0", "100")
+		report.addPerfdata(name+"_memory", fmt.Sprintf("%g%%", s.GetMemory()), thresholdString(opts.warnMemory), thresholdString(opts.critMemory), "0", "100")
+		report.addPerfdata(name+"_disk", fmt.Sprintf("%g%%", s.GetDisk()), thresholdString(opts.warnDisk), thresholdString(opts.critDisk), "0", "100")
+		report.addPerfdata(name+"_sessions", fmt.Sprint(s.GetNumberOfSessions()), "", "", "0", "")
+	}
+	return report
+}
+
+func checkHealthThreshold(report *healthReport, name, resource string, value, warn, crit float32) {
+	switch {
+	case crit > 0 && value >= crit:
+		report.add(cmdutil.ExitPluginCritical, "%s %s %g%% >= %g%%", name, resource, value, crit)
+	case warn > 0 && value >= warn:
+		report.add(cmdutil.ExitPluginWarning, "%s %s %g%% >= %g%%", name, resource, value, warn)
+	}
+}
+
+// thresholdString returns the threshold for the perfdata, which is empty if the check is disabled
+func thresholdString(v float32) string {
+	if v <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%g", v)
+}
@@ -0,0 +1,217 @@
+package appliance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/cmdutil"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/httpmock"
+)
+
+func healthTestStat(name, status, version string, cpu, disk float32, controller bool) openapi.ApplianceWithStatus {
+	return openapi.ApplianceWithStatus{
+		Name:             name,
+		Status:           openapi.PtrString(status),
+		ApplianceVersion: openapi.PtrString(version),
+		Cpu:              openapi.PtrFloat32(cpu),
+		Memory:           openapi.PtrFloat32(10),
+		Disk:             openapi.PtrFloat32(disk),
+		Controller: &openapi.ApplianceAllOfController{
+			Enabled: openapi.PtrBool(controller),
+		},
+	}
+}
+
+func TestEvaluateHealth(t *testing.T) {
+	defaults := healthOptions{
+		warnCPU:        80,
+		critCPU:        95,
+		warnMemory:     85,
+		critMemory:     95,
+		warnDisk:       75,
+		critDisk:       90,
+		minControllers: 1,
+		versionDrift:   true,
+	}
+	tests := []struct {
+		name      string
+		stats     []openapi.ApplianceWithStatus
+		opts      healthOptions
+		wantState cmdutil.ExitCode
+		want      []string
+	}{
+		{
+			name: "healthy",
+			stats: []openapi.ApplianceWithStatus{
+				healthTestStat("controller", "healthy", "6.2.1", 1, 10, true),
+				healthTestStat("gateway", "healthy", "6.2.1", 2, 20, false),
+			},
+			opts:      defaults,
+			wantState: cmdutil.ExitPluginOK,
+			want: []string{
+				"APPLIANCE HEALTH OK - 2 appliances, 0 offline, 1 Controllers online | ",
+				"'controllers'=1;;1:;0",
+				"'gateway_disk'=20%;75;90;0;100",
+			},
+		},
+		{
+			name: "disk and cpu warnings",
+			stats: []openapi.ApplianceWithStatus{
+				healthTestStat("controller", "healthy", "6.2.1", 81, 10, true),
+				healthTestStat("gateway", "healthy", "6.2.1", 2, 80, false),
+			},
+			opts:      defaults,
+			wantState: cmdutil.ExitPluginWarning,
+			want: []string{
+				"WARNING: controller cpu 81% >= 80%",
+				"WARNING: gateway disk 80% >= 75%",
+			},
+		},
+		{
+			name: "critical disk is not also a warning",
+			stats: []openapi.ApplianceWithStatus{
+				healthTestStat("controller", "healthy", "6.2.1", 1, 92, true),
+			},
+			opts:      defaults,
+			wantState: cmdutil.ExitPluginCritical,
+			want:      []string{"CRITICAL: controller disk 92% >= 90% |"},
+		},
+		{
+			name: "offline controller and version drift",
+			stats: []openapi.ApplianceWithStatus{
+				healthTestStat("controller", "offline", "6.2.1", 0, 0, true),
+				healthTestStat("gateway", "healthy", "6.2.1", 2, 20, false),
+				healthTestStat("portal", "warning", "6.3.0", 2, 20, false),
+			},
+			opts:      defaults,
+			wantState: cmdutil.ExitPluginCritical,
+			want: []string{
+				"3 appliances, 1 offline, 0 Controllers online",
+				"CRITICAL: controller is offline",
+				"WARNING: portal status is warning",
+				"WARNING: versions differ (gateway=6.2.1 portal=6.3.0)",
+				"CRITICAL: 0 of minimum 1 Controllers online",
+			},
+		},
+		{
+			name: "disabled checks",
+			stats: []openapi.ApplianceWithStatus{
+				healthTestStat("controller", "healthy", "6.2.1", 99, 99, true),
+				healthTestStat("gateway", "healthy", "6.3.0", 99, 99, false),
+			},
+			opts:      healthOptions{minControllers: 1},
+			wantState: cmdutil.ExitPluginOK,
+			want:      []string{"'controller_cpu'=99%;;;0;100"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := evaluateHealth(tt.stats, &tt.opts)
+			if report.state != tt.wantState {
+				t.Errorf("got state %s, want %s", healthStateNames[report.state], healthStateNames[tt.wantState])
+			}
+			got := report.String()
+			if strings.Contains(got, "\n") {
+				t.Errorf("expected a single line, got %q", got)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %q in\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthCommand(t *testing.T) {
+	registry := httpmock.NewRegistry(t)
+	registry.Register(
+		"/admin/appliances/status",
+		httpmock.JSONResponse("../../pkg/appliance/fixtures/stats_appliance.json"),
+	)
+	defer registry.Teardown()
+	registry.Serve()
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode cmdutil.ExitCode
+		want     string
+	}{
+		{
+			name:     "ok",
+			args:     []string{"health"},
+			wantCode: cmdutil.ExitPluginOK,
+			want:     "APPLIANCE HEALTH OK - 2 appliances, 0 offline, 1 Controllers online |",
+		},
+		{
+			name:     "warning disk threshold",
+			args:     []string{"health", "--warn-disk", "4", "--crit-disk", "10"},
+			wantCode: cmdutil.ExitPluginWarning,
+			want:     "WARNING: gateway-da0375f6-0b28-4248-bd54-a933c4c39008-site1 disk 4.9% >= 4%",
+		},
+		{
+			name:     "no controller matched",
+			args:     []string{"health", "--include", "function=gateway"},
+			wantCode: cmdutil.ExitPluginCritical,
+			want:     "CRITICAL: 0 of minimum 1 Controllers online",
+		},
+		{
+			name:     "invalid thresholds",
+			args:     []string{"health", "--warn-cpu", "90", "--crit-cpu", "80"},
+			wantCode: cmdutil.ExitPluginUnknown,
+			want:     "APPLIANCE HEALTH UNKNOWN - invalid thresholds, warning 90% is above critical 80%",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			f := &factory.Factory{
+				Config: &configuration.Config{
+					Debug: false,
+					URL:   fmt.Sprintf("http://localhost:%d", registry.Port),
+				},
+				IOOutWriter: stdout,
+				Stdin:       io.NopCloser(&bytes.Buffer{}),
+				StdErr:      &bytes.Buffer{},
+			}
+			f.APIClient = func(c *configuration.Config) (*openapi.APIClient, error) {
+				return registry.Client, nil
+			}
+			f.Appliance = func(c *configuration.Config) (*appliance.Appliance, error) {
+				api, _ := f.APIClient(c)
+				return &appliance.Appliance{
+					APIClient:  api,
+					HTTPClient: api.GetConfig().HTTPClient,
+					Token:      "",
+				}, nil
+			}
+			cmd := NewApplianceCmd(f)
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			_, err := cmd.ExecuteC()
+			code := cmdutil.ExitPluginOK
+			var exitErr *cmdutil.ExitCodeError
+			if errors.As(err, &exitErr) {
+				code = exitErr.Code
+			} else if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if code != tt.wantCode {
+				t.Errorf("got exit code %d, want %d", code, tt.wantCode)
+			}
+			if got := stdout.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("expected %q in\n%s", tt.want, got)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"io"
 
 	appliancepkg "github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
@@ -16,7 +17,7 @@ type listOptions struct {
 	Out           io.Writer
 	Appliance     func(c *configuration.Config) (*appliancepkg.Appliance, error)
 	debug         bool
-	json          bool
+	output        *cmdutil.OutputOptions
 	defaultFilter map[string]map[string]string
 }
 
@@ -39,11 +40,14 @@ func NewListCmd(f *factory.Factory) *cobra.Command {
 			return listRun(c, args, &opts)
 		},
 	}
-	listCmd.Flags().BoolVar(&opts.json, "json", false, "Display in JSON format")
+	opts.output = cmdutil.AddOutputFlags(listCmd, cmdutil.OutputTable)
 	return listCmd
 }
 
 func listRun(cmd *cobra.Command, args []string, opts *listOptions) error {
+	if err := opts.output.Validate(); err != nil {
+		return err
+	}
 	cfg := opts.Config
 	a, err := opts.Appliance(cfg)
 	if err != nil {
@@ -55,16 +59,10 @@ func listRun(cmd *cobra.Command, args []string, opts *listOptions) error {
 	if err != nil {
 		return err
 	}
-	if opts.json {
-		return util.PrintJSON(opts.Out, allAppliances)
-	}
 
-	p := util.NewPrinter(opts.Out, 4)
-	p.AddHeader("Name", "ID", "Hostname", "Site", "Activated")
+	t := cmdutil.NewTable("Name", "ID", "Hostname", "Site", "Activated")
 	for _, a := range allAppliances {
-		p.AddLine(a.GetName(), a.GetId(), a.GetHostname(), a.GetSiteName(), a.GetActivated())
+		t.AddRow(a.GetName(), a.GetId(), a.GetHostname(), a.GetSiteName(), a.GetActivated())
 	}
-	p.Print()
-
-	return nil
+	return opts.output.Print(opts.Out, allAppliances, t)
 }
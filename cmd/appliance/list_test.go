@@ -133,6 +133,83 @@ gateway-da0375f6-0b28-4248-bd54-a933c4c39008-site1       ee639d70-e075-4f01-596b
 	}
 }
 
+func TestApplianceListCommandOutputFormats(t *testing.T) {
+	registry := httpmock.NewRegistry(t)
+	registry.Register(
+		"/admin/appliances",
+		httpmock.JSONResponse("../../pkg/appliance/fixtures/appliance_list.json"),
+	)
+	defer registry.Teardown()
+	registry.Serve()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "csv with columns",
+			args: []string{"--output", "csv", "--columns", "name,site"},
+			want: `Name,Site
+controller-4c07bc67-57ea-42dd-b702-c2d6c45419fc-site1,Default Site
+gateway-da0375f6-0b28-4248-bd54-a933c4c39008-site1,Default Site
+`,
+		},
+		{
+			name: "table without headers",
+			args: []string{"--columns", "hostname,activated", "--no-headers"},
+			want: `appgate.test      true
+gateway.devops    true
+`,
+		},
+		{
+			name: "jsonpath",
+			args: []string{"-o", "jsonpath={[*].id}"},
+			want: "4c07bc67-57ea-42dd-b702-c2d6c45419fc ee639d70-e075-4f01-596b-930d5f24f569\n",
+		},
+		{
+			name: "go-template",
+			args: []string{"-o", "go-template={{range .}}{{.hostname}}\n{{end}}"},
+			want: "appgate.test\ngateway.devops\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			f := &factory.Factory{
+				Config: &configuration.Config{
+					Debug: false,
+					URL:   fmt.Sprintf("http://appgate.test:%d", registry.Port),
+				},
+				IOOutWriter: stdout,
+				Stdin:       io.NopCloser(&bytes.Buffer{}),
+				StdErr:      &bytes.Buffer{},
+			}
+			f.APIClient = func(c *configuration.Config) (*openapi.APIClient, error) {
+				return registry.Client, nil
+			}
+			f.Appliance = func(c *configuration.Config) (*appliance.Appliance, error) {
+				api, _ := f.APIClient(c)
+				return &appliance.Appliance{
+					APIClient:  api,
+					HTTPClient: api.GetConfig().HTTPClient,
+					Token:      "",
+				}, nil
+			}
+			cmd := NewApplianceCmd(f)
+			cmd.SetArgs(append([]string{"list"}, tt.args...))
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			if _, err := cmd.ExecuteC(); err != nil {
+				t.Fatalf("executeC %s", err)
+			}
+			if diff := cmp.Diff(tt.want, stdout.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestApplianceFiltering(t *testing.T) {
 	registry := httpmock.NewRegistry(t)
 	registry.Register(
@@ -196,3 +273,74 @@ controller-4c07bc67-57ea-42dd-b702-c2d6c45419fc-site1    4c07bc67-57ea-42dd-b702
 		t.Fatalf("\nGot: \n %q \n\n Want: \n %q \n", gotStr, want)
 	}
 }
+
+func TestApplianceListVersionRange(t *testing.T) {
+	registry := httpmock.NewRegistry(t)
+	registry.Register(
+		"/admin/appliances",
+		httpmock.JSONResponse("../../pkg/appliance/fixtures/appliance_list.json"),
+	)
+	registry.Register(
+		"/admin/appliances/status",
+		httpmock.JSONResponse("../../pkg/appliance/fixtures/stats_appliance.json"),
+	)
+	defer registry.Teardown()
+	registry.Serve()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "version range",
+			args: []string{"--include", "version=>=6.2;<6.4"},
+			want: "4c07bc67-57ea-42dd-b702-c2d6c45419fc ee639d70-e075-4f01-596b-930d5f24f569\n",
+		},
+		{
+			name: "version range with another keyword",
+			args: []string{"--include", "function=gateway,version=>=6.2;<6.4"},
+			want: "ee639d70-e075-4f01-596b-930d5f24f569\n",
+		},
+		{
+			name: "excluded version range",
+			args: []string{"--exclude", "version=>=6.0;<6.2", "--include", "function=controller"},
+			want: "4c07bc67-57ea-42dd-b702-c2d6c45419fc\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			f := &factory.Factory{
+				Config: &configuration.Config{
+					Debug: false,
+					URL:   fmt.Sprintf("http://appgate.test:%d", registry.Port),
+				},
+				IOOutWriter: stdout,
+				Stdin:       io.NopCloser(&bytes.Buffer{}),
+				StdErr:      &bytes.Buffer{},
+			}
+			f.APIClient = func(c *configuration.Config) (*openapi.APIClient, error) {
+				return registry.Client, nil
+			}
+			f.Appliance = func(c *configuration.Config) (*appliance.Appliance, error) {
+				api, _ := f.APIClient(c)
+				return &appliance.Appliance{
+					APIClient:  api,
+					HTTPClient: api.GetConfig().HTTPClient,
+					Token:      "",
+				}, nil
+			}
+			cmd := NewApplianceCmd(f)
+			cmd.SetArgs(append([]string{"list", "-o", "jsonpath={[*].id}"}, tt.args...))
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			if _, err := cmd.ExecuteC(); err != nil {
+				t.Fatalf("executeC %s", err)
+			}
+			if diff := cmp.Diff(tt.want, stdout.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -9,11 +9,16 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
 	"github.com/appgate/sdpctl/pkg/api"
+	appliancepkg "github.com/appgate/sdpctl/pkg/appliance"
 	"github.com/appgate/sdpctl/pkg/cmdappliance"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/queue"
 	"github.com/appgate/sdpctl/pkg/tui"
 	"github.com/appgate/sdpctl/pkg/util"
 	log "github.com/sirupsen/logrus"
@@ -33,6 +38,9 @@ type logOpts struct {
 	json       bool
 	since      int
 	processed  bool
+	extract    bool
+	throttle   int
+	multiple   bool
 }
 
 func NewLogsCmd(f *factory.Factory) *cobra.Command {
@@ -53,13 +61,22 @@ func NewLogsCmd(f *factory.Factory) *cobra.Command {
 		false,
 		7,
 		false,
+		false,
+		5,
+		false,
 	}
 	cmd := &cobra.Command{
 		Use:     "logs",
 		Short:   docs.ApplianceLogsDoc.Short,
-		Long:    docs.ApplianceLogsDoc.Short,
+		Long:    docs.ApplianceLogsDoc.Long,
 		Example: docs.ApplianceLogsDoc.ExampleString(),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// download the logs from all appliances matching the filters
+			// instead of selecting a single appliance
+			if len(args) == 0 && (cmd.Flags().Changed("include") || cmd.Flags().Changed("exclude") || cmd.Flags().Changed("filter")) {
+				opts.multiple = true
+				return nil
+			}
 			return cmdappliance.ArgsSelectAppliance(cmd, args, &opts.AppliancCmdOpts)
 		},
 		RunE: func(c *cobra.Command, args []string) error {
@@ -70,9 +87,28 @@ func NewLogsCmd(f *factory.Factory) *cobra.Command {
 	cmd.Flags().BoolVar(&opts.json, "json", false, "Display in JSON format")
 	cmd.Flags().BoolVar(&opts.processed, "process-logs", false, "Process the logs server side. Smaller download but very heavy to generate")
 	cmd.Flags().IntVar(&opts.since, "since", 7, "Number of days of logs to process")
+	cmd.Flags().BoolVar(&opts.extract, "extract", false, "Extract the journal files from the log bundle after the download")
+	cmd.Flags().IntVar(&opts.throttle, "throttle", 5, "Number of appliances to download logs from at the same time when using '--include' or '--exclude'")
+	cmd.AddCommand(NewLogsTimelineCmd(f))
 	return cmd
 }
 
+// logBundleResult is the outcome of downloading the logs from an appliance
+type logBundleResult struct {
+	Name   string `json:"name"`
+	ID     string `json:"id"`
+	Path   string `json:"path,omitempty"`
+	Size   int64  `json:"size"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+const (
+	logBundleStatusDownloaded = "downloaded"
+	logBundleStatusExtracted  = "extracted"
+	logBundleStatusFailed     = "failed"
+)
+
 func logsRun(cmd *cobra.Command, args []string, opts *logOpts) error {
 	client, err := opts.HTTPClient()
 	if err != nil {
@@ -88,34 +124,185 @@ func logsRun(cmd *cobra.Command, args []string, opts *logOpts) error {
 	if opts.since <= 0 {
 		return errors.New("Invalid since")
 	}
+	if opts.multiple {
+		return logsRunMultiple(cmd, client, path, opts)
+	}
+
+	p := mpb.New(mpb.WithWidth(64), mpb.WithOutput(opts.SpinnerOut()))
+	file, size, err := downloadLogBundle(client, opts, opts.ApplianceID, path, p)
+	if err != nil {
+		return err
+	}
+	p.Wait()
+	log.Infof("Downloaded %d bytes zip bundle for %s", size, opts.ApplianceID)
+	result := map[string]string{"path": file}
+	if opts.extract {
+		dir := strings.TrimSuffix(file, filepath.Ext(file))
+		if err := extractLogBundle(file, dir); err != nil {
+			return err
+		}
+		result["extracted"] = dir
+	}
+	if opts.json {
+		return util.PrintJSON(opts.Out, result)
+	}
+	fmt.Fprintf(opts.Out, "saved to %s\n", file)
+	if dir, ok := result["extracted"]; ok {
+		fmt.Fprintf(opts.Out, "extracted to %s\n", dir)
+	}
+	return nil
+}
+
+// logsRunMultiple downloads the logs from all appliances matching the filters, with at most
+// opts.throttle downloads at the same time, into a directory per appliance
+func logsRunMultiple(cmd *cobra.Command, client *http.Client, path string, opts *logOpts) error {
+	a, err := opts.Appliance(opts.Config)
+	if err != nil {
+		return err
+	}
+	filter, orderBy, descending := util.ParseFilteringFlags(cmd.Flags(), appliancepkg.DefaultCommandFilter)
+	appliances, err := a.List(util.BaseAuthContext(a.Token), filter, orderBy, descending)
+	if err != nil {
+		return err
+	}
+	if len(appliances) == 0 {
+		return errors.New("No appliances matched the filters")
+	}
+	workers := opts.throttle
+	if workers <= 0 {
+		workers = len(appliances)
+	}
+
+	type queueStruct struct {
+		index     int
+		appliance openapi.Appliance
+	}
+	var (
+		results = make([]logBundleResult, len(appliances))
+		qw      = queue.New(len(appliances), workers)
+		p       = mpb.New(mpb.WithWidth(64), mpb.WithOutput(opts.SpinnerOut()))
+	)
+	for i, appliance := range appliances {
+		if err := qw.Push(queueStruct{index: i, appliance: appliance}); err != nil {
+			return err
+		}
+	}
+	err = qw.Work(func(v interface{}) error {
+		item := v.(queueStruct)
+		result := logBundleResult{
+			Name:   item.appliance.GetName(),
+			ID:     item.appliance.GetId(),
+			Status: logBundleStatusFailed,
+		}
+		defer func() { results[item.index] = result }()
+
+		dir := filepath.Join(path, logBundleDirName(item.appliance))
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			result.Error = err.Error()
+			return nil
+		}
+		file, size, err := downloadLogBundle(client, opts, result.ID, dir, p)
+		if err != nil {
+			log.WithError(err).WithField("appliance", result.Name).Error("Failed to download logs")
+			result.Error = err.Error()
+			return nil
+		}
+		result.Path, result.Size, result.Status = file, size, logBundleStatusDownloaded
+		log.Infof("Downloaded %d bytes zip bundle for %s", size, result.Name)
+		if opts.extract {
+			if err := extractLogBundle(file, dir); err != nil {
+				log.WithError(err).WithField("appliance", result.Name).Error("Failed to extract logs")
+				result.Status, result.Error = logBundleStatusFailed, err.Error()
+				return nil
+			}
+			result.Status = logBundleStatusExtracted
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.Status == logBundleStatusFailed {
+			failed++
+		}
+	}
+	if opts.json {
+		if err := util.PrintJSON(opts.Out, results); err != nil {
+			return err
+		}
+	} else {
+		w := util.NewPrinter(opts.Out, 4)
+		w.AddHeader("Name", "Status", "Size", "Path")
+		for _, r := range results {
+			status := r.Status
+			if len(r.Error) > 0 {
+				status = fmt.Sprintf("%s: %s", r.Status, r.Error)
+			}
+			w.AddLine(r.Name, status, appliancepkg.PrettyBytes(float64(r.Size)), r.Path)
+		}
+		w.Print()
+	}
+	if failed > 0 {
+		return fmt.Errorf("Failed to download logs from %d of %d appliances", failed, len(results))
+	}
+	return nil
+}
+
+// logBundleDirName is the directory name used for the logs of an appliance
+func logBundleDirName(appliance openapi.Appliance) string {
+	return strings.ReplaceAll(strings.ReplaceAll(appliance.GetName(), " ", "_"), string(filepath.Separator), "_")
+}
+
+// extractLogBundle extracts the log bundle into dir, with the journal entries grouped by daemon
+func extractLogBundle(file, dir string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	e := &journalExtractor{
+		path:    dir,
+		workers: runtime.NumCPU(),
+	}
+	err := e.processJournalFile(file)
+	if closeErr := e.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
 
+// downloadLogBundle downloads the log bundle from an appliance into dir, showing the progress in p,
+// and returns the path and size of the bundle
+func downloadLogBundle(client *http.Client, opts *logOpts, applianceID, dir string, p *mpb.Progress) (string, int64, error) {
 	requestURL := ""
 	if opts.Version < 22 {
-		requestURL = fmt.Sprintf("%s/appliances/%s/logs", opts.BaseURL, opts.ApplianceID)
+		requestURL = fmt.Sprintf("%s/appliances/%s/logs", opts.BaseURL, applianceID)
 	} else {
 		processedStr := "false"
 		if opts.processed {
 			processedStr = "true"
 		}
-		requestURL = fmt.Sprintf("%s/appliances/%s/logs?since=%d&journalctl_processed=%s", opts.BaseURL, opts.ApplianceID, opts.since, processedStr)
+		requestURL = fmt.Sprintf("%s/appliances/%s/logs?since=%d&journalctl_processed=%s", opts.BaseURL, applianceID, opts.since, processedStr)
 	}
 	request, err := http.NewRequest(http.MethodGet, requestURL, nil)
 	if err != nil {
-		return err
+		return "", 0, err
 	}
 	request = request.WithContext(context.WithValue(context.Background(), api.ContextAcceptValue, fmt.Sprintf("application/vnd.appgate.peer-v%d+zip", opts.Version)))
 	request.Close = true
-	log.Infof("Starting downloading log zip bundle for %s", opts.ApplianceID)
+	log.Infof("Starting downloading log zip bundle for %s", applianceID)
 	response, err := client.Do(request)
 	if response == nil || err != nil {
-		return api.HTTPErrorResponse(response, err)
+		return "", 0, api.HTTPErrorResponse(response, err)
 	}
 	if response.StatusCode != http.StatusOK {
-		return api.HTTPErrorResponse(response, err)
+		return "", 0, api.HTTPErrorResponse(response, err)
 	}
 	defer response.Body.Close()
 
-	name := fmt.Sprintf("%s_logs.zip", opts.ApplianceID)
+	name := fmt.Sprintf("%s_logs.zip", applianceID)
 	_, params, err := mime.ParseMediaType(response.Header.Get("Content-Disposition"))
 	if err == nil {
 		if v, ok := params["filename"]; ok {
@@ -123,12 +310,11 @@ func logsRun(cmd *cobra.Command, args []string, opts *logOpts) error {
 		}
 	}
 
-	file, err := os.Create(filepath.Join(path, name))
+	file, err := os.Create(filepath.Join(dir, name))
 	if err != nil {
-		return err
+		return "", 0, err
 	}
-
-	p := mpb.New(mpb.WithWidth(64), mpb.WithOutput(opts.SpinnerOut()))
+	defer file.Close()
 
 	bar := p.New(0,
 		mpb.SpinnerStyle(tui.SpinnerStyle...),
@@ -139,15 +325,10 @@ func logsRun(cmd *cobra.Command, args []string, opts *logOpts) error {
 
 	size, err := copy(file, response.Body, bar)
 	if err != nil {
-		return err
+		bar.Abort(false)
+		return "", 0, err
 	}
-	p.Wait()
-	log.Infof("Downloaded %d bytes zip bundle for %s", size, opts.ApplianceID)
-	if opts.json {
-		return util.PrintJSON(opts.Out, map[string]string{"path": file.Name()})
-	}
-	fmt.Fprintf(opts.Out, "saved to %s\n", file.Name())
-	return nil
+	return file.Name(), size, nil
 }
 
 func copy(dst io.Writer, src io.Reader, bar *mpb.Bar) (written int64, err error) {
@@ -2,8 +2,13 @@ package appliance
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
@@ -71,3 +76,88 @@ func TestNewLogsCmd(t *testing.T) {
 	}
 
 }
+
+func TestLogsMultipleAppliances(t *testing.T) {
+	dir := t.TempDir()
+	registry := httpmock.NewRegistry(t)
+	registry.Register("/admin/appliances", httpmock.JSONResponse("../../pkg/appliance/fixtures/appliance_list.json"))
+	registry.Register(
+		"/admin/appliances/4c07bc67-57ea-42dd-b702-c2d6c45419fc/logs",
+		func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Content-Type", "application/vnd.appgate.peer-v18+zip")
+			rw.WriteHeader(http.StatusOK)
+			fmt.Fprint(rw, string(`binary data`))
+		},
+	)
+	registry.Register(
+		"/admin/appliances/ee639d70-e075-4f01-596b-930d5f24f569/logs",
+		func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		},
+	)
+	defer registry.Teardown()
+	registry.Serve()
+
+	stdout := &bytes.Buffer{}
+	url := fmt.Sprintf("http://localhost:%d", registry.Port)
+	f := &factory.Factory{
+		Config: &configuration.Config{
+			Debug: false,
+			URL:   url,
+		},
+		IOOutWriter: stdout,
+		StdErr:      &bytes.Buffer{},
+	}
+	f.APIClient = func(c *configuration.Config) (*openapi.APIClient, error) {
+		return registry.Client, nil
+	}
+	f.BaseURL = func() string {
+		return url + "/admin"
+	}
+	f.CustomHTTPClient = func() (*http.Client, error) {
+		return &http.Client{}, nil
+	}
+	f.Appliance = func(c *configuration.Config) (*appliance.Appliance, error) {
+		api, _ := f.APIClient(c)
+		return &appliance.Appliance{
+			APIClient:  api,
+			HTTPClient: api.GetConfig().HTTPClient,
+			Token:      "",
+		}, nil
+	}
+	cmd := NewLogsCmd(f)
+	cmd.PersistentFlags().Bool("no-interactive", false, "")
+	cmd.PersistentFlags().Bool("descending", false, "")
+	cmd.PersistentFlags().StringSlice("order-by", []string{"name"}, "")
+	cmd.PersistentFlags().StringToStringP("include", "i", map[string]string{}, "")
+	cmd.PersistentFlags().StringToStringP("exclude", "e", map[string]string{}, "")
+	cmd.SetArgs([]string{"--exclude", "name=logforwarder", "--path", dir, "--json"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	_, err := cmd.ExecuteC()
+	if err == nil || !strings.Contains(err.Error(), "Failed to download logs from 1 of 2 appliances") {
+		t.Fatalf("expected error for the failed download, got %v", err)
+	}
+
+	var results []logBundleResult
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatalf("invalid JSON summary %s: %s", err, stdout.String())
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected a result for each appliance, got %+v", results)
+	}
+	controller, gateway := results[0], results[1]
+	if controller.Status != logBundleStatusDownloaded || controller.Size != int64(len("binary data")) {
+		t.Errorf("unexpected controller result %+v", controller)
+	}
+	want := filepath.Join(dir, "controller-4c07bc67-57ea-42dd-b702-c2d6c45419fc-site1", "4c07bc67-57ea-42dd-b702-c2d6c45419fc_logs.zip")
+	if controller.Path != want {
+		t.Errorf("got path %s, want %s", controller.Path, want)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Error(err)
+	}
+	if gateway.Status != logBundleStatusFailed || len(gateway.Error) == 0 {
+		t.Errorf("unexpected gateway result %+v", gateway)
+	}
+}
@@ -0,0 +1,340 @@
+package appliance
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/hashicorp/go-multierror"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// timelineTimestampField and timelineSourceField are added to the journal fields
+	// in the jsonl and csv output of the timeline
+	timelineTimestampField = "TIMELINE_TIMESTAMP"
+	timelineSourceField    = "TIMELINE_SOURCE"
+)
+
+var defaultTimelineCSVFields = []string{timelineTimestampField, timelineSourceField, "_HOSTNAME", "SYSLOG_IDENTIFIER", "_PID", "PRIORITY", "MESSAGE"}
+
+type logsTimelineOpts struct {
+	logextractOpts
+	Out     io.Writer
+	Offsets []string
+}
+
+func NewLogsTimelineCmd(f *factory.Factory) *cobra.Command {
+	opts := logsTimelineOpts{
+		Out: f.IOOutWriter,
+	}
+	cmd := &cobra.Command{
+		Use:     "timeline <bundle.zip>...",
+		Short:   docs.ApplianceLogsTimelineDoc.Short,
+		Long:    docs.ApplianceLogsTimelineDoc.Long,
+		Example: docs.ApplianceLogsTimelineDoc.ExampleString(),
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			return logsTimelineRun(args, &opts)
+		},
+		Annotations: map[string]string{
+			configuration.SkipAuthCheck: "true",
+		},
+	}
+	addJournalFilterFlags(cmd.Flags(), &opts.logextractOpts)
+	addJournalFormatFlags(cmd.Flags(), &opts.logextractOpts)
+	cmd.Flags().StringArrayVar(&opts.Offsets, "offset", []string{}, "Clock-skew offset added to the timestamps of a bundle, for example 'gateway.zip=-2.5s', can be repeated")
+	return cmd
+}
+
+// timelineEntry is a journal entry from one of the log bundles, with the clock-skew offset applied to its time
+type timelineEntry struct {
+	source string
+	time   time.Time
+	entry  map[string]string
+}
+
+// timelineSource reads the journal entries of a single log bundle in time order
+type timelineSource struct {
+	bundle  string
+	label   string
+	offset  time.Duration
+	entries chan timelineEntry
+	err     error
+}
+
+func logsTimelineRun(args []string, opts *logsTimelineOpts) error {
+	filter, err := newJournalFilter(&opts.logextractOpts)
+	if err != nil {
+		return err
+	}
+	format, err := newJournalOutputFormat(&opts.logextractOpts)
+	if err != nil {
+		return err
+	}
+	if len(opts.Fields) == 0 {
+		format.fields = defaultTimelineCSVFields
+	}
+	sources, err := newTimelineSources(args, opts.Offsets)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, source := range sources {
+		wg.Add(1)
+		go func(s *timelineSource) {
+			defer wg.Done()
+			defer close(s.entries)
+			s.err = s.read(ctx, filter)
+		}(source)
+	}
+
+	if format.format == journalFormatCSV {
+		header, err := csvLine(format.fields)
+		if err != nil {
+			return err
+		}
+		if _, err := opts.Out.Write(header); err != nil {
+			return err
+		}
+	}
+	streams := make([]<-chan timelineEntry, 0, len(sources))
+	for _, s := range sources {
+		streams = append(streams, s.entries)
+	}
+	written := 0
+	err = mergeTimeline(streams, func(e timelineEntry) error {
+		b, err := format.encodeTimeline(e)
+		if err != nil {
+			return err
+		}
+		written++
+		_, err = opts.Out.Write(b)
+		return err
+	})
+	// stop reading the bundles and wait for the temporary files to be removed before returning
+	cancel()
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	var errs *multierror.Error
+	for _, s := range sources {
+		if s.err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("%s: %w", s.bundle, s.err))
+		}
+	}
+	log.Infof("Wrote %d journal entries from %d log bundles", written, len(sources))
+	return errs.ErrorOrNil()
+}
+
+// newTimelineSources returns a source for each bundle, labeled with the file name of the bundle.
+// Offsets are given as 'bundle=duration', where bundle is the path or the file name of the bundle.
+func newTimelineSources(bundles, offsets []string) ([]*timelineSource, error) {
+	sources := make([]*timelineSource, 0, len(bundles))
+	for _, bundle := range bundles {
+		name := filepath.Base(bundle)
+		sources = append(sources, &timelineSource{
+			bundle:  bundle,
+			label:   strings.TrimSuffix(name, filepath.Ext(name)),
+			entries: make(chan timelineEntry, journalRecordBuffer),
+		})
+	}
+	for _, o := range offsets {
+		name, value, ok := strings.Cut(o, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --offset %q, expected 'bundle=duration', for example 'gateway.zip=-2.5s'", o)
+		}
+		offset, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --offset %q: %w", o, err)
+		}
+		found := false
+		for _, s := range sources {
+			if name == s.bundle || name == filepath.Base(s.bundle) {
+				s.offset = offset
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid --offset %q, no log bundle named %s", o, name)
+		}
+	}
+	return sources, nil
+}
+
+// read sends the entries of all journal files in the bundle that match the filter, ordered by time.
+// The journal files of a bundle overlap in time, for example the system and user journals,
+// so all of them are read at the same time and merged like the bundles.
+func (s *timelineSource) read(ctx context.Context, filter *journalFilter) error {
+	r, err := openLogBundle(s.bundle)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var journals []journalHeader
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".journal") {
+			continue
+		}
+		h, err := readJournalHeader(f)
+		if err != nil {
+			log.WithError(err).Warnf("Skipping journal file %s", f.Name)
+			continue
+		}
+		journals = append(journals, h)
+	}
+	// entries with the same time are written in the order of the journal files
+	sortJournalHeaders(journals)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	streams := make([]<-chan timelineEntry, len(journals))
+	errs := make([]error, len(journals))
+	for i, h := range journals {
+		entries := make(chan timelineEntry, journalRecordBuffer)
+		streams[i] = entries
+		wg.Add(1)
+		go func(i int, h journalHeader) {
+			defer wg.Done()
+			defer close(entries)
+			errs[i] = s.readJournal(ctx, h, filter, entries)
+		}(i, h)
+	}
+	err = mergeTimeline(streams, func(e timelineEntry) error {
+		select {
+		case s.entries <- e:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	// stop reading the journal files and wait for the temporary files to be removed before returning
+	cancel()
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readJournal sends the entries of a journal file that match the filter to out.
+// Entries without a timestamp get the time of the previous entry in the file, so they stay next to it in the
+// timeline, or the time of the head entry from the file header if they are at the start of the file.
+func (s *timelineSource) readJournal(ctx context.Context, h journalHeader, filter *journalFilter, out chan<- timelineEntry) error {
+	// SYSLOG_TIMESTAMP does not include the year, so the year of the journal file is used
+	year := h.file.Modified.Year()
+	if h.file.Modified.IsZero() {
+		year = time.Now().Year()
+	}
+	last := h.headEntryRealtime.Add(s.offset)
+	return walkJournal(h.file, nil, func(entry map[string]string) error {
+		if t, ok := journalEntryTime(entry, year); ok {
+			last = t.Add(s.offset)
+		}
+		if !filter.inRange(last) || !filter.matchFields(entry) {
+			return nil
+		}
+		select {
+		case out <- timelineEntry{source: s.label, time: last, entry: entry}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// timelineCursor is the next entry of a stream
+type timelineCursor struct {
+	index   int
+	entries <-chan timelineEntry
+	head    timelineEntry
+}
+
+// timelineHeap orders the cursors by the time of their next entry,
+// and by the order of the streams for entries with the same time
+type timelineHeap []*timelineCursor
+
+func (h timelineHeap) Len() int { return len(h) }
+func (h timelineHeap) Less(i, j int) bool {
+	if !h[i].head.time.Equal(h[j].head.time) {
+		return h[i].head.time.Before(h[j].head.time)
+	}
+	return h[i].index < h[j].index
+}
+func (h timelineHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *timelineHeap) Push(x interface{}) { *h = append(*h, x.(*timelineCursor)) }
+func (h *timelineHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	c := old[n-1]
+	*h = old[:n-1]
+	return c
+}
+
+// mergeTimeline calls fn with the entries of all streams ordered by time.
+// The entries of each stream must already be in time order.
+func mergeTimeline(streams []<-chan timelineEntry, fn func(e timelineEntry) error) error {
+	h := &timelineHeap{}
+	for i, entries := range streams {
+		if e, ok := <-entries; ok {
+			*h = append(*h, &timelineCursor{index: i, entries: entries, head: e})
+		}
+	}
+	heap.Init(h)
+	for h.Len() > 0 {
+		c := (*h)[0]
+		if err := fn(c.head); err != nil {
+			return err
+		}
+		e, ok := <-c.entries
+		if !ok {
+			heap.Pop(h)
+			continue
+		}
+		c.head = e
+		heap.Fix(h, 0)
+	}
+	return nil
+}
+
+// encodeTimeline formats an entry of the timeline, using the time with the clock-skew offset applied
+// and the source bundle as label
+func (o *journalOutputFormat) encodeTimeline(e timelineEntry) ([]byte, error) {
+	timestamp := e.time.Format(time.RFC3339Nano)
+	switch o.format {
+	case journalFormatJSONL, journalFormatCSV:
+		fields := make(map[string]string, len(e.entry)+2)
+		for k, v := range e.entry {
+			fields[k] = v
+		}
+		fields[timelineTimestampField] = timestamp
+		fields[timelineSourceField] = e.source
+		return o.encode(fields)
+	case journalFormatECS:
+		ecs := newECSEntry(e.entry)
+		ecs.Timestamp = timestamp
+		ecs.Labels = map[string]string{"source": e.source}
+		return jsonLine(ecs)
+	}
+	return []byte(fmt.Sprintf("%s %s %s %s[%s]: %s\n", timestamp, e.source, e.entry["_HOSTNAME"], e.entry["SYSLOG_IDENTIFIER"], e.entry["_PID"], e.entry["MESSAGE"])), nil
+}
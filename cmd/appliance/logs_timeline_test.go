@@ -0,0 +1,210 @@
+package appliance
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type timelineTestEntry struct {
+	message string
+	time    time.Time
+}
+
+// buildTimelineBundle creates a log bundle with one journal file per entry,
+// each with a single sshd entry at the given time
+func buildTimelineBundle(t *testing.T, path string, entries []timelineTestEntry) {
+	t.Helper()
+	zf, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zf.Close()
+	zw := zip.NewWriter(zf)
+	defer zw.Close()
+	var seqnum uint64
+	for _, e := range entries {
+		seqnum++
+		journalPath := filepath.Join(t.TempDir(), "system.journal")
+		buildJournal(t, journalPath,
+			"SYSLOG_IDENTIFIER=sshd",
+			"_HOSTNAME="+filepath.Base(path),
+			"MESSAGE="+e.message,
+			fmt.Sprintf("_SOURCE_REALTIME_TIMESTAMP=%d", e.time.UnixMicro()),
+		)
+		b, err := os.ReadFile(journalPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		binary.LittleEndian.PutUint64(b[168:], seqnum) // head_entry_seqnum
+		w, err := zw.Create(fmt.Sprintf("abc123/system@%d.journal", seqnum))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(b)
+	}
+}
+
+func TestLogsTimeline(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", t.TempDir())
+	start := time.Date(2024, time.March, 10, 14, 0, 0, 0, time.UTC)
+	controller := filepath.Join(dir, "controller.zip")
+	buildTimelineBundle(t, controller, []timelineTestEntry{
+		{"first", start},
+		{"fourth", start.Add(3 * time.Second)},
+	})
+	gateway := filepath.Join(dir, "gateway.zip")
+	// the gateway clock is 10 seconds ahead
+	buildTimelineBundle(t, gateway, []timelineTestEntry{
+		{"second", start.Add(11 * time.Second)},
+		{"third", start.Add(12 * time.Second)},
+	})
+
+	tests := []struct {
+		name string
+		opts logsTimelineOpts
+		want string
+	}{
+		{
+			name: "merged with offset",
+			opts: logsTimelineOpts{Offsets: []string{"gateway.zip=-10s"}},
+			want: `2024-03-10T14:00:00Z controller controller.zip sshd[]: first
+2024-03-10T14:00:01Z gateway gateway.zip sshd[]: second
+2024-03-10T14:00:02Z gateway gateway.zip sshd[]: third
+2024-03-10T14:00:03Z controller controller.zip sshd[]: fourth
+`,
+		},
+		{
+			name: "filters apply to the corrected time",
+			opts: logsTimelineOpts{
+				logextractOpts: logextractOpts{Since: "2024-03-10T14:00:01Z", Until: "2024-03-10T14:00:02Z"},
+				Offsets:        []string{gateway + "=-10s"},
+			},
+			want: `2024-03-10T14:00:01Z gateway gateway.zip sshd[]: second
+2024-03-10T14:00:02Z gateway gateway.zip sshd[]: third
+`,
+		},
+		{
+			name: "csv",
+			opts: logsTimelineOpts{
+				logextractOpts: logextractOpts{Format: "csv", Grep: "first|second"},
+			},
+			want: `TIMELINE_TIMESTAMP,TIMELINE_SOURCE,_HOSTNAME,SYSLOG_IDENTIFIER,_PID,PRIORITY,MESSAGE
+2024-03-10T14:00:00Z,controller,controller.zip,sshd,,,first
+2024-03-10T14:00:11Z,gateway,gateway.zip,sshd,,,second
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			tt.opts.Out = stdout
+			if err := logsTimelineRun([]string{controller, gateway}, &tt.opts); err != nil {
+				t.Fatalf("logsTimelineRun() unexpected error %s", err)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+	assertEmptyDir(t, os.Getenv("TMPDIR"))
+}
+
+func TestLogsTimelineMergesJournalFiles(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	start := time.Date(2024, time.March, 10, 14, 0, 0, 0, time.UTC)
+	entry := func(message string, at time.Time) []string {
+		fields := []string{"SYSLOG_IDENTIFIER=sshd", "_HOSTNAME=controller", "MESSAGE=" + message}
+		if !at.IsZero() {
+			fields = append(fields, fmt.Sprintf("_SOURCE_REALTIME_TIMESTAMP=%d", at.UnixMicro()))
+		}
+		return fields
+	}
+	bundle := filepath.Join(t.TempDir(), "controller.zip")
+	zf, err := os.Create(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zf)
+	// the user journal is sorted before the system journal, but its entries are in between the system entries,
+	// and its first entry has no timestamp
+	for _, j := range []struct {
+		name     string
+		seqnumID byte
+		head     time.Time
+		entries  [][]string
+	}{
+		{"system.journal", 2, start, [][]string{entry("first", start), entry("third", start.Add(2*time.Second))}},
+		{"user-1000.journal", 1, start.Add(time.Second), [][]string{entry("second", time.Time{}), entry("fourth", start.Add(3*time.Second))}},
+	} {
+		journalPath := filepath.Join(t.TempDir(), j.name)
+		buildJournalEntries(t, journalPath, j.entries...)
+		b, err := os.ReadFile(journalPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b[72] = j.seqnumID                                                 // seqnum_id
+		binary.LittleEndian.PutUint64(b[184:], uint64(j.head.UnixMicro())) // head_entry_realtime
+		w, err := zw.Create("abc123/" + j.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(b)
+	}
+	zw.Close()
+	zf.Close()
+
+	tests := []struct {
+		name string
+		opts logsTimelineOpts
+		want string
+	}{
+		{
+			name: "ordered by time",
+			want: `2024-03-10T14:00:00Z controller controller sshd[]: first
+2024-03-10T14:00:01Z controller controller sshd[]: second
+2024-03-10T14:00:02Z controller controller sshd[]: third
+2024-03-10T14:00:03Z controller controller sshd[]: fourth
+`,
+		},
+		{
+			name: "entry without timestamp uses the head time of the file",
+			opts: logsTimelineOpts{logextractOpts: logextractOpts{Since: "2024-03-10T14:00:01Z", Until: "2024-03-10T14:00:02Z"}},
+			want: `2024-03-10T14:00:01Z controller controller sshd[]: second
+2024-03-10T14:00:02Z controller controller sshd[]: third
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			tt.opts.Out = stdout
+			if err := logsTimelineRun([]string{bundle}, &tt.opts); err != nil {
+				t.Fatalf("logsTimelineRun() unexpected error %s", err)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+	assertEmptyDir(t, os.Getenv("TMPDIR"))
+}
+
+func TestLogsTimelineInvalidOffset(t *testing.T) {
+	tests := []string{
+		"controller.zip",
+		"controller.zip=soon",
+		"gateway.zip=1s",
+	}
+	for _, offset := range tests {
+		if _, err := newTimelineSources([]string{"/tmp/controller.zip"}, []string{offset}); err == nil {
+			t.Errorf("newTimelineSources() expected error for --offset %s", offset)
+		}
+	}
+}
@@ -2,18 +2,22 @@ package appliance
 
 import (
 	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"path"
+	"strings"
 
 	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
 	"github.com/appgate/sdpctl/pkg/api"
 	appliancepkg "github.com/appgate/sdpctl/pkg/appliance"
-	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
 	"github.com/appgate/sdpctl/pkg/util"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 )
 
 type metricOptions struct {
@@ -24,6 +28,11 @@ type metricOptions struct {
 	debug       bool
 	applianceID string
 	metric      string
+	json        bool
+	csv         bool
+	labels      map[string]string
+	all         bool
+	throttle    int
 }
 
 // NewMetricCmd return a new appliance metric command
@@ -42,6 +51,18 @@ func NewMetricCmd(f *factory.Factory) *cobra.Command {
 		Example: docs.ApplianceMetricsDoc.ExampleString(),
 		Aliases: []string{"metrics"},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.json && opts.csv {
+				return errors.New("the '--json' and '--csv' flags are mutually exclusive")
+			}
+			if opts.all {
+				if len(args) > 1 {
+					return errors.New("only a metric name can be given as argument with '--all-appliances'")
+				}
+				if len(args) == 1 {
+					opts.metric = args[0]
+				}
+				return nil
+			}
 			a, err := opts.Appliance(opts.Config)
 			if err != nil {
 				return err
@@ -90,10 +111,24 @@ func NewMetricCmd(f *factory.Factory) *cobra.Command {
 			return metricRun(c, args, &opts)
 		},
 	}
-	cmd.SetHelpFunc(cmdutil.HideIncludeExcludeFlags)
+	cmd.Flags().BoolVar(&opts.json, "json", false, "Display the samples in JSON format")
+	cmd.Flags().BoolVar(&opts.csv, "csv", false, "Display the samples in CSV format")
+	cmd.Flags().StringToStringVar(&opts.labels, "label", map[string]string{}, "Only show samples with the given label values, for example '--label type=appliance_status_changed'")
+	cmd.Flags().BoolVar(&opts.all, "all-appliances", false, "Get the metrics from all appliances matching '--include' and '--exclude'")
+	cmd.Flags().IntVar(&opts.throttle, "throttle", 5, "Number of appliances to get metrics from at the same time when using '--all-appliances'")
 	return cmd
 }
 
+// isMetricGlob reports whether the metric name is a pattern, such as 'vpn_*', that matches several metrics
+func isMetricGlob(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// structured reports whether the metrics have to be parsed, instead of printing the text from the appliance
+func (opts *metricOptions) structured() bool {
+	return opts.json || opts.csv || opts.all || len(opts.labels) > 0 || isMetricGlob(opts.metric)
+}
+
 func metricRun(cmd *cobra.Command, args []string, opts *metricOptions) error {
 	client, err := opts.APIClient(opts.Config)
 	if err != nil {
@@ -104,28 +139,170 @@ func metricRun(cmd *cobra.Command, args []string, opts *metricOptions) error {
 		return err
 	}
 	ctx := util.BaseAuthContext(a.Token)
-	ctx = context.WithValue(
-		ctx,
-		openapi.ContextAcceptHeader,
-		fmt.Sprintf("application/vnd.appgate.peer-v%d+text", opts.Config.Version),
-	)
 	_, err = opts.Config.GetBearTokenHeaderValue()
 	if err != nil {
 		return err
 	}
-	if len(opts.metric) > 0 {
-		data, response, err := client.ApplianceMetricsApi.AppliancesIdMetricsNameGet(ctx, opts.applianceID, opts.metric).Execute()
+	if !opts.structured() {
+		data, err := getMetrics(ctx, client, opts, opts.applianceID, opts.metric)
 		if err != nil {
-			return api.HTTPErrorResponse(response, err)
+			return err
 		}
 		fmt.Fprintln(opts.Out, data)
 		return nil
 	}
-	data, response, err := client.ApplianceMetricsApi.AppliancesIdMetricsGet(ctx, opts.applianceID).Execute()
+	if isMetricGlob(opts.metric) {
+		if _, err := path.Match(opts.metric, ""); err != nil {
+			return fmt.Errorf("invalid metric name pattern %q: %w", opts.metric, err)
+		}
+	}
+
+	if !opts.all {
+		samples, err := getMetricSamples(ctx, client, opts, opts.applianceID)
+		if err != nil {
+			return err
+		}
+		return printMetricSamples(opts, samples)
+	}
+
+	filter, orderBy, descending := util.ParseFilteringFlags(cmd.Flags(), appliancepkg.DefaultCommandFilter)
+	appliances, err := a.List(ctx, filter, orderBy, descending)
+	if err != nil {
+		return err
+	}
+	if len(appliances) == 0 {
+		return errors.New("No appliances matched the filters")
+	}
+	results := make([][]appliancepkg.MetricSample, len(appliances))
+	g, gctx := errgroup.WithContext(ctx)
+	if opts.throttle > 0 {
+		g.SetLimit(opts.throttle)
+	}
+	for i, appliance := range appliances {
+		g.Go(func() error {
+			samples, err := getMetricSamples(gctx, client, opts, appliance.GetId())
+			if err != nil {
+				return fmt.Errorf("%s: %w", appliance.GetName(), err)
+			}
+			for j := range samples {
+				samples[j].Appliance = appliance.GetName()
+			}
+			results[i] = samples
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	samples := make([]appliancepkg.MetricSample, 0)
+	for _, r := range results {
+		samples = append(samples, r...)
+	}
+	return printMetricSamples(opts, samples)
+}
+
+// getMetrics returns the metrics of an appliance in the Prometheus text format, either all of them or a single metric
+func getMetrics(ctx context.Context, client *openapi.APIClient, opts *metricOptions, applianceID, metric string) (string, error) {
+	ctx = context.WithValue(
+		ctx,
+		openapi.ContextAcceptHeader,
+		fmt.Sprintf("application/vnd.appgate.peer-v%d+text", opts.Config.Version),
+	)
+	if len(metric) > 0 {
+		data, response, err := client.ApplianceMetricsApi.AppliancesIdMetricsNameGet(ctx, applianceID, metric).Execute()
+		if err != nil {
+			return "", api.HTTPErrorResponse(response, err)
+		}
+		return data, nil
+	}
+	data, response, err := client.ApplianceMetricsApi.AppliancesIdMetricsGet(ctx, applianceID).Execute()
+	if err != nil {
+		return "", api.HTTPErrorResponse(response, err)
+	}
+	return data, nil
+}
+
+// getMetricSamples returns the parsed samples of an appliance that match the metric name or pattern and the labels
+func getMetricSamples(ctx context.Context, client *openapi.APIClient, opts *metricOptions, applianceID string) ([]appliancepkg.MetricSample, error) {
+	metric := opts.metric
+	if isMetricGlob(metric) {
+		// the API only supports exact names, so all metrics are fetched and matched here
+		metric = ""
+	}
+	data, err := getMetrics(ctx, client, opts, applianceID, metric)
 	if err != nil {
-		return api.HTTPErrorResponse(response, err)
+		return nil, err
+	}
+	samples, err := appliancepkg.ParseMetrics(data)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]appliancepkg.MetricSample, 0, len(samples))
+	for _, s := range samples {
+		if isMetricGlob(opts.metric) {
+			if ok, _ := path.Match(opts.metric, s.Name); !ok {
+				continue
+			}
+		}
+		if !matchMetricLabels(s, opts.labels) {
+			continue
+		}
+		result = append(result, s)
 	}
+	return result, nil
+}
 
-	fmt.Fprintln(opts.Out, data)
+func matchMetricLabels(s appliancepkg.MetricSample, labels map[string]string) bool {
+	for k, v := range labels {
+		if s.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func printMetricSamples(opts *metricOptions, samples []appliancepkg.MetricSample) error {
+	if opts.json {
+		return util.PrintJSON(opts.Out, samples)
+	}
+	if opts.csv {
+		w := csv.NewWriter(opts.Out)
+		header := []string{"name", "type", "labels", "value", "timestamp"}
+		if opts.all {
+			header = append([]string{"appliance"}, header...)
+		}
+		if err := w.Write(header); err != nil {
+			return err
+		}
+		for _, s := range samples {
+			timestamp := ""
+			if s.Timestamp != nil {
+				timestamp = fmt.Sprintf("%d", *s.Timestamp)
+			}
+			record := []string{s.Name, s.Type, s.LabelString(), s.Value.String(), timestamp}
+			if opts.all {
+				record = append([]string{s.Appliance}, record...)
+			}
+			if err := w.Write(record); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+	p := util.NewPrinter(opts.Out, 4)
+	if opts.all {
+		p.AddHeader("Appliance", "Name", "Labels", "Value")
+	} else {
+		p.AddHeader("Name", "Labels", "Value")
+	}
+	for _, s := range samples {
+		if opts.all {
+			p.AddLine(s.Appliance, s.Name, s.LabelString(), s.Value.String())
+		} else {
+			p.AddLine(s.Name, s.LabelString(), s.Value.String())
+		}
+	}
+	p.Print()
 	return nil
 }
@@ -136,3 +136,102 @@ func TestMetricCommandSpecificMetric(t *testing.T) {
 		t.Fatalf("executeC %s", err)
 	}
 }
+
+const metricTestData = `
+# HELP vpn_total_sessions Number of sessions
+# TYPE vpn_total_sessions gauge
+vpn_total_sessions 5.0
+# HELP vpn_session_bytes Bytes sent and received
+# TYPE vpn_session_bytes counter
+vpn_session_bytes{direction="in"} 1024.0
+vpn_session_bytes{direction="out"} 2048.0
+# HELP audit_event Total audit event count
+# TYPE audit_event counter
+audit_event{type="appliance_status_changed"} 3.0
+`
+
+func TestMetricCommandStructured(t *testing.T) {
+	registry := httpmock.NewRegistry(t)
+	registry.Register("/admin/appliances", httpmock.JSONResponse("../../pkg/appliance/fixtures/appliance_list.json"))
+	for _, id := range []string{"4c07bc67-57ea-42dd-b702-c2d6c45419fc", "ee639d70-e075-4f01-596b-930d5f24f569"} {
+		registry.Register(
+			fmt.Sprintf("/admin/appliances/%s/metrics", id),
+			func(rw http.ResponseWriter, r *http.Request) {
+				rw.Header().Set("Content-Type", "application/plain")
+				rw.WriteHeader(http.StatusOK)
+				fmt.Fprint(rw, metricTestData)
+			},
+		)
+	}
+	defer registry.Teardown()
+	registry.Serve()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "glob and label as JSON",
+			args: []string{"4c07bc67-57ea-42dd-b702-c2d6c45419fc", "vpn_*", "--label", "direction=out", "--json"},
+			want: `[
+  {
+    "name": "vpn_session_bytes",
+    "type": "counter",
+    "labels": {
+      "direction": "out"
+    },
+    "value": 2048
+  }
+]
+`,
+		},
+		{
+			name: "all appliances as CSV",
+			args: []string{"vpn_total_*", "--all-appliances", "--csv"},
+			want: `appliance,name,type,labels,value,timestamp
+controller-4c07bc67-57ea-42dd-b702-c2d6c45419fc-site1,vpn_total_sessions,gauge,,5,
+gateway-da0375f6-0b28-4248-bd54-a933c4c39008-site1,vpn_total_sessions,gauge,,5,
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			f := &factory.Factory{
+				Config: &configuration.Config{
+					Debug: false,
+					URL:   fmt.Sprintf("http://localhost:%d", registry.Port),
+				},
+				IOOutWriter: stdout,
+				Stdin:       io.NopCloser(&bytes.Buffer{}),
+				StdErr:      &bytes.Buffer{},
+			}
+			f.APIClient = func(c *configuration.Config) (*openapi.APIClient, error) {
+				return registry.Client, nil
+			}
+			f.Appliance = func(c *configuration.Config) (*appliance.Appliance, error) {
+				api, _ := f.APIClient(c)
+				return &appliance.Appliance{
+					APIClient:  api,
+					HTTPClient: api.GetConfig().HTTPClient,
+					Token:      "",
+				}, nil
+			}
+			cmd := NewMetricCmd(f)
+			cmd.PersistentFlags().Bool("descending", false, "")
+			cmd.PersistentFlags().StringSlice("order-by", []string{"name"}, "")
+			cmd.PersistentFlags().StringToStringP("include", "i", map[string]string{}, "")
+			cmd.PersistentFlags().StringToStringP("exclude", "e", map[string]string{}, "")
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			if _, err := cmd.ExecuteC(); err != nil {
+				t.Fatalf("executeC %s", err)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("want:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
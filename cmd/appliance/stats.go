@@ -1,12 +1,14 @@
 package appliance
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
 	appliancepkg "github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
@@ -15,11 +17,13 @@ import (
 )
 
 type statsOptions struct {
-	Config    *configuration.Config
-	Out       io.Writer
-	Appliance func(c *configuration.Config) (*appliancepkg.Appliance, error)
-	debug     bool
-	json      bool
+	Config         *configuration.Config
+	Out            io.Writer
+	Appliance      func(c *configuration.Config) (*appliancepkg.Appliance, error)
+	debug          bool
+	output         *cmdutil.OutputOptions
+	serve          string
+	scrapeInterval time.Duration
 }
 
 var (
@@ -45,44 +49,53 @@ func NewStatsCmd(f *factory.Factory) *cobra.Command {
 			return statsRun(c, args, &opts)
 		},
 	}
-	listCmd.Flags().BoolVar(&opts.json, "json", false, "Display in JSON format")
+	opts.output = cmdutil.AddOutputFlags(listCmd, cmdutil.OutputTable)
+	listCmd.Flags().StringVar(&opts.serve, "serve", "", "Serve the stats as Prometheus metrics on http://<address>/metrics, for example ':9850'")
+	listCmd.Flags().DurationVar(&opts.scrapeInterval, "scrape-interval", 30*time.Second, "How long the stats are cached when using '--serve'")
 	listCmd.PersistentFlags().StringToStringP("include", "i", map[string]string{}, "Include appliance stats. Adheres to the same syntax and key-value pairs as '--exclude'")
 	listCmd.PersistentFlags().StringToStringP("exclude", "e", map[string]string{}, filterStatsHelp)
+	listCmd.AddCommand(NewStatsRecordCmd(f), NewStatsHistoryCmd(f))
 	return listCmd
 }
 
 func statsRun(cmd *cobra.Command, args []string, opts *statsOptions) error {
+	if err := opts.output.Validate(); err != nil {
+		return err
+	}
 	cfg := opts.Config
 	a, err := opts.Appliance(cfg)
 	if err != nil {
 		return err
 	}
 	filter, orderBy, descending := util.ParseFilteringFlags(cmd.Flags(), appliancepkg.DefaultCommandFilter)
+	if len(opts.serve) > 0 {
+		if opts.scrapeInterval <= 0 {
+			return errors.New("'--scrape-interval' must be greater than zero")
+		}
+		return statsServe(opts, &statsExporter{
+			opts:       opts,
+			filter:     filter,
+			orderBy:    orderBy,
+			descending: descending,
+			interval:   opts.scrapeInterval,
+		})
+	}
 	ctx := util.BaseAuthContext(a.Token)
 	stats, _, err := a.ApplianceStatus(ctx, filter, orderBy, descending)
 	if err != nil {
 		return err
 	}
-	if opts.json {
-		j, err := json.MarshalIndent(&stats, "", "  ")
-		if err != nil {
-			return err
-		}
-		fmt.Fprintf(opts.Out, "\n%s\n", string(j))
-		return nil
-	}
-	w := util.NewPrinter(opts.Out, 4)
 	diskHeader := "Disk"
 	if cfg.Version >= 18 {
 		diskHeader += " (used / total)"
 	}
-	w.AddHeader("Name", "Status", "Function", "CPU", "Memory", "Network out/in", diskHeader, "Version", "Sessions")
+	t := cmdutil.NewTable("Name", "Status", "Function", "CPU", "Memory", "Network out/in", diskHeader, "Version", "Sessions")
 	for _, s := range stats.GetData() {
 		version := s.GetApplianceVersion()
 		if v, err := appliancepkg.ParseVersionString(version); err == nil {
 			version = v.String()
 		}
-		w.AddLine(
+		t.AddRow(
 			s.GetName(),
 			s.GetStatus(),
 			appliancepkg.ApplianceActiveFunctions(s),
@@ -94,8 +107,7 @@ func statsRun(cmd *cobra.Command, args []string, opts *statsOptions) error {
 			s.GetNumberOfSessions(),
 		)
 	}
-	w.Print()
-	return nil
+	return opts.output.Print(opts.Out, stats, t)
 }
 
 func statsNetworkPrettyPrint(n *openapi.NetworkInfo) string {
@@ -112,3 +124,17 @@ func statsDiskUsage(stats openapi.ApplianceWithStatus) string {
 	}
 	return fmt.Sprintf("%g%%", stats.GetDisk())
 }
+
+// collectStats gets the appliance stats for the long running stats commands, the factory signs in again when the
+// bearer token expires between collections
+func (opts *statsOptions) collectStats(filter map[string]map[string]string, orderBy []string, descending bool) ([]openapi.ApplianceWithStatus, error) {
+	a, err := opts.Appliance(opts.Config)
+	if err != nil {
+		return nil, err
+	}
+	stats, _, err := a.ApplianceStatus(util.BaseAuthContext(a.Token), filter, orderBy, descending)
+	if err != nil {
+		return nil, err
+	}
+	return stats.GetData(), nil
+}
@@ -0,0 +1,217 @@
+package appliance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	appliancepkg "github.com/appgate/sdpctl/pkg/appliance"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// metricsContentType is the Prometheus text exposition format
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// statsExporter serves the appliance stats as Prometheus metrics. The stats are cached
+// for the scrape interval, so that several scrapes do not each query the Controller.
+type statsExporter struct {
+	opts       *statsOptions
+	filter     map[string]map[string]string
+	orderBy    []string
+	descending bool
+	interval   time.Duration
+
+	mu      sync.Mutex
+	metrics []byte
+	updated time.Time
+}
+
+func statsServe(opts *statsOptions, exporter *statsExporter) error {
+	listener, err := net.Listen("tcp", opts.serve)
+	if err != nil {
+		return fmt.Errorf("could not start metrics endpoint: %w", err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(listener)
+	}()
+	fmt.Fprintf(opts.Out, "Serving appliance metrics on http://%s/metrics\n", listener.Addr().String())
+
+	select {
+	case <-ctx.Done():
+		fmt.Fprintln(opts.Out, "Stopping metrics endpoint")
+		return server.Shutdown(context.Background())
+	case err := <-errs:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+func (e *statsExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", metricsContentType)
+	w.Write(e.scrape())
+}
+
+// scrape returns the cached metrics, or collects new metrics if the cache is older than the scrape interval
+func (e *statsExporter) scrape() []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.metrics != nil && time.Since(e.updated) < e.interval {
+		return e.metrics
+	}
+	started := time.Now()
+	stats, err := e.opts.collectStats(e.filter, e.orderBy, e.descending)
+	if err != nil {
+		log.WithError(err).Error("failed to collect appliance stats")
+	}
+	e.metrics = formatStatsMetrics(stats, err == nil, time.Since(started))
+	e.updated = time.Now()
+	return e.metrics
+}
+
+// metricFamily is a metric name with its samples, written in the Prometheus text format
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples []metricSample
+}
+
+type metricSample struct {
+	labels [][2]string
+	value  float64
+}
+
+func (m *metricFamily) add(value float64, labels ...[2]string) {
+	m.samples = append(m.samples, metricSample{labels: labels, value: value})
+}
+
+func (m *metricFamily) write(b *bytes.Buffer) {
+	if len(m.samples) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "# HELP %s %s\n", m.name, m.help)
+	fmt.Fprintf(b, "# TYPE %s %s\n", m.name, m.kind)
+	for _, s := range m.samples {
+		b.WriteString(m.name)
+		if len(s.labels) > 0 {
+			pairs := make([]string, 0, len(s.labels))
+			for _, l := range s.labels {
+				pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", l[0], escapeLabelValue(l[1])))
+			}
+			fmt.Fprintf(b, "{%s}", strings.Join(pairs, ","))
+		}
+		fmt.Fprintf(b, " %g\n", s.value)
+	}
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+func label(name, value string) [2]string {
+	return [2]string{name, value}
+}
+
+// formatStatsMetrics returns the appliance stats in the Prometheus text format
+func formatStatsMetrics(stats []openapi.ApplianceWithStatus, success bool, duration time.Duration) []byte {
+	var (
+		up       = &metricFamily{name: "sdpctl_appliance_up", kind: "gauge", help: "Whether the appliance is online (1) or offline (0)."}
+		status   = &metricFamily{name: "sdpctl_appliance_status", kind: "gauge", help: "Always 1, with the current status of the appliance as label."}
+		info     = &metricFamily{name: "sdpctl_appliance_info", kind: "gauge", help: "Always 1, with the version, functions and site of the appliance as labels."}
+		cpu      = &metricFamily{name: "sdpctl_appliance_cpu_usage_percent", kind: "gauge", help: "CPU usage of the appliance in percent."}
+		memory   = &metricFamily{name: "sdpctl_appliance_memory_usage_percent", kind: "gauge", help: "Memory usage of the appliance in percent."}
+		disk     = &metricFamily{name: "sdpctl_appliance_disk_usage_percent", kind: "gauge", help: "Disk usage of the appliance in percent."}
+		diskUsed = &metricFamily{name: "sdpctl_appliance_disk_used_bytes", kind: "gauge", help: "Used disk space of the appliance in bytes."}
+		diskSize = &metricFamily{name: "sdpctl_appliance_disk_total_bytes", kind: "gauge", help: "Total disk space of the appliance in bytes."}
+		tx       = &metricFamily{name: "sdpctl_appliance_network_transmit_bits_per_second", kind: "gauge", help: "Transmit speed of the busiest network interface of the appliance."}
+		rx       = &metricFamily{name: "sdpctl_appliance_network_receive_bits_per_second", kind: "gauge", help: "Receive speed of the busiest network interface of the appliance."}
+		sessions = &metricFamily{name: "sdpctl_appliance_sessions", kind: "gauge", help: "Number of sessions on the appliance."}
+		upgrade  = &metricFamily{name: "sdpctl_appliance_upgrade_status", kind: "gauge", help: "Always 1, with the current upgrade status of the appliance as label."}
+	)
+	for _, s := range stats {
+		appliance := [][2]string{label("appliance", s.GetName()), label("id", s.GetId())}
+		online := 1.0
+		if s.GetStatus() == "offline" {
+			online = 0
+		}
+		up.add(online, appliance...)
+		status.add(1, append(appliance, label("status", s.GetStatus()))...)
+
+		version := s.GetApplianceVersion()
+		if v, err := appliancepkg.ParseVersionString(version); err == nil {
+			version = v.String()
+		}
+		info.add(1, append(appliance,
+			label("version", version),
+			label("functions", appliancepkg.ApplianceActiveFunctions(s)),
+			label("site", s.GetSiteName()),
+		)...)
+
+		cpu.add(appliancepkg.ExactFloat64(s.GetCpu()), appliance...)
+		memory.add(appliancepkg.ExactFloat64(s.GetMemory()), appliance...)
+		disk.add(appliancepkg.ExactFloat64(s.GetDisk()), appliance...)
+		details := s.GetDetails()
+		if d := details.Disk; d != nil && d.GetTotal() > 0 {
+			diskUsed.add(float64(d.GetUsed()), appliance...)
+			diskSize.add(float64(d.GetTotal()), appliance...)
+		}
+		if n := details.Network; n != nil {
+			nic := n.GetBusiestNic()
+			if nicDetails, ok := n.GetDetails()[nic]; ok {
+				nicLabels := append(appliance, label("nic", nic))
+				if v, err := appliancepkg.ParseNetworkSpeed(nicDetails.GetTxSpeed()); err == nil {
+					tx.add(v, nicLabels...)
+				}
+				if v, err := appliancepkg.ParseNetworkSpeed(nicDetails.GetRxSpeed()); err == nil {
+					rx.add(v, nicLabels...)
+				}
+			}
+		}
+		sessions.add(float64(s.GetNumberOfSessions()), appliance...)
+		if us := details.Upgrade; us != nil {
+			upgrade.add(1, append(appliance, label("status", us.GetStatus()), label("details", us.GetDetails()))...)
+		}
+	}
+
+	scrapeSuccess := &metricFamily{name: "sdpctl_scrape_success", kind: "gauge", help: "Whether the last collection of the appliance stats succeeded."}
+	scrapeDuration := &metricFamily{name: "sdpctl_scrape_duration_seconds", kind: "gauge", help: "Duration of the last collection of the appliance stats."}
+	successValue := 0.0
+	if success {
+		successValue = 1
+	}
+	scrapeSuccess.add(successValue)
+	scrapeDuration.add(duration.Seconds())
+
+	b := &bytes.Buffer{}
+	for _, f := range []*metricFamily{up, status, info, cpu, memory, disk, diskUsed, diskSize, tx, rx, sessions, upgrade, scrapeSuccess, scrapeDuration} {
+		f.write(b)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,109 @@
+package appliance
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/httpmock"
+)
+
+func TestStatsExporter(t *testing.T) {
+	statusRequests := 0
+	registry := httpmock.NewRegistry(t)
+	registry.Register(
+		"/admin/appliances/status",
+		func(rw http.ResponseWriter, r *http.Request) {
+			statusRequests++
+			httpmock.JSONResponse("../../pkg/appliance/fixtures/stats_appliance.json")(rw, r)
+		},
+	)
+	defer registry.Teardown()
+	registry.Serve()
+
+	opts := &statsOptions{
+		Config: &configuration.Config{},
+		Appliance: func(c *configuration.Config) (*appliance.Appliance, error) {
+			return &appliance.Appliance{
+				APIClient:  registry.Client,
+				HTTPClient: registry.Client.GetConfig().HTTPClient,
+			}, nil
+		},
+	}
+	exporter := &statsExporter{opts: opts, interval: time.Hour}
+	server := httptest.NewServer(exporter)
+	defer server.Close()
+
+	scrape := func() string {
+		t.Helper()
+		response, err := http.Get(server.URL + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		if ct := response.Header.Get("Content-Type"); ct != metricsContentType {
+			t.Errorf("got Content-Type %s, want %s", ct, metricsContentType)
+		}
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	got := scrape()
+	wantLines := []string{
+		`sdpctl_appliance_up{appliance="controller-4c07bc67-57ea-42dd-b702-c2d6c45419fc-site1",id="4c07bc67-57ea-42dd-b702-c2d6c45419fc"} 1`,
+		`sdpctl_appliance_cpu_usage_percent{appliance="controller-4c07bc67-57ea-42dd-b702-c2d6c45419fc-site1",id="4c07bc67-57ea-42dd-b702-c2d6c45419fc"} 0.8`,
+		`sdpctl_appliance_info{appliance="controller-4c07bc67-57ea-42dd-b702-c2d6c45419fc-site1",id="4c07bc67-57ea-42dd-b702-c2d6c45419fc",version="6.2.1",functions="LogServer, Controller",site="Default Site"} 1`,
+		`sdpctl_appliance_network_receive_bits_per_second{appliance="controller-4c07bc67-57ea-42dd-b702-c2d6c45419fc-site1",id="4c07bc67-57ea-42dd-b702-c2d6c45419fc",nic="eth0"} 260`,
+		`sdpctl_appliance_sessions{appliance="gateway-da0375f6-0b28-4248-bd54-a933c4c39008-site1",id="ee639d70-e075-4f01-596b-930d5f24f569"} 5`,
+		`sdpctl_appliance_upgrade_status{appliance="gateway-da0375f6-0b28-4248-bd54-a933c4c39008-site1",id="ee639d70-e075-4f01-596b-930d5f24f569",status="ready",details="6.2.2"} 1`,
+		"# TYPE sdpctl_appliance_up gauge",
+		"sdpctl_scrape_success 1",
+	}
+	for _, want := range wantLines {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("metrics do not contain %q, got\n%s", want, got)
+		}
+	}
+
+	// a second scrape within the interval is served from the cache
+	if cached := scrape(); cached != got {
+		t.Errorf("expected the cached metrics, got\n%s", cached)
+	}
+	if statusRequests != 1 {
+		t.Errorf("expected 1 request to the Controller, got %d", statusRequests)
+	}
+}
+
+func TestStatsExporterCollectError(t *testing.T) {
+	opts := &statsOptions{
+		Config: &configuration.Config{},
+		Appliance: func(c *configuration.Config) (*appliance.Appliance, error) {
+			return nil, fmt.Errorf("no credentials")
+		},
+	}
+	exporter := &statsExporter{opts: opts, interval: time.Hour}
+	got := string(exporter.scrape())
+	if !strings.Contains(got, "sdpctl_scrape_success 0\n") {
+		t.Errorf("expected a failed scrape, got\n%s", got)
+	}
+	if strings.Contains(got, "sdpctl_appliance_up") {
+		t.Errorf("expected no appliance metrics, got\n%s", got)
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	got := escapeLabelValue("a \"quoted\" \\ value\nwith newline")
+	want := `a \"quoted\" \\ value\nwith newline`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+package appliance
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	appliancepkg "github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/tui"
+	"github.com/appgate/sdpctl/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type statsHistoryOptions struct {
+	Out       io.Writer
+	path      string
+	appliance string
+	since     string
+	width     int
+	json      bool
+}
+
+// NewStatsHistoryCmd return a new appliance stats history command
+func NewStatsHistoryCmd(f *factory.Factory) *cobra.Command {
+	opts := statsHistoryOptions{
+		Out: f.IOOutWriter,
+	}
+	cmd := &cobra.Command{
+		Use:     "history",
+		Short:   docs.ApplianceStatsHistoryDoc.Short,
+		Long:    docs.ApplianceStatsHistoryDoc.Long,
+		Example: docs.ApplianceStatsHistoryDoc.ExampleString(),
+		Args:    cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			return statsHistoryRun(&opts)
+		},
+		Annotations: map[string]string{
+			configuration.SkipAuthCheck: "true",
+		},
+	}
+	cmd.Flags().StringVar(&opts.path, "in", appliancepkg.DefaultStatsHistoryFile(), "File with the snapshots recorded by 'sdpctl appliance stats record'")
+	cmd.Flags().StringVar(&opts.appliance, "appliance", "", "Only show appliances with a name matching the regular expression")
+	cmd.Flags().StringVar(&opts.since, "since", "24h", "Only use snapshots taken after this time, a duration like '24h' or a timestamp like '2006-01-02 15:04:05' in the local time zone")
+	cmd.Flags().IntVar(&opts.width, "width", 30, "Maximum number of characters in the trend column")
+	cmd.Flags().BoolVar(&opts.json, "json", false, "Display in JSON format")
+	return cmd
+}
+
+// statsTrend is the summary of one metric of an appliance over the recorded snapshots
+type statsTrend struct {
+	Appliance string    `json:"appliance"`
+	Metric    string    `json:"metric"`
+	Min       float64   `json:"min"`
+	Avg       float64   `json:"avg"`
+	Max       float64   `json:"max"`
+	Last      float64   `json:"last"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	values    []float64
+	format    func(v float64) string
+}
+
+// statsHistoryMetrics are the metrics shown by 'stats history', in order
+var statsHistoryMetrics = []struct {
+	name   string
+	value  func(s appliancepkg.StatsSample) float64
+	format func(v float64) string
+}{
+	{"cpu", func(s appliancepkg.StatsSample) float64 { return s.CPU }, formatPercent},
+	{"memory", func(s appliancepkg.StatsSample) float64 { return s.Memory }, formatPercent},
+	{"disk", func(s appliancepkg.StatsSample) float64 { return s.Disk }, formatPercent},
+	{"sessions", func(s appliancepkg.StatsSample) float64 { return float64(s.Sessions) }, func(v float64) string { return fmt.Sprintf("%.1f", v) }},
+	{"net-out", func(s appliancepkg.StatsSample) float64 { return s.NetworkOut }, formatBitsPerSecond},
+	{"net-in", func(s appliancepkg.StatsSample) float64 { return s.NetworkIn }, formatBitsPerSecond},
+}
+
+func statsHistoryRun(opts *statsHistoryOptions) error {
+	since, err := parseJournalTime(opts.since)
+	if err != nil {
+		return fmt.Errorf("invalid --since value: %w", err)
+	}
+	var nameFilter *regexp.Regexp
+	if len(opts.appliance) > 0 {
+		if nameFilter, err = regexp.Compile(opts.appliance); err != nil {
+			return fmt.Errorf("invalid --appliance value: %w", err)
+		}
+	}
+	snapshots, err := appliancepkg.ReadStatsSnapshots(opts.path, since)
+	if err != nil {
+		return err
+	}
+	trends := statsTrends(snapshots, nameFilter)
+	if len(trends) == 0 {
+		return fmt.Errorf("no stats recorded since %s", since.Format(time.RFC3339))
+	}
+	if opts.json {
+		return util.PrintJSON(opts.Out, trends)
+	}
+
+	fmt.Fprintf(opts.Out, "%d snapshots from %s to %s\n\n", len(snapshots), snapshots[0].Time.Format(time.RFC3339), snapshots[len(snapshots)-1].Time.Format(time.RFC3339))
+	p := util.NewPrinter(opts.Out, 4)
+	p.AddHeader("Appliance", "Metric", "Min", "Avg", "Max", "Last", "Trend")
+	for _, t := range trends {
+		p.AddLine(t.Appliance, t.Metric, t.format(t.Min), t.format(t.Avg), t.format(t.Max), t.format(t.Last), tui.Sparkline(t.values, opts.width))
+	}
+	p.Print()
+	return nil
+}
+
+// statsTrends returns a trend for each metric of each appliance, with the appliances in the order they were first recorded
+func statsTrends(snapshots []appliancepkg.StatsSnapshot, nameFilter *regexp.Regexp) []*statsTrend {
+	trends := make([]*statsTrend, 0)
+	byAppliance := map[string][]*statsTrend{}
+	for _, snapshot := range snapshots {
+		for _, sample := range snapshot.Appliances {
+			if nameFilter != nil && !nameFilter.MatchString(sample.Name) {
+				continue
+			}
+			applianceTrends, ok := byAppliance[sample.Name]
+			if !ok {
+				for _, m := range statsHistoryMetrics {
+					applianceTrends = append(applianceTrends, &statsTrend{Appliance: sample.Name, Metric: m.name, From: snapshot.Time, format: m.format})
+				}
+				byAppliance[sample.Name] = applianceTrends
+				trends = append(trends, applianceTrends...)
+			}
+			for i, m := range statsHistoryMetrics {
+				t := applianceTrends[i]
+				t.values = append(t.values, m.value(sample))
+				t.To = snapshot.Time
+			}
+		}
+	}
+	for _, t := range trends {
+		t.Min, t.Max = t.values[0], t.values[0]
+		sum := 0.0
+		for _, v := range t.values {
+			t.Min = min(t.Min, v)
+			t.Max = max(t.Max, v)
+			sum += v
+		}
+		t.Avg = sum / float64(len(t.values))
+		t.Last = t.values[len(t.values)-1]
+	}
+	return trends
+}
+
+func formatPercent(v float64) string {
+	return fmt.Sprintf("%.1f%%", v)
+}
+
+// formatBitsPerSecond formats a network speed the same way as the appliance stats, for example '0.26 Kbps'
+func formatBitsPerSecond(v float64) string {
+	units := []string{"bps", "Kbps", "Mbps", "Gbps"}
+	i := 0
+	for v >= 1000 && i < len(units)-1 {
+		v /= 1000
+		i++
+	}
+	return fmt.Sprintf("%.2f %s", v, units[i])
+}
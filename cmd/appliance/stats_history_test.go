@@ -0,0 +1,103 @@
+package appliance
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	appliancepkg "github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/httpmock"
+)
+
+func TestStatsRecordAndHistory(t *testing.T) {
+	registry := httpmock.NewRegistry(t)
+	registry.Register(
+		"/admin/appliances/status",
+		httpmock.JSONResponse("../../pkg/appliance/fixtures/stats_appliance.json"),
+	)
+	defer registry.Teardown()
+	registry.Serve()
+
+	path := filepath.Join(t.TempDir(), "stats.jsonl")
+	stdout := &bytes.Buffer{}
+	record := &statsRecordOptions{
+		statsOptions: statsOptions{
+			Config: &configuration.Config{},
+			Out:    stdout,
+			Appliance: func(c *configuration.Config) (*appliancepkg.Appliance, error) {
+				return &appliancepkg.Appliance{
+					APIClient:  registry.Client,
+					HTTPClient: registry.Client.GetConfig().HTTPClient,
+				}, nil
+			},
+		},
+		interval: time.Millisecond,
+		path:     path,
+		count:    2,
+	}
+	cmd := NewStatsRecordCmd(&factory.Factory{Config: &configuration.Config{}})
+	cmd.Flags().StringToStringP("include", "i", map[string]string{}, "")
+	cmd.Flags().StringToStringP("exclude", "e", map[string]string{}, "")
+	if err := statsRecordRun(cmd, record); err != nil {
+		t.Fatalf("statsRecordRun() unexpected error %s", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(b), "\n"); lines != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", lines)
+	}
+
+	tests := []struct {
+		name string
+		opts statsHistoryOptions
+		want []string
+	}{
+		{
+			name: "table",
+			opts: statsHistoryOptions{appliance: "^gateway", since: "1h", width: 30},
+			want: []string{
+				"2 snapshots from ",
+				"gateway-da0375f6-0b28-4248-bd54-a933c4c39008-site1",
+				"0.7%",
+				"96.00 bps",
+				"5.0",
+			},
+		},
+		{
+			name: "json",
+			opts: statsHistoryOptions{appliance: "controller", since: "1h", json: true},
+			want: []string{`"metric": "cpu"`, `"max": 0.8`, `"metric": "net-out"`, `"last": 260`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			tt.opts.Out = out
+			tt.opts.path = path
+			if err := statsHistoryRun(&tt.opts); err != nil {
+				t.Fatalf("statsHistoryRun() unexpected error %s", err)
+			}
+			got := out.String()
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %q in\n%s", want, got)
+				}
+			}
+			if tt.opts.appliance == "^gateway" && strings.Contains(got, "controller") {
+				t.Errorf("expected only the gateway, got\n%s", got)
+			}
+		})
+	}
+
+	future := statsHistoryOptions{Out: &bytes.Buffer{}, path: path, since: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)}
+	if err := statsHistoryRun(&future); err == nil {
+		t.Error("expected an error when no stats are recorded in the time range")
+	}
+}
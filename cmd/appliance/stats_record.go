@@ -0,0 +1,97 @@
+package appliance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	appliancepkg "github.com/appgate/sdpctl/pkg/appliance"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type statsRecordOptions struct {
+	statsOptions
+	interval time.Duration
+	path     string
+	count    int
+}
+
+// NewStatsRecordCmd return a new appliance stats record command
+func NewStatsRecordCmd(f *factory.Factory) *cobra.Command {
+	opts := statsRecordOptions{
+		statsOptions: statsOptions{
+			Config:    f.Config,
+			Appliance: f.Appliance,
+			Out:       f.IOOutWriter,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "record",
+		Short:   docs.ApplianceStatsRecordDoc.Short,
+		Long:    docs.ApplianceStatsRecordDoc.Long,
+		Example: docs.ApplianceStatsRecordDoc.ExampleString(),
+		Args:    cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			return statsRecordRun(c, &opts)
+		},
+	}
+	cmd.Flags().DurationVar(&opts.interval, "interval", time.Minute, "Time between two snapshots of the appliance stats")
+	cmd.Flags().StringVar(&opts.path, "out", appliancepkg.DefaultStatsHistoryFile(), "File to append the snapshots to")
+	cmd.Flags().IntVar(&opts.count, "count", 0, "Stop after this number of snapshots, 0 records until interrupted")
+	return cmd
+}
+
+func statsRecordRun(cmd *cobra.Command, opts *statsRecordOptions) error {
+	if opts.interval <= 0 {
+		return errors.New("'--interval' must be greater than zero")
+	}
+	filter, orderBy, descending := util.ParseFilteringFlags(cmd.Flags(), appliancepkg.DefaultCommandFilter)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(opts.Out, "Recording appliance stats every %s to %s\n", opts.interval, opts.path)
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	recorded := 0
+	for {
+		// a failed snapshot is logged and skipped, so that a Controller that is unavailable for a while
+		// leaves a gap in the history instead of stopping the recording
+		if err := opts.record(filter, orderBy, descending); err != nil {
+			log.WithError(err).Error("failed to record appliance stats")
+			fmt.Fprintf(opts.Out, "[%s] Failed to record appliance stats: %s\n", time.Now().Format(time.RFC3339), err)
+		} else {
+			recorded++
+		}
+		if opts.count > 0 && recorded >= opts.count {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(opts.Out, "Stopping recording of appliance stats")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (opts *statsRecordOptions) record(filter map[string]map[string]string, orderBy []string, descending bool) error {
+	stats, err := opts.collectStats(filter, orderBy, descending)
+	if err != nil {
+		return err
+	}
+	snapshot := appliancepkg.NewStatsSnapshot(time.Now(), stats)
+	if err := appliancepkg.AppendStatsSnapshot(opts.path, snapshot); err != nil {
+		return err
+	}
+	log.WithField("appliances", len(snapshot.Appliances)).Info("recorded appliance stats")
+	return nil
+}
@@ -354,7 +354,7 @@ func prepareRun(cmd *cobra.Command, opts *prepareUpgradeOptions) error {
 			Appliance: a,
 		})
 	}
-	appliances, filtered, err := appliancepkg.FilterAppliances(online, filter, orderBy, descending)
+	appliances, filtered, err := appliancepkg.FilterAppliancesWithStats(online, initialStats.GetData(), filter, orderBy, descending)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/spf13/cobra"
+)
+
+// NewAuthCmd return a new auth command
+func NewAuthCmd(f *factory.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "auth",
+		Annotations: map[string]string{
+			configuration.SkipAuthCheck: "true",
+		},
+		TraverseChildren: true,
+		Short:            docs.AuthDocs.Short,
+		Long:             docs.AuthDocs.Long,
+	}
+	cmd.AddCommand(NewStatusCmd(f))
+	cmd.AddCommand(NewLogoutCmd(f))
+
+	return cmd
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	authpkg "github.com/appgate/sdpctl/pkg/auth"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/keyring"
+	"github.com/appgate/sdpctl/pkg/profiles"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type logoutOptions struct {
+	factory     *factory.Factory
+	out         io.Writer
+	stdErr      io.Writer
+	allProfiles bool
+}
+
+// NewLogoutCmd return a new auth logout command
+func NewLogoutCmd(f *factory.Factory) *cobra.Command {
+	opts := logoutOptions{
+		factory: f,
+		out:     f.IOOutWriter,
+		stdErr:  f.StdErr,
+	}
+	cmd := &cobra.Command{
+		Use:     "logout",
+		Aliases: []string{"signout"},
+		Args:    cobra.NoArgs,
+		Short:   docs.AuthLogoutDocs.Short,
+		Long:    docs.AuthLogoutDocs.Long,
+		Example: docs.AuthLogoutDocs.ExampleString(),
+		RunE: func(c *cobra.Command, args []string) error {
+			return logoutRun(c, &opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.allProfiles, "all-profiles", false, "Sign out of all profiles")
+
+	return cmd
+}
+
+func logoutRun(cmd *cobra.Command, opts *logoutOptions) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cfg := opts.factory.Config
+	current := currentProfile(cmd)
+	if len(cfg.URL) > 0 {
+		prefix, err := cfg.KeyringPrefix()
+		if err != nil {
+			return err
+		}
+		opts.revokeRefreshToken(ctx, opts.factory, prefix)
+		if err := cfg.ClearCredentials(); err != nil {
+			return err
+		}
+		log.WithField("profile", current).Info("Sign out event")
+		fmt.Fprintf(opts.out, "Signed out of %s\n", cfg.URL)
+	} else if !opts.allProfiles {
+		return errors.New("sdpctl is not configured, run 'sdpctl configure'")
+	}
+	if !opts.allProfiles || !profiles.FileExists() {
+		return nil
+	}
+
+	p, err := profiles.Read()
+	if err != nil {
+		return err
+	}
+	for _, profile := range p.List {
+		if profile.Name == current {
+			continue
+		}
+		if err := opts.logoutProfile(ctx, profile); err != nil {
+			return fmt.Errorf("failed to sign out of profile %s: %w", profile.Name, err)
+		}
+	}
+	return nil
+}
+
+// logoutProfile signs out of a profile that isn't the one in use, which has its own configuration file
+// and keyring prefix
+func (opts *logoutOptions) logoutProfile(ctx context.Context, profile profiles.Profile) error {
+	v := viper.New()
+	v.SetConfigFile(profile.GetConfigurationPath())
+	v.SetConfigType("json")
+	if err := v.ReadInConfig(); err != nil {
+		// the profile has not been configured
+		log.WithError(err).WithField("profile", profile.Name).Info("skipping sign out")
+		return nil
+	}
+	cfg := &configuration.Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return err
+	}
+	if len(cfg.URL) == 0 {
+		return nil
+	}
+	prefix, err := cfg.ProfileKeyringPrefix(profile.Name)
+	if err != nil {
+		return err
+	}
+	host, err := cfg.GetHost()
+	if err != nil {
+		return err
+	}
+	opts.revokeRefreshToken(ctx, opts.factory.WithConfig(cfg), prefix)
+	if err := keyring.ClearCredentials(prefix); err != nil {
+		return err
+	}
+	if err := keyring.DeleteBearer(host); err != nil {
+		return err
+	}
+	for _, k := range []string{"expires_at", "provider"} {
+		if v.IsSet(k) {
+			v.Set(k, "")
+		}
+	}
+	if err := v.WriteConfig(); err != nil {
+		return err
+	}
+	log.WithField("profile", profile.Name).Info("Sign out event")
+	fmt.Fprintf(opts.out, "Signed out of %s (profile %s)\n", cfg.URL, profile.Name)
+	return nil
+}
+
+// revokeRefreshToken revokes the sign in with the identity provider, if it's supported. A failure is not fatal,
+// the credentials are removed locally regardless.
+func (opts *logoutOptions) revokeRefreshToken(ctx context.Context, f *factory.Factory, prefix string) {
+	err := authpkg.RevokeRefreshToken(ctx, f, prefix)
+	if err == nil || errors.Is(err, authpkg.ErrRevocationNotSupported) {
+		return
+	}
+	log.WithError(err).Warn("failed to revoke the refresh token")
+	fmt.Fprintf(opts.stdErr, "[warning] failed to revoke the sign in with the identity provider: %s\n", err)
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/keyring"
+	"github.com/appgate/sdpctl/pkg/profiles"
+	zkeyring "github.com/zalando/go-keyring"
+)
+
+func TestAuthLogout(t *testing.T) {
+	zkeyring.MockInit()
+	t.Setenv("SDPCTL_CONFIG_DIR", t.TempDir())
+	t.Cleanup(func() {
+		profiles.ReadProfiles = nil
+	})
+	host := "controller.devops"
+	if err := keyring.SetBearer(host, "abc123456789"); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.SetUsername(host, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	stdout := &bytes.Buffer{}
+	f := &factory.Factory{
+		Config: &configuration.Config{
+			URL:       "https://" + host + ":8443/admin",
+			Provider:  openapi.PtrString("local"),
+			ExpiresAt: openapi.PtrString("2031-12-08 08:15:39.137584 +0000 UTC"),
+		},
+		IOOutWriter: stdout,
+	}
+	cmd := NewLogoutCmd(f)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if _, err := cmd.ExecuteC(); err != nil {
+		t.Fatalf("executeC %s", err)
+	}
+	if _, err := keyring.GetBearer(host); err == nil {
+		t.Error("expected the bearer token to be removed")
+	}
+	if _, err := keyring.GetUsername(host); err == nil {
+		t.Error("expected the username to be removed")
+	}
+	if f.Config.ExpiresAt != nil || f.Config.Provider != nil {
+		t.Errorf("expected expires_at and provider to be cleared, got %+v", f.Config)
+	}
+	if got, want := stdout.String(), "Signed out of https://controller.devops:8443/admin\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAuthLogoutAllProfiles(t *testing.T) {
+	zkeyring.MockInit()
+	dir := t.TempDir()
+	t.Setenv("SDPCTL_CONFIG_DIR", dir)
+	t.Setenv("SDPCTL_DATA_DIR", t.TempDir())
+	t.Cleanup(func() {
+		profiles.ReadProfiles = nil
+	})
+	profileList := []profiles.Profile{}
+	for name, host := range map[string]string{"production": "production.devops", "staging": "staging.devops"} {
+		d := filepath.Join(dir, "profiles", name)
+		if err := os.MkdirAll(d, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		config := map[string]interface{}{
+			"url":         "https://" + host + ":8443/admin",
+			"provider":    "local",
+			"expires_at":  "2031-12-08 08:15:39.137584 +0000 UTC",
+			"api_version": 20,
+		}
+		b, err := json.Marshal(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(d, "config.json"), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := keyring.SetBearer(name+host, "abc123456789"); err != nil {
+			t.Fatal(err)
+		}
+		profileList = append(profileList, profiles.Profile{Name: name, Directory: d})
+	}
+	current := "production"
+	if err := profiles.Write(&profiles.Profiles{Current: &current, List: profileList}); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	f := &factory.Factory{
+		Config: &configuration.Config{
+			URL:      "https://production.devops:8443/admin",
+			Provider: openapi.PtrString("local"),
+		},
+		IOOutWriter: stdout,
+	}
+	cmd := NewLogoutCmd(f)
+	cmd.SetArgs([]string{"--all-profiles"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if _, err := cmd.ExecuteC(); err != nil {
+		t.Fatalf("executeC %s", err)
+	}
+	for _, prefix := range []string{"productionproduction.devops", "stagingstaging.devops"} {
+		if _, err := keyring.GetBearer(prefix); err == nil {
+			t.Errorf("expected the bearer token of %s to be removed", prefix)
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "profiles", "staging", "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var staging map[string]interface{}
+	if err := json.Unmarshal(b, &staging); err != nil {
+		t.Fatal(err)
+	}
+	if staging["expires_at"] != "" || staging["provider"] != "" {
+		t.Errorf("expected expires_at and provider to be cleared in the staging profile, got %v", staging)
+	}
+	if !strings.Contains(stdout.String(), "Signed out of https://staging.devops:8443/admin (profile staging)") {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+}
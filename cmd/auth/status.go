@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	authpkg "github.com/appgate/sdpctl/pkg/auth"
+	"github.com/appgate/sdpctl/pkg/cmdutil"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/profiles"
+	"github.com/appgate/sdpctl/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type statusOptions struct {
+	config  *configuration.Config
+	factory *factory.Factory
+	out     io.Writer
+	output  *cmdutil.OutputOptions
+}
+
+type authStatus struct {
+	Profile      string     `json:"profile"`
+	URL          string     `json:"url"`
+	Provider     string     `json:"provider"`
+	Username     string     `json:"username"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	Expired      bool       `json:"expired"`
+	APIVersion   int        `json:"api_version"`
+	TokenStorage string     `json:"token_storage"`
+}
+
+// NewStatusCmd return a new auth status command
+func NewStatusCmd(f *factory.Factory) *cobra.Command {
+	opts := statusOptions{
+		config:  f.Config,
+		factory: f,
+		out:     f.IOOutWriter,
+	}
+	cmd := &cobra.Command{
+		Use:     "status",
+		Args:    cobra.NoArgs,
+		Short:   docs.AuthStatusDocs.Short,
+		Long:    docs.AuthStatusDocs.Long,
+		Example: docs.AuthStatusDocs.ExampleString(),
+		RunE: func(c *cobra.Command, args []string) error {
+			return statusRun(c, &opts)
+		},
+	}
+	opts.output = cmdutil.AddOutputFlags(cmd, cmdutil.OutputTable)
+
+	return cmd
+}
+
+func statusRun(cmd *cobra.Command, opts *statusOptions) error {
+	if err := opts.output.Validate(); err != nil {
+		return err
+	}
+	cfg := opts.config
+	status := authStatus{
+		Profile:      currentProfile(cmd),
+		URL:          cfg.URL,
+		APIVersion:   cfg.Version,
+		TokenStorage: cfg.TokenStorage(),
+	}
+	if len(cfg.URL) > 0 {
+		if u, err := configuration.NormalizeConfigurationURL(cfg.URL); err == nil {
+			status.URL = u
+		}
+	}
+	if cfg.Provider != nil {
+		status.Provider = *cfg.Provider
+	}
+	if t, err := cfg.ExpiresAtTime(); err == nil {
+		status.ExpiresAt = &t
+		status.Expired = !cfg.ExpiredAtValid()
+	}
+	status.Username = username(opts.factory, status)
+
+	if opts.output.IsDefaultTable() {
+		expires := "-"
+		if status.ExpiresAt != nil {
+			expires = status.ExpiresAt.Format(time.RFC3339)
+			if status.Expired {
+				expires += " (expired)"
+			}
+		}
+		p := util.NewPrinter(opts.out, 4)
+		p.AddLine("Profile:", valueOrDash(status.Profile))
+		p.AddLine("URL:", valueOrDash(status.URL))
+		p.AddLine("Provider:", valueOrDash(status.Provider))
+		p.AddLine("Username:", valueOrDash(status.Username))
+		p.AddLine("Token expires:", expires)
+		p.AddLine("API version:", strconv.Itoa(status.APIVersion))
+		p.AddLine("Token storage:", status.TokenStorage)
+		p.Print()
+		return nil
+	}
+	t := cmdutil.NewTable("Profile", "URL", "Provider", "Username", "Expires At", "Expired", "API Version", "Token Storage")
+	t.AddRow(status.Profile, status.URL, status.Provider, status.Username, status.ExpiresAt, status.Expired, status.APIVersion, status.TokenStorage)
+	return opts.output.Print(opts.out, status, t)
+}
+
+// currentProfile returns the profile selected with '--profile', SDPCTL_PROFILE or 'sdpctl profile set'
+func currentProfile(cmd *cobra.Command) string {
+	if v, err := cmd.Flags().GetString("profile"); err == nil && len(v) > 0 {
+		return v
+	}
+	p, err := profiles.Read()
+	if err != nil {
+		return ""
+	}
+	current, err := p.CurrentProfile()
+	if err != nil {
+		return ""
+	}
+	return current.Name
+}
+
+// username returns the name of the signed in user from the Controller if the bearer token is valid,
+// otherwise the username stored in the keyring, if any
+func username(f *factory.Factory, status authStatus) string {
+	cfg := f.Config
+	if status.TokenStorage != configuration.TokenStorageNone && status.ExpiresAt != nil && !status.Expired {
+		name, err := authorizedUsername(f)
+		if err == nil {
+			return name
+		}
+		log.WithError(err).Info("could not get the signed in user from the Controller")
+	}
+	if len(cfg.URL) == 0 {
+		return ""
+	}
+	credentials, err := cfg.LoadCredentials()
+	if err != nil {
+		return ""
+	}
+	return credentials.Username
+}
+
+func authorizedUsername(f *factory.Factory) (string, error) {
+	cfg := f.Config
+	// the status must not sign in again if the token has been revoked, so the clients don't use the TokenSource
+	if f.TokenSource != nil {
+		f = f.WithConfig(cfg)
+	}
+	client, err := f.APIClient(cfg)
+	if err != nil {
+		return "", err
+	}
+	token, err := cfg.GetBearTokenHeaderValue()
+	if err != nil {
+		return "", err
+	}
+	response, err := authpkg.NewAuth(client).Authorization(util.BaseAuthContext(token))
+	if err != nil {
+		return "", err
+	}
+	user := response.GetUser()
+	if len(user.GetName()) == 0 {
+		return "", fmt.Errorf("no user name in the authorization response")
+	}
+	return user.GetName(), nil
+}
+
+func valueOrDash(v string) string {
+	if len(v) == 0 {
+		return "-"
+	}
+	return v
+}
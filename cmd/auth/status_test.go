@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/httpmock"
+	"github.com/appgate/sdpctl/pkg/profiles"
+	zkeyring "github.com/zalando/go-keyring"
+)
+
+const authorizationResponse = `
+{
+    "expires": "2023-06-14T14:48:35.333918184Z",
+    "token": "myToken",
+    "user": {
+        "name": "admin",
+        "needTwoFactorAuth": false,
+        "privileges": []
+    }
+}`
+
+func setupStatusTest(t *testing.T, expiresAt time.Time) (*factory.Factory, *bytes.Buffer) {
+	t.Helper()
+	zkeyring.MockInit()
+	t.Setenv("SDPCTL_CONFIG_DIR", t.TempDir())
+	t.Cleanup(func() {
+		profiles.ReadProfiles = nil
+	})
+	registry := httpmock.NewRegistry(t)
+	registry.Register(
+		"/admin/authorization",
+		func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusOK)
+			fmt.Fprint(rw, authorizationResponse)
+		},
+	)
+	registry.Serve()
+	t.Cleanup(registry.Teardown)
+
+	stdout := &bytes.Buffer{}
+	f := &factory.Factory{
+		Config: &configuration.Config{
+			URL:         fmt.Sprintf("http://localhost:%d/admin", registry.Port),
+			Provider:    openapi.PtrString("local"),
+			Version:     20,
+			BearerToken: openapi.PtrString("abc123456789"),
+			ExpiresAt:   openapi.PtrString(expiresAt.Round(0).String()),
+		},
+		IOOutWriter: stdout,
+	}
+	f.APIClient = func(c *configuration.Config) (*openapi.APIClient, error) {
+		return registry.Client, nil
+	}
+	return f, stdout
+}
+
+func TestAuthStatus(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	f, stdout := setupStatusTest(t, expiresAt)
+	cmd := NewStatusCmd(f)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if _, err := cmd.ExecuteC(); err != nil {
+		t.Fatalf("executeC %s", err)
+	}
+	got := stdout.String()
+	for _, want := range []string{
+		"Provider:         local",
+		"Username:         admin",
+		"Token expires:    " + expiresAt.Format(time.RFC3339) + "\n",
+		"API version:      20",
+		"Token storage:    config",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output, got\n%s", want, got)
+		}
+	}
+}
+
+func TestAuthStatusJSON(t *testing.T) {
+	f, stdout := setupStatusTest(t, time.Now().Add(-time.Hour))
+	cmd := NewStatusCmd(f)
+	cmd.SetArgs([]string{"--json"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if _, err := cmd.ExecuteC(); err != nil {
+		t.Fatalf("executeC %s", err)
+	}
+	var got authStatus
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON output %s\n%s", err, stdout.String())
+	}
+	if !got.Expired || got.ExpiresAt == nil {
+		t.Errorf("expected an expired token, got %+v", got)
+	}
+	// the Controller is not asked for the user of an expired token
+	if got.Username != "" {
+		t.Errorf("expected no username for an expired token, got %q", got.Username)
+	}
+	if got.Provider != "local" || got.APIVersion != 20 || got.TokenStorage != configuration.TokenStorageConfig {
+		t.Errorf("unexpected status %+v", got)
+	}
+}
@@ -1,12 +1,14 @@
 package configure
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/docs"
@@ -21,21 +23,29 @@ import (
 )
 
 type configureOptions struct {
-	Config    *configuration.Config
-	CertPath  string
-	Out       io.Writer
-	StdErr    io.Writer
-	CanPrompt bool
-	URL       string
+	Config            *configuration.Config
+	CertPath          string
+	ClientCertPath    string
+	ClientKeyPath     string
+	CredentialProcess string
+	TOTPGenerate      bool
+	Pin               bool
+	PinMode           string
+	PeerCertificates  func(ctx context.Context) (*factory.PresentedCertificate, error)
+	Out               io.Writer
+	StdErr            io.Writer
+	CanPrompt         bool
+	URL               string
 }
 
 // NewCmdConfigure return a new Configure command
 func NewCmdConfigure(f *factory.Factory) *cobra.Command {
 	opts := configureOptions{
-		Config:    f.Config,
-		Out:       f.IOOutWriter,
-		StdErr:    f.StdErr,
-		CanPrompt: f.CanPrompt(),
+		Config:           f.Config,
+		Out:              f.IOOutWriter,
+		StdErr:           f.StdErr,
+		CanPrompt:        f.CanPrompt(),
+		PeerCertificates: f.PeerCertificates,
 	}
 	cmd := &cobra.Command{
 		Use: "configure",
@@ -76,8 +86,15 @@ func NewCmdConfigure(f *factory.Factory) *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&opts.CertPath, "pem", "", "Path to PEM file to use for request certificate validation")
+	cmd.Flags().StringVar(&opts.ClientCertPath, "client-cert", "", "Path to a PEM or PKCS#12 client certificate, for a Controller behind a proxy that requires mutual TLS")
+	cmd.Flags().StringVar(&opts.ClientKeyPath, "client-key", "", "Path to the PEM private key of the client certificate, if it's not in the same file")
+	cmd.Flags().StringVar(&opts.CredentialProcess, "credential-process", "", "Command that prints the sign in credentials as JSON, instead of storing them in the keyring")
+	cmd.Flags().BoolVar(&opts.TOTPGenerate, "totp", false, "Store the one-time password secret in the keyring and generate the one-time passwords, for service accounts")
+	cmd.Flags().BoolVar(&opts.Pin, "pin", false, "Pin the public key of the Controller certificate, to detect when it changes")
+	cmd.Flags().StringVar(&opts.PinMode, "pin-mode", configuration.PinModeFail, "What to do when the Controller certificate doesn't match the pin, 'fail' or 'warn'")
 
 	cmd.AddCommand(NewSigninCmd(f))
+	cmd.AddCommand(NewTrustUpdateCmd(f))
 
 	return cmd
 }
@@ -95,14 +112,44 @@ func configRun(opts *configureOptions) error {
 		fmt.Fprintf(opts.Out, "Added certificate as trusted source for sdpctl from %s\n", opts.CertPath)
 		fmt.Fprintln(opts.Out, configuration.CertificateDetails(cert))
 
-		viper.Set("pem_base64", base64.StdEncoding.EncodeToString(cert.Raw))
+		pemBase64 := base64.StdEncoding.EncodeToString(cert.Raw)
+		viper.Set("pem_base64", pemBase64)
+		opts.Config.PemBase64 = &pemBase64
+	}
+	if len(opts.ClientCertPath) > 0 {
+		if err := configureClientCertificate(opts); err != nil {
+			return err
+		}
+	} else if len(opts.ClientKeyPath) > 0 {
+		return errors.New("--client-key requires --client-cert")
 	}
 	u, err := configuration.NormalizeConfigurationURL(opts.URL)
 	if err != nil {
 		return fmt.Errorf("Could not determine URL for %s %s", opts.URL, err)
 	}
+	if previous := viper.GetString("url"); len(previous) > 0 && previous != u {
+		// the pin of another Controller would never match
+		for _, k := range []string{"pinned_spki", "pinned_certificate"} {
+			viper.Set(k, "")
+		}
+		opts.Config.PinnedSPKI = ""
+		opts.Config.PinnedCertBase64 = nil
+	}
 	viper.Set("url", u)
 	opts.Config.URL = u
+	if opts.Pin {
+		if err := configurePin(opts); err != nil {
+			return err
+		}
+	}
+	if len(opts.CredentialProcess) > 0 {
+		viper.Set("credential_process", opts.CredentialProcess)
+		opts.Config.CredentialProcess = opts.CredentialProcess
+	}
+	if opts.TOTPGenerate {
+		viper.Set("totp_generate", true)
+		opts.Config.TOTPGenerate = true
+	}
 	viper.Set("device_id", configuration.DefaultDeviceID())
 
 	h, err := opts.Config.GetHost()
@@ -148,3 +195,57 @@ func argValidation(cmd *cobra.Command, args []string) error {
 	}
 	return nil
 }
+
+// configureClientCertificate checks that the client certificate matches its key and is valid before it's saved,
+// since a proxy only rejects the TLS handshake without saying why
+func configureClientCertificate(opts *configureOptions) error {
+	certPath := filesystem.AbsolutePath(opts.ClientCertPath)
+	keyPath := ""
+	if len(opts.ClientKeyPath) > 0 {
+		keyPath = filesystem.AbsolutePath(opts.ClientKeyPath)
+	}
+	cert, err := configuration.LoadClientCertificate(certPath, keyPath, configuration.ClientKeyPassphrase(opts.CanPrompt))
+	if err != nil {
+		return err
+	}
+	if err := configuration.CheckClientCertificate(cert, time.Now()); err != nil {
+		return err
+	}
+	fmt.Fprintf(opts.Out, "Added client certificate for sdpctl from %s\n", certPath)
+	fmt.Fprintln(opts.Out, configuration.CertificateDetails(cert.Leaf))
+
+	viper.Set("client_certificate", certPath)
+	viper.Set("client_key", keyPath)
+	opts.Config.ClientCertificate = certPath
+	opts.Config.ClientKey = keyPath
+	return nil
+}
+
+// configurePin pins the public key of the certificate that the Controller presents, which must be trusted
+func configurePin(opts *configureOptions) error {
+	if opts.PinMode != configuration.PinModeFail && opts.PinMode != configuration.PinModeWarn {
+		return fmt.Errorf("invalid --pin-mode %q, expected %s or %s", opts.PinMode, configuration.PinModeFail, configuration.PinModeWarn)
+	}
+	// the pin is checked on the connection, so it must not be compared to an old pin
+	opts.Config.PinnedSPKI = ""
+	presented, err := opts.PeerCertificates(context.Background())
+	if err != nil {
+		return fmt.Errorf("could not get the certificate of the Controller to pin: %w", err)
+	}
+	if presented.VerifyError != nil && !opts.Config.Insecure {
+		return fmt.Errorf("the certificate of the Controller is not trusted, add it with --pem first: %w", presented.VerifyError)
+	}
+	leaf := presented.Leaf()
+	pin := configuration.SPKIPin(leaf)
+	cert := base64.StdEncoding.EncodeToString(leaf.Raw)
+	fmt.Fprintf(opts.Out, "Pinned the public key of the Controller certificate (sha256/%s)\n", pin)
+	fmt.Fprintln(opts.Out, configuration.CertificateDetails(leaf))
+
+	viper.Set("pinned_spki", pin)
+	viper.Set("pinned_certificate", cert)
+	viper.Set("pin_mode", opts.PinMode)
+	opts.Config.PinnedSPKI = pin
+	opts.Config.PinnedCertBase64 = &cert
+	opts.Config.PinMode = opts.PinMode
+	return nil
+}
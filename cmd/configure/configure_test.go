@@ -343,3 +343,46 @@ func TestConfigUrlCmd(t *testing.T) {
 		t.Fatalf("wrong addr stored in config, expected %q got %q", want, v)
 	}
 }
+
+func TestConfigureClientCertificate(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	certPath := filepath.Join("..", "..", "pkg", "configuration", "testdata", "client.pem")
+	tests := []struct {
+		name    string
+		keyPath string
+		wantErr bool
+	}{
+		{
+			name:    "matching key",
+			keyPath: filepath.Join("..", "..", "pkg", "configuration", "testdata", "client.key"),
+		},
+		{
+			name:    "key doesn't match",
+			keyPath: filepath.Join("..", "..", "pkg", "configuration", "testdata", "other.key"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &configureOptions{
+				Config:         &configuration.Config{},
+				ClientCertPath: certPath,
+				ClientKeyPath:  tt.keyPath,
+				Out:            io.Discard,
+			}
+			err := configureClientCertificate(opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("configureClientCertificate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !filepath.IsAbs(opts.Config.ClientCertificate) || !filepath.IsAbs(opts.Config.ClientKey) {
+				t.Errorf("expected absolute paths, got %q %q", opts.Config.ClientCertificate, opts.Config.ClientKey)
+			}
+			if viper.GetString("client_certificate") != opts.Config.ClientCertificate {
+				t.Errorf("expected client_certificate in the configuration, got %q", viper.GetString("client_certificate"))
+			}
+		})
+	}
+}
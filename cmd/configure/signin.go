@@ -41,6 +41,7 @@ func NewSigninCmd(f *factory.Factory) *cobra.Command {
 			return signinRun(c, &opts)
 		},
 	}
+	signinCmd.Flags().BoolVar(&f.Config.OIDCDeviceCode, "device-code", f.Config.OIDCDeviceCode, "Sign in to an OpenID Connect provider with a device code, for when no browser can be opened on this machine")
 
 	return signinCmd
 }
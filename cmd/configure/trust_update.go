@@ -0,0 +1,168 @@
+package configure
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/docs"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/filesystem"
+	"github.com/appgate/sdpctl/pkg/prompt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type trustUpdateOptions struct {
+	Config           *configuration.Config
+	Out              io.Writer
+	CanPrompt        bool
+	CertPath         string
+	ExpectedPin      string
+	PeerCertificates func(ctx context.Context) (*factory.PresentedCertificate, error)
+}
+
+// NewTrustUpdateCmd return a new trust-update command
+func NewTrustUpdateCmd(f *factory.Factory) *cobra.Command {
+	opts := trustUpdateOptions{
+		Config:           f.Config,
+		Out:              f.IOOutWriter,
+		CanPrompt:        f.CanPrompt(),
+		PeerCertificates: f.PeerCertificates,
+	}
+	cmd := &cobra.Command{
+		Use: "trust-update",
+		Annotations: map[string]string{
+			configuration.SkipAuthCheck: "true",
+		},
+		Args:    cobra.NoArgs,
+		Short:   docs.ConfigureTrustUpdateDocs.Short,
+		Long:    docs.ConfigureTrustUpdateDocs.Long,
+		Example: docs.ConfigureTrustUpdateDocs.ExampleString(),
+		RunE: func(c *cobra.Command, args []string) error {
+			return trustUpdateRun(c, &opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.CertPath, "pem", "", "Path to the PEM file of the CA certificate that signed the new Controller certificate, if it's not trusted")
+	cmd.Flags().StringVar(&opts.ExpectedPin, "expected-pin", "", "Accept the new certificate without a prompt if its public key matches this pin, formatted as sha256/<base64 hash>")
+	return cmd
+}
+
+const pinPrefix = "sha256/"
+
+// verifyChain verifies the certificate chain presented by host with the system certificates and ca
+func verifyChain(chain []*x509.Certificate, ca *x509.Certificate, host string) error {
+	roots, _ := x509.SystemCertPool()
+	if roots == nil {
+		roots = x509.NewCertPool()
+	}
+	roots.AddCert(ca)
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       host,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+func trustUpdateRun(cmd *cobra.Command, opts *trustUpdateOptions) error {
+	cfg := opts.Config
+	if len(cfg.URL) == 0 {
+		return errors.New("sdpctl is not configured, run 'sdpctl configure'")
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	host, err := cfg.GetHost()
+	if err != nil {
+		return err
+	}
+	presented, err := opts.PeerCertificates(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get the certificate of %s: %w", host, err)
+	}
+	leaf := presented.Leaf()
+	pin := configuration.SPKIPin(leaf)
+	verifyErr := presented.VerifyError
+	var ca *x509.Certificate
+	if len(opts.CertPath) > 0 {
+		ca, err = configuration.ReadPemFile(filesystem.AbsolutePath(opts.CertPath))
+		if err != nil {
+			return err
+		}
+		// the new CA certificate replaces the one added with --pem before
+		verifyErr = verifyChain(presented.Chain, ca, host)
+	}
+	pinChanged := len(cfg.PinnedSPKI) > 0 && pin != cfg.PinnedSPKI
+	untrusted := verifyErr != nil && !cfg.Insecure
+	caChanged := ca != nil && presented.VerifyError != nil
+	if !pinChanged && !untrusted && !caChanged {
+		fmt.Fprintf(opts.Out, "The certificate presented by %s is trusted and has not changed\n", host)
+		return nil
+	}
+
+	pinned, err := cfg.PinnedCertificate()
+	if err != nil {
+		log.WithError(err).Warn("could not read the pinned certificate")
+	}
+	fmt.Fprintf(opts.Out, "The certificate presented by %s has changed:\n", host)
+	fmt.Fprintln(opts.Out, configuration.CertificateDiff(pinned, leaf))
+	fmt.Fprintf(opts.Out, "Public key: %s%s\n\n", pinPrefix, pin)
+	if untrusted {
+		// a certificate presented by the Controller can't be trusted on its own, it could be presented by anyone
+		// between sdpctl and the Controller
+		if ca != nil {
+			return fmt.Errorf("the new certificate is not signed by %s: %w", opts.CertPath, verifyErr)
+		}
+		return fmt.Errorf("the new certificate is not trusted, run with --pem and the CA certificate that signed it: %w", verifyErr)
+	}
+
+	if len(opts.ExpectedPin) > 0 {
+		if !strings.HasPrefix(opts.ExpectedPin, pinPrefix) {
+			return fmt.Errorf("invalid --expected-pin %q, expected %s<base64 hash>", opts.ExpectedPin, pinPrefix)
+		}
+		if strings.TrimPrefix(opts.ExpectedPin, pinPrefix) != pin {
+			return fmt.Errorf("%w: the public key is %s%s, expected %s", configuration.ErrCertificatePinMismatch, pinPrefix, pin, opts.ExpectedPin)
+		}
+	} else {
+		if cfg.NoInteractive || !opts.CanPrompt {
+			return fmt.Errorf("the new certificate must be reviewed before it's trusted, run with --expected-pin %s<base64 hash> with the pin of the new certificate from a trusted source to accept it without a prompt", pinPrefix)
+		}
+		if err := prompt.AskConfirmation("The new certificate will be trusted for this profile."); err != nil {
+			return err
+		}
+	}
+
+	if ca != nil {
+		pemBase64 := base64.StdEncoding.EncodeToString(ca.Raw)
+		viper.Set("pem_base64", pemBase64)
+		cfg.PemBase64 = &pemBase64
+	}
+	if len(cfg.PinnedSPKI) > 0 {
+		cert := base64.StdEncoding.EncodeToString(leaf.Raw)
+		viper.Set("pinned_spki", pin)
+		viper.Set("pinned_certificate", cert)
+		cfg.PinnedSPKI = pin
+		cfg.PinnedCertBase64 = &cert
+	}
+	if err := viper.WriteConfig(); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"host": host,
+		"pin":  cfg.PinnedSPKI,
+	}).Info("Trusted new Controller certificate")
+	fmt.Fprintf(opts.Out, "Updated the trusted certificate for %s\n", host)
+	return nil
+}
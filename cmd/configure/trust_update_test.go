@@ -0,0 +1,166 @@
+package configure
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// controllerCertificate returns a self-signed certificate for controller.devops and the path to it as a PEM file
+func controllerCertificate(t *testing.T) (*x509.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "controller.devops"},
+		DNSNames:              []string{"controller.devops"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "controller.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return cert, path
+}
+
+func TestTrustUpdate(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	cert, certPath := controllerCertificate(t)
+	pin := configuration.SPKIPin(cert)
+	otherPin := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	unknownAuthority := errors.New("x509: certificate signed by unknown authority")
+	tests := []struct {
+		name        string
+		pin         string
+		expectedPin string
+		certPath    string
+		verifyError error
+		want        string
+		wantErr     string
+		wantPin     string
+		wantPem     bool
+	}{
+		{
+			name: "unchanged",
+			pin:  pin,
+			want: "is trusted and has not changed",
+		},
+		{
+			name:    "rotated pinned certificate without review",
+			pin:     otherPin,
+			wantErr: "run with --expected-pin",
+		},
+		{
+			name:        "rotated pinned certificate with expected pin",
+			pin:         otherPin,
+			expectedPin: "sha256/" + pin,
+			want:        "Updated the trusted certificate for controller.devops",
+			wantPin:     pin,
+		},
+		{
+			name:        "rotated pinned certificate with another expected pin",
+			pin:         otherPin,
+			expectedPin: "sha256/" + otherPin,
+			wantErr:     configuration.ErrCertificatePinMismatch.Error(),
+		},
+		{
+			name:        "untrusted certificate",
+			expectedPin: "sha256/" + pin,
+			verifyError: unknownAuthority,
+			wantErr:     "run with --pem",
+		},
+		{
+			name:        "untrusted certificate with new CA",
+			expectedPin: "sha256/" + pin,
+			certPath:    certPath,
+			verifyError: unknownAuthority,
+			want:        "Updated the trusted certificate for controller.devops",
+			wantPem:     true,
+		},
+		{
+			name:        "untrusted certificate with another CA",
+			expectedPin: "sha256/" + pin,
+			certPath:    filepath.Join("..", "..", "pkg", "configuration", "testdata", "client.pem"),
+			verifyError: unknownAuthority,
+			wantErr:     "the new certificate is not signed by",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			viper.SetConfigFile(filepath.Join(t.TempDir(), "config.json"))
+			stdout := &bytes.Buffer{}
+			opts := &trustUpdateOptions{
+				Config: &configuration.Config{
+					URL:           "https://controller.devops:8443/admin",
+					PinnedSPKI:    tt.pin,
+					NoInteractive: true,
+				},
+				Out:         stdout,
+				CertPath:    tt.certPath,
+				ExpectedPin: tt.expectedPin,
+				PeerCertificates: func(ctx context.Context) (*factory.PresentedCertificate, error) {
+					return &factory.PresentedCertificate{
+						Chain:       []*x509.Certificate{cert},
+						VerifyError: tt.verifyError,
+					}, nil
+				},
+			}
+			err := trustUpdateRun(&cobra.Command{}, opts)
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("trustUpdateRun() error = %v, want %s", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(stdout.String(), tt.want) {
+				t.Errorf("expected %q in output\n%s", tt.want, stdout)
+			}
+			if got := viper.GetString("pinned_spki"); got != tt.wantPin {
+				t.Errorf("got pinned_spki %q, want %q", got, tt.wantPin)
+			}
+			wantPem := ""
+			if tt.wantPem {
+				wantPem = base64.StdEncoding.EncodeToString(cert.Raw)
+			}
+			if got := viper.GetString("pem_base64"); got != wantPem {
+				t.Errorf("got pem_base64 %q, want %q", got, wantPem)
+			}
+		})
+	}
+}
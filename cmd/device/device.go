@@ -3,6 +3,7 @@ package device
 import (
 	"io"
 
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/device"
 	"github.com/appgate/sdpctl/pkg/factory"
@@ -15,6 +16,7 @@ type DeviceOptions struct {
 	Device     func(c *configuration.Config) (*device.Device, error)
 	Debug      bool
 	useJSON    bool
+	output     *cmdutil.OutputOptions
 	orderBy    []string
 	descending bool
 }
@@ -1,6 +1,7 @@
 package device
 
 import (
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/util"
 	"github.com/spf13/cobra"
@@ -23,7 +24,7 @@ func NewDeviceListCmd(opts *DeviceOptions) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			return nil
+			return opts.output.Validate()
 		},
 		RunE: func(c *cobra.Command, args []string) error {
 			return deviceListRun(opts)
@@ -32,6 +33,7 @@ func NewDeviceListCmd(opts *DeviceOptions) *cobra.Command {
 
 	listCmd.Flags().StringSlice("order-by", []string{"distinguished-name"}, "Order devices list by keyword. Available keywords are 'distinguished-name', 'hostname', 'username', 'provider-name', 'device-id' and 'username'")
 	listCmd.Flags().Bool("descending", false, "Reverses the order of the device list")
+	opts.output = cmdutil.AddOutputFlagsInheritJSON(listCmd, cmdutil.OutputTable)
 
 	return listCmd
 }
@@ -48,12 +50,7 @@ func deviceListRun(opts *DeviceOptions) error {
 		return err
 	}
 
-	if opts.useJSON {
-		return util.PrintJSON(opts.Out, distinguishedNames)
-	}
-
-	p := util.NewPrinter(opts.Out, 4)
-	p.AddHeader(
+	table := cmdutil.NewTable(
 		"Distinguished Name",
 		"Device ID",
 		"Username",
@@ -64,7 +61,7 @@ func deviceListRun(opts *DeviceOptions) error {
 		"Last Seen At",
 	)
 	for _, t := range distinguishedNames {
-		p.AddLine(
+		table.AddRow(
 			t.GetDistinguishedName(),
 			t.GetDeviceId(),
 			t.GetUsername(),
@@ -75,6 +72,5 @@ func deviceListRun(opts *DeviceOptions) error {
 			t.GetLastSeenAt(),
 		)
 	}
-	p.Print()
-	return nil
+	return opts.output.Print(opts.Out, distinguishedNames, table)
 }
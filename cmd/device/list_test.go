@@ -12,6 +12,7 @@ import (
 	"github.com/appgate/sdpctl/pkg/factory"
 	"github.com/appgate/sdpctl/pkg/httpmock"
 	"github.com/appgate/sdpctl/pkg/util"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -98,9 +99,11 @@ func TestDeviceListJSON(t *testing.T) {
 	registry, opts, out := setupDeviceListTest(t)
 	defer registry.Teardown()
 
-	opts.useJSON = true
-
-	cmd := NewDeviceListCmd(opts)
+	// '--json' is a persistent flag of the device command
+	cmd := &cobra.Command{Use: "device"}
+	cmd.PersistentFlags().BoolVar(&opts.useJSON, "json", false, "Display in JSON format")
+	cmd.AddCommand(NewDeviceListCmd(opts))
+	cmd.SetArgs([]string{"list", "--json"})
 	cmd.SetOut(io.Discard)
 	cmd.SetErr(io.Discard)
 
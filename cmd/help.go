@@ -87,9 +87,19 @@ Available Variables:
   SDPCTL_BEARER:
     Description: The Bearer authentication, computed from 'sdpctl configure signin'
   SDPCTL_USERNAME:
-    Description: username for local identity provider, can be used instead of SDPCTL_BEARER in combination with SDPCTL_PASSWORD.
+    Description: username for local or RADIUS identity provider, can be used instead of SDPCTL_BEARER in combination with SDPCTL_PASSWORD.
   SDPCTL_PASSWORD:
-    Description: password for local identity provider, can be used instead of SDPCTL_BEARER in combination with SDPCTL_USERNAME.
+    Description: password for local or RADIUS identity provider, can be used instead of SDPCTL_BEARER in combination with SDPCTL_USERNAME.
+  SDPCTL_RADIUS_RESPONSE:
+    Description: response to the RADIUS challenge when signing in without a TTY. If the RADIUS server sends more than one challenge,
+                 the responses are on separate lines, in the order of the challenges, for example $'1234\n123456' in bash.
+  SDPCTL_CREDENTIAL_PROCESS:
+    Description: Command that prints the username, password and optionally a one-time password for local or RADIUS identity providers
+                 as JSON, for example {"username": "admin", "password": "secret", "otp": "123456"}. It is used instead of the keyring,
+                 SDPCTL_USERNAME, SDPCTL_PASSWORD and prompts.
+  SDPCTL_OIDC_DEVICE_CODE:
+    Description: Sign in to OpenID Connect providers with a device code instead of a browser on this machine.
+    Options: true, false
   SDPCTL_DEVICE_ID:
     Description: UUID to distinguish the Client device making the request. It is supposed to be same for every sign in request from the same server.
     Default: /etc/machine-id on Linux
@@ -112,8 +122,37 @@ Available Variables:
   SDPCTL_PROFILE:
     Description: Profile name to use
     Options:  See 'sdpctl profile list'
+  SDPCTL_CLIENT_CERTIFICATE:
+    Description: Path to a PEM or PKCS#12 client certificate used for mutual TLS, overriding the configuration.
+  SDPCTL_CLIENT_KEY:
+    Description: Path to the PEM private key of the client certificate, if it's not in the same file.
+  SDPCTL_CLIENT_KEY_PASSPHRASE:
+    Description: Passphrase of an encrypted PKCS#8 client key or PKCS#12 client certificate, instead of prompting for it.
+  SDPCTL_PINNED_SPKI:
+    Description: Base64 SHA-256 hash of the public key the Controller certificate must have, see 'sdpctl configure --pin'.
+  SDPCTL_PIN_MODE:
+    Description: What to do when the Controller certificate doesn't match the pinned public key.
+    Default: fail
+    Options: fail, warn
+  SDPCTL_TOTP_GENERATE:
+    Description: Generate one-time passwords from the TOTP secret in the keyring instead of prompting for them. The secret
+                 is stored in the keyring when the Controller enrolls it on the first sign in. A rejected code is
+                 retried once with the closest time step.
+    Options: true, false
+  SDPCTL_TOTP_SECRET:
+    Description: Base32 TOTP secret used to generate one-time passwords when SDPCTL_TOTP_GENERATE is set, instead of the keyring.
   SDPCTL_NO_KEYRING:
     Description: Disable keyring integration. Does not attempt to store anything in the os keychain.
+  SDPCTL_KEYRING_BACKEND:
+    Description: Where to store credentials on Linux. 'auto' uses the system keyring, or the encrypted file when the system
+                 keyring is unavailable and SDPCTL_KEYRING_PASSPHRASE or a key file is set up.
+    Default: auto
+    Options: auto, system, file
+  SDPCTL_KEYRING_PASSPHRASE:
+    Description: Passphrase used to encrypt the file keyring.
+  SDPCTL_KEYRING_KEYFILE:
+    Description: Path to a key file used to encrypt the file keyring when no passphrase is set, only readable by its owner (chmod 600).
+    Default: "$SDPCTL_CONFIG_DIR/keyring.key", created if missing when SDPCTL_KEYRING_BACKEND is 'file'.
   SDPCTL_DISABLE_VERSION_CHECK:
     Description: Disable version checking when running commands
     Options: true, false
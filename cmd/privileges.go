@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"github.com/appgate/sdpctl/pkg/auth"
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
@@ -16,7 +17,7 @@ type privilegeOption struct {
 	config  *configuration.Config
 	factory *factory.Factory
 	out     io.Writer
-	json    bool
+	output  *cmdutil.OutputOptions
 }
 
 // NewPrivilegesCmd return a new privileges command
@@ -33,12 +34,15 @@ func NewPrivilegesCmd(f *factory.Factory) *cobra.Command {
 			return privilegeRun(&opts)
 		},
 	}
-	cmd.Flags().BoolVar(&opts.json, "json", false, "Display in JSON format")
+	opts.output = cmdutil.AddOutputFlags(cmd, cmdutil.OutputTable)
 
 	return cmd
 }
 
 func privilegeRun(opts *privilegeOption) error {
+	if err := opts.output.Validate(); err != nil {
+		return err
+	}
 	cfg := opts.config
 	client, err := opts.factory.APIClient(cfg)
 	if err != nil {
@@ -56,16 +60,13 @@ func privilegeRun(opts *privilegeOption) error {
 	}
 	user := response.GetUser()
 	privileges := user.GetPrivileges()
-	if opts.json {
-		return util.PrintJSON(opts.out, user)
-	}
-	fmt.Fprintf(opts.out, "\n%s have the following privileges\n\n", user.GetName())
-	p := util.NewPrinter(opts.out, 4)
-	p.AddHeader("target", "type", "scope")
+	t := cmdutil.NewTable("target", "type", "scope")
 	for _, privilege := range privileges {
 		s := privilege.GetScope()
-		p.AddLine(privilege.GetTarget(), privilege.GetType(), append(s.GetTags(), s.GetIds()...))
+		t.AddRow(privilege.GetTarget(), privilege.GetType(), append(s.GetTags(), s.GetIds()...))
+	}
+	if opts.output.IsDefaultTable() {
+		fmt.Fprintf(opts.out, "\n%s have the following privileges\n\n", user.GetName())
 	}
-	p.Print()
-	return nil
+	return opts.output.Print(opts.out, user, t)
 }
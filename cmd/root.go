@@ -20,6 +20,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 
 	appliancecmd "github.com/appgate/sdpctl/cmd/appliance"
+	authcmd "github.com/appgate/sdpctl/cmd/auth"
 	cfgcmd "github.com/appgate/sdpctl/cmd/configure"
 	"github.com/appgate/sdpctl/cmd/serviceusers"
 	"github.com/appgate/sdpctl/pkg/auth"
@@ -27,6 +28,7 @@ import (
 	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/factory"
 	"github.com/appgate/sdpctl/pkg/filesystem"
+	"github.com/appgate/sdpctl/pkg/keyring"
 	"github.com/appgate/sdpctl/pkg/profiles"
 	"github.com/appgate/sdpctl/pkg/util"
 	log "github.com/sirupsen/logrus"
@@ -204,13 +206,19 @@ func NewCmdRoot(currentProfile *string) (*cobra.Command, error) {
 
 	BindEnvs(*cfg)
 	viper.Unmarshal(cfg)
+	if err := keyring.SetBackend(cfg.KeyringBackend); err != nil {
+		return nil, fmt.Errorf("sdpctl configuration error: %w", err)
+	}
 
 	initConfig(currentProfile)
 
 	f := factory.New(version, cfg)
+	// sign in again when the bearer token expires during long running commands
+	f.TokenSource = factory.NewTokenSource(f, auth.Signin)
 	rootCmd.AddCommand(
 		cfgcmd.NewCmdConfigure(f),
 		appliancecmd.NewApplianceCmd(f),
+		authcmd.NewAuthCmd(f),
 		device.NewDeviceCmd(f),
 		NewCmdCompletion(),
 		NewHelpCmd(f),
@@ -348,6 +356,11 @@ func rootPersistentPreRunEFunc(f *factory.Factory, cfg *configuration.Config) fu
 		}
 		log.WithFields(logFields).Info()
 
+		// Expand '--group' on the appliance commands into the filter flags of the appliance group
+		if err := cfg.ApplyApplianceGroup(cmd.Flags()); err != nil {
+			return err
+		}
+
 		f.DisablePrompt(cfg.NoInteractive)
 		if cfg.CiMode {
 			f.SetSpinnerOutput(io.Discard)
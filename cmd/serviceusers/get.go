@@ -2,7 +2,9 @@ package serviceusers
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
 	"github.com/appgate/sdpctl/pkg/util"
@@ -28,7 +30,7 @@ func NewServiceUsersGetCMD(f *factory.Factory) *cobra.Command {
 			if !util.IsUUID(args[0]) {
 				return fmt.Errorf("%s: %s", InvalidUUIDError, args[0])
 			}
-			return nil
+			return opts.Output.Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			api, err := opts.API(opts.Config)
@@ -43,9 +45,13 @@ func NewServiceUsersGetCMD(f *factory.Factory) *cobra.Command {
 				return err
 			}
 
-			return util.PrintJSON(opts.Out, user)
+			t := cmdutil.NewTable("Name", "ID", "Disabled", "Tags", "Modified")
+			t.AddRow(user.GetName(), user.GetId(), user.GetDisabled(), strings.Join(user.GetTags(), ","), user.GetUpdated())
+			return opts.Output.Print(opts.Out, user, t)
 		},
 	}
+	// the service user is shown in JSON by default, since the table leaves out the labels and notes
+	opts.Output = cmdutil.AddOutputFlagsInheritJSON(cmd, cmdutil.OutputJSON)
 
 	return cmd
 }
@@ -3,6 +3,7 @@ package serviceusers
 import (
 	"strings"
 
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
 	"github.com/appgate/sdpctl/pkg/util"
@@ -23,6 +24,9 @@ func NewServiceUsersListCMD(f *factory.Factory) *cobra.Command {
 		Short:   docs.ServiceUsersList.Short,
 		Long:    docs.ServiceUsersList.Long,
 		Example: docs.ServiceUsersList.ExampleString(),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Output.Validate()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			api, err := opts.API(opts.Config)
 			if err != nil {
@@ -36,23 +40,14 @@ func NewServiceUsersListCMD(f *factory.Factory) *cobra.Command {
 				return err
 			}
 
-			if opts.JSON, err = cmd.Flags().GetBool("json"); err != nil {
-				return err
-			}
-			if opts.JSON {
-				return util.PrintJSON(opts.Out, users)
-			}
-
-			p := util.NewPrinter(opts.Out, 4)
-			p.AddHeader("Name", "ID", "Disabled", "Tags", "Modified")
+			t := cmdutil.NewTable("Name", "ID", "Disabled", "Tags", "Modified")
 			for _, u := range users {
-				p.AddLine(u.GetName(), u.GetId(), u.GetDisabled(), strings.Join(u.GetTags(), ","), u.GetUpdated())
+				t.AddRow(u.GetName(), u.GetId(), u.GetDisabled(), strings.Join(u.GetTags(), ","), u.GetUpdated())
 			}
-			p.Print()
-
-			return nil
+			return opts.Output.Print(opts.Out, users, t)
 		},
 	}
+	opts.Output = cmdutil.AddOutputFlagsInheritJSON(cmd, cmdutil.OutputTable)
 
 	return cmd
 }
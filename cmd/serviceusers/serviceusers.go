@@ -3,6 +3,7 @@ package serviceusers
 import (
 	"io"
 
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/factory"
@@ -16,7 +17,7 @@ type ServiceUsersOptions struct {
 	API    func(c *configuration.Config) (*serviceusers.ServiceUsersAPI, error)
 	In     io.ReadCloser
 	Out    io.Writer
-	JSON   bool
+	Output *cmdutil.OutputOptions
 }
 
 // ServiceUserDTO represents a service users options which are modifiable by the user
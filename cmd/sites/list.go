@@ -1,11 +1,11 @@
 package sites
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/util"
 	"github.com/spf13/cobra"
@@ -14,7 +14,7 @@ import (
 type SitesListOptions struct {
 	SitesOptions
 	ids, siteNames []string
-	json           bool
+	output         *cmdutil.OutputOptions
 }
 
 func NewSitesListCmd(parentOpts *SitesOptions) *cobra.Command {
@@ -45,7 +45,7 @@ func NewSitesListCmd(parentOpts *SitesOptions) *cobra.Command {
 				}
 			}
 
-			return nil
+			return opts.output.Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := util.BaseAuthContext(opts.SitesAPI.Token)
@@ -71,33 +71,21 @@ func NewSitesListCmd(parentOpts *SitesOptions) *cobra.Command {
 				return nil
 			}
 
-			if opts.json {
-				o, err := json.MarshalIndent(sites, "", "  ")
-				if err != nil {
-					return err
-				}
-				fmt.Fprintln(opts.Out, string(o))
-			} else {
-				p := util.NewPrinter(opts.Out, 4)
-				p.AddHeader("Site Name", "ID", "Tags", "Description", "Status")
-				for _, s := range sites {
-
-					p.AddLine(
-						util.StringAbbreviate(s.GetName()),
-						util.StringAbbreviate(s.GetId()),
-						s.GetTags(),
-						util.StringAbbreviate(s.GetDescription()),
-						util.StringAbbreviate(s.GetStatus()),
-					)
-				}
-				p.Print()
+			t := cmdutil.NewTable("Site Name", "ID", "Tags", "Description", "Status")
+			for _, s := range sites {
+				t.AddRow(
+					s.GetName(),
+					s.GetId(),
+					s.GetTags(),
+					s.GetDescription(),
+					s.GetStatus(),
+				)
 			}
-
-			return nil
+			return opts.output.Print(opts.Out, sites, t)
 		},
 	}
 
-	cmd.Flags().BoolVar(&opts.json, "json", false, "")
+	opts.output = cmdutil.AddOutputFlags(cmd, cmdutil.OutputTable)
 
 	return cmd
 }
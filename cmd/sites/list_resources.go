@@ -1,11 +1,11 @@
 package sites
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/cmdutil"
 	"github.com/appgate/sdpctl/pkg/docs"
 	"github.com/appgate/sdpctl/pkg/util"
 	"github.com/sirupsen/logrus"
@@ -15,7 +15,7 @@ import (
 type ResourcesListOptions struct {
 	SitesOptions
 	siteID string
-	json   bool
+	output *cmdutil.OutputOptions
 }
 
 func NewResourceNamesCmd(parentOpts *SitesOptions) *cobra.Command {
@@ -39,6 +39,9 @@ func NewResourceNamesCmd(parentOpts *SitesOptions) *cobra.Command {
 				return fmt.Errorf("A site id must be specified")
 			}
 			opts.siteID = args[0]
+			if err := opts.output.Validate(); err != nil {
+				return err
+			}
 			ctx := util.BaseAuthContext(opts.SitesAPI.Token)
 			sites, err := opts.SitesAPI.ListSites(ctx)
 			if sites == nil || err != nil {
@@ -58,7 +61,7 @@ func NewResourceNamesCmd(parentOpts *SitesOptions) *cobra.Command {
 			resourceTypes := openapi.AllowedResourceTypeEnumValues
 			resourceReturnList := []openapi.ResolverResources{}
 
-			fmt.Println("Querying resource names...")
+			log.Info("Querying resource names...")
 			resolverString, _ := cmd.Flags().GetString("resolver")
 			resolvers := strings.Split(resolverString, "&")
 
@@ -105,37 +108,24 @@ func NewResourceNamesCmd(parentOpts *SitesOptions) *cobra.Command {
 				}
 			}
 
-			if opts.json {
-				o, err := json.MarshalIndent(resourceReturnList, "", "  ")
-				if err != nil {
-					return err
-				}
-				fmt.Fprintln(opts.Out, string(o))
-			} else {
-				p := util.NewPrinter(opts.Out, 4)
-				p.AddHeader("Name", "Resolver", "Type", "Gateway Name")
-				for _, s := range resourceReturnList {
-					for _, d := range s.Data {
-
-						p.AddLine(
-							util.StringAbbreviate(string(d)),
-							util.StringAbbreviate(string(*s.Resolver)),
-							util.StringAbbreviate(string(*s.Type)),
-							util.StringAbbreviate(string(*s.GatewayName)),
-						)
-
-					}
+			t := cmdutil.NewTable("Name", "Resolver", "Type", "Gateway Name")
+			for _, s := range resourceReturnList {
+				for _, d := range s.Data {
+					t.AddRow(
+						string(d),
+						string(*s.Resolver),
+						string(*s.Type),
+						string(*s.GatewayName),
+					)
 				}
-				if len(resourceReturnList) == 0 {
-					p.AddLine("No resources found in the site")
-					return nil
-				}
-
-				p.Print()
 			}
-			return nil
+			if len(resourceReturnList) == 0 && opts.output.IsDefaultTable() {
+				t.AddRow("No resources found in the site")
+			}
+			return opts.output.Print(opts.Out, resourceReturnList, t)
 		},
 	}
+	opts.output = cmdutil.AddOutputFlags(cmd, cmdutil.OutputTable)
 
 	pFlags := cmd.PersistentFlags()
 	pFlags.String("resolver", "", "Specify resolver types. Use & to append multiple.")
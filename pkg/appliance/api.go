@@ -28,12 +28,21 @@ type Appliance struct {
 
 // List from the Collective
 // Filter is applied in app after getting all the appliances because the auto generated API screws up the 'filterBy' command
+// If the filter or order uses the live stats, such as 'cpu' or 'sessions', the appliance stats are fetched as well.
 func (a *Appliance) List(ctx context.Context, filter map[string]map[string]string, orderBy []string, descending bool) ([]openapi.Appliance, error) {
 	appliances, response, err := a.APIClient.AppliancesApi.AppliancesGet(ctx).OrderBy("name").Execute()
 	if err != nil {
 		return nil, api.HTTPErrorResponse(response, err)
 	}
-	result, _, err := FilterAppliances(appliances.GetData(), filter, orderBy, descending)
+	var stats []openapi.ApplianceWithStatus
+	if NeedsApplianceStats(filter, orderBy) {
+		status, _, err := a.ApplianceStatus(ctx, nil, nil, false)
+		if err != nil {
+			return nil, err
+		}
+		stats = status.GetData()
+	}
+	result, _, err := FilterAppliancesWithStats(appliances.GetData(), stats, filter, orderBy, descending)
 	if err != nil {
 		return nil, err
 	}
@@ -12,6 +12,8 @@ import (
 	"os/signal"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -189,7 +191,15 @@ NO_ENABLE_CHECK:
 		}
 
 		if !reflect.DeepEqual(nullFilter, opts.FilterFlag) {
-			res, _, err := FilterAppliances(appliances, opts.FilterFlag, opts.OrderBy, opts.Descending)
+			var stats []openapi.ApplianceWithStatus
+			if NeedsApplianceStats(opts.FilterFlag, opts.OrderBy) {
+				status, _, err := app.ApplianceStatus(ctx, nil, nil, false)
+				if err != nil {
+					return nil, err
+				}
+				stats = status.GetData()
+			}
+			res, _, err := FilterAppliancesWithStats(appliances, stats, opts.FilterFlag, opts.OrderBy, opts.Descending)
 			if err != nil {
 				return nil, err
 			}
@@ -307,7 +317,7 @@ NO_ENABLE_CHECK:
 		msg := "downloading"
 		logger.Info(msg)
 		tracker.Update(msg)
-		b.destination = filepath.Join(opts.Destination, fmt.Sprintf("appgate_backup_%s_%s.bkp", strings.ReplaceAll(appliance.GetName(), " ", "_"), time.Now().Format("20060102_150405")))
+		b.destination = filepath.Join(opts.Destination, backupFilename(appliance.GetName(), time.Now()))
 		var file *os.File
 		if opts.Config.Version >= 20 {
 			file, err = backupAPI.Download(ctx, b.applianceID, b.backupID, b.destination)
@@ -512,6 +522,72 @@ func backupEnabled(ctx context.Context, client *openapi.APIClient, token string,
 	return enabled, nil
 }
 
+const backupTimeFormat = "20060102_150405"
+
+var backupFileRegex = regexp.MustCompile(`^appgate_backup_(.+)_(\d{8}_\d{6})\.bkp$`)
+
+func backupFilename(applianceName string, t time.Time) string {
+	return fmt.Sprintf("appgate_backup_%s_%s.bkp", strings.ReplaceAll(applianceName, " ", "_"), t.Format(backupTimeFormat))
+}
+
+// BackupRetention removes the oldest backup files in the destination directory so that
+// at most 'keep' backup files remain for each appliance. Only files matching the backup
+// file name pattern are considered. The paths of the removed files are returned.
+func BackupRetention(destination string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(destination)
+	if err != nil {
+		return nil, err
+	}
+	type backupFile struct {
+		path    string
+		created time.Time
+	}
+	byAppliance := map[string][]backupFile{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		match := backupFileRegex.FindStringSubmatch(e.Name())
+		if match == nil {
+			continue
+		}
+		created, err := time.ParseInLocation(backupTimeFormat, match[2], time.Local)
+		if err != nil {
+			continue
+		}
+		byAppliance[match[1]] = append(byAppliance[match[1]], backupFile{
+			path:    filepath.Join(destination, e.Name()),
+			created: created,
+		})
+	}
+
+	var (
+		removed []string
+		errs    *multierror.Error
+	)
+	for name, files := range byAppliance {
+		if len(files) <= keep {
+			continue
+		}
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].created.After(files[j].created)
+		})
+		for _, f := range files[keep:] {
+			if err := os.Remove(f.path); err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			log.WithFields(log.Fields{"appliance": name, "file": f.path}).Info("Removed backup file due to retention")
+			removed = append(removed, f.path)
+		}
+	}
+	sort.Strings(removed)
+	return removed, errs.ErrorOrNil()
+}
+
 func showBackupSummary(dest string, appliances []openapi.Appliance) (string, error) {
 	type ApplianceStub struct {
 		Name string
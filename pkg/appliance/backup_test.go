@@ -0,0 +1,55 @@
+package appliance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBackupRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, time.March, 10, 2, 0, 0, 0, time.Local)
+	files := []string{
+		backupFilename("controller one", now),
+		backupFilename("controller one", now.AddDate(0, 0, -1)),
+		backupFilename("controller one", now.AddDate(0, 0, -2)),
+		backupFilename("controller one", now.AddDate(0, 0, -3)),
+		backupFilename("controller", now),
+		backupFilename("controller", now.AddDate(0, 0, -1)),
+		"unrelated.bkp",
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte("backup"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := BackupRetention(dir, 2)
+	if err != nil {
+		t.Fatalf("BackupRetention() unexpected error %s", err)
+	}
+	want := []string{
+		filepath.Join(dir, "appgate_backup_controller_one_20240307_020000.bkp"),
+		filepath.Join(dir, "appgate_backup_controller_one_20240308_020000.bkp"),
+	}
+	if diff := cmp.Diff(want, removed); diff != "" {
+		t.Fatalf("removed files mismatch (-want +got):\n%s", diff)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 files left after retention, got %d", len(entries))
+	}
+}
+
+func TestBackupRetentionDisabled(t *testing.T) {
+	removed, err := BackupRetention("/path/does/not/exist", 0)
+	if err != nil || removed != nil {
+		t.Fatalf("BackupRetention() with keep=0 should be a no-op, got %v %v", removed, err)
+	}
+}
@@ -44,10 +44,18 @@ To avoid problems during the upgrade process it's recommended to
 increase the space on those appliances.`)
 }
 
+// LowDiskSpaceThreshold is the disk usage in percent at which an appliance is considered to have low disk space
+const LowDiskSpaceThreshold float32 = 75
+
 func HasLowDiskSpace(stats []openapi.ApplianceWithStatus) []openapi.ApplianceWithStatus {
+	return DiskUsageAbove(stats, LowDiskSpaceThreshold)
+}
+
+// DiskUsageAbove returns the appliances with a disk usage of at least percent
+func DiskUsageAbove(stats []openapi.ApplianceWithStatus, percent float32) []openapi.ApplianceWithStatus {
 	result := []openapi.ApplianceWithStatus{}
 	for _, s := range stats {
-		if s.GetDisk() >= 75 {
+		if s.GetDisk() >= percent {
 			result = append(result, s)
 		}
 	}
@@ -0,0 +1,829 @@
+package appliance
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/util"
+)
+
+// FilterExpression is a parsed '--filter' expression, such as
+//
+//	function == "gateway" && site == "Stockholm" && !tag("canary")
+//
+// Unlike the '--include' and '--exclude' flags, where an appliance is selected if any of the key-value pairs matches,
+// the expression is a boolean combination of comparisons with '&&', '||', '!' and parenthesis. The version is compared
+// as semver, so 'version >= 6.2 && version < 6.4' and 'version(">=6.2;<6.4")' select the same appliances.
+type FilterExpression struct {
+	input  string
+	root   filterNode
+	fields map[string]bool
+}
+
+// FilterExpressionError is returned when a filter expression can't be parsed. Position is the byte offset in the
+// expression where the error was found, and is marked in the error message.
+type FilterExpressionError struct {
+	Expression string
+	Position   int
+	Message    string
+}
+
+func (e *FilterExpressionError) Error() string {
+	return fmt.Sprintf("invalid filter expression: %s at position %d\n  %s\n  %s^", e.Message, e.Position+1, e.Expression, strings.Repeat(" ", e.Position))
+}
+
+type filterValueKind int
+
+const (
+	filterString filterValueKind = iota
+	filterNumber
+	filterBool
+	filterList
+	filterVersion
+)
+
+var filterValueKindNames = map[filterValueKind]string{
+	filterString:  "string",
+	filterNumber:  "number",
+	filterBool:    "boolean",
+	filterList:    "list",
+	filterVersion: "version",
+}
+
+type filterValue struct {
+	kind filterValueKind
+	str  string
+	num  float64
+	b    bool
+	list []string
+}
+
+// filterField describes a field that can be used in an expression. appliance is nil for fields that only exist on
+// the appliance stats.
+type filterField struct {
+	kind      filterValueKind
+	appliance func(a openapi.Appliance) filterValue
+	stats     func(s openapi.ApplianceWithStatus) filterValue
+}
+
+func stringValue(s string) filterValue     { return filterValue{kind: filterString, str: s} }
+func numberValue(n float64) filterValue    { return filterValue{kind: filterNumber, num: n} }
+func boolValue(b bool) filterValue         { return filterValue{kind: filterBool, b: b} }
+func listValue(list []string) filterValue  { return filterValue{kind: filterList, list: list} }
+func versionValue(v string) filterValue    { return filterValue{kind: filterVersion, str: v} }
+func float32Value(n float32) filterValue   { return numberValue(ExactFloat64(n)) }
+func activeFunctions(s string) filterValue { return listValue(strings.Split(s, ", ")) }
+
+var filterFields = map[string]filterField{
+	"name": {
+		kind:      filterString,
+		appliance: func(a openapi.Appliance) filterValue { return stringValue(a.GetName()) },
+		stats:     func(s openapi.ApplianceWithStatus) filterValue { return stringValue(s.GetName()) },
+	},
+	"id": {
+		kind:      filterString,
+		appliance: func(a openapi.Appliance) filterValue { return stringValue(a.GetId()) },
+		stats:     func(s openapi.ApplianceWithStatus) filterValue { return stringValue(s.GetId()) },
+	},
+	"hostname": {
+		kind:      filterString,
+		appliance: func(a openapi.Appliance) filterValue { return stringValue(a.GetHostname()) },
+		stats:     func(s openapi.ApplianceWithStatus) filterValue { return stringValue(s.GetHostname()) },
+	},
+	"site": {
+		kind:      filterString,
+		appliance: func(a openapi.Appliance) filterValue { return stringValue(a.GetSiteName()) },
+		stats:     func(s openapi.ApplianceWithStatus) filterValue { return stringValue(s.GetSiteName()) },
+	},
+	"site_id": {
+		kind:      filterString,
+		appliance: func(a openapi.Appliance) filterValue { return stringValue(a.GetSite()) },
+		stats:     func(s openapi.ApplianceWithStatus) filterValue { return stringValue(s.GetSite()) },
+	},
+	"activated": {
+		kind:      filterBool,
+		appliance: func(a openapi.Appliance) filterValue { return boolValue(a.GetActivated()) },
+		stats:     func(s openapi.ApplianceWithStatus) filterValue { return boolValue(s.GetActivated()) },
+	},
+	"tags": {
+		kind:      filterList,
+		appliance: func(a openapi.Appliance) filterValue { return listValue(a.GetTags()) },
+		stats:     func(s openapi.ApplianceWithStatus) filterValue { return listValue(s.GetTags()) },
+	},
+	"function": {
+		kind:      filterList,
+		appliance: func(a openapi.Appliance) filterValue { return listValue(GetActiveFunctions(a)) },
+		stats:     func(s openapi.ApplianceWithStatus) filterValue { return activeFunctions(ApplianceActiveFunctions(s)) },
+	},
+	"status": {
+		kind:  filterString,
+		stats: func(s openapi.ApplianceWithStatus) filterValue { return stringValue(s.GetStatus()) },
+	},
+	"state": {
+		kind:  filterString,
+		stats: func(s openapi.ApplianceWithStatus) filterValue { return stringValue(s.GetState()) },
+	},
+	"version": {
+		kind:  filterVersion,
+		stats: func(s openapi.ApplianceWithStatus) filterValue { return versionValue(s.GetApplianceVersion()) },
+	},
+	"cpu": {
+		kind:  filterNumber,
+		stats: func(s openapi.ApplianceWithStatus) filterValue { return float32Value(s.GetCpu()) },
+	},
+	"memory": {
+		kind:  filterNumber,
+		stats: func(s openapi.ApplianceWithStatus) filterValue { return float32Value(s.GetMemory()) },
+	},
+	"disk": {
+		kind:  filterNumber,
+		stats: func(s openapi.ApplianceWithStatus) filterValue { return float32Value(s.GetDisk()) },
+	},
+	"sessions": {
+		kind:  filterNumber,
+		stats: func(s openapi.ApplianceWithStatus) filterValue { return numberValue(float64(s.GetNumberOfSessions())) },
+	},
+}
+
+// filterFieldAliases are alternative names for the fields, matching the keywords of '--include' and '--exclude'
+var filterFieldAliases = map[string]string{
+	"host":      "hostname",
+	"site-id":   "site_id",
+	"site-name": "site",
+	"active":    "activated",
+	"tag":       "tags",
+	"functions": "function",
+	"mem":       "memory",
+}
+
+// filterFunctions are the functions that can be called in an expression, and the field of the list they search.
+// 'version' is not a list, it checks the appliance version against version ranges such as '>=6.2;<6.4'.
+var filterFunctions = map[string]string{
+	"tag":      "tags",
+	"function": "function",
+	"version":  "version",
+}
+
+// filterSubject is an appliance, appliance stats or both that an expression is evaluated against
+type filterSubject struct {
+	appliance *openapi.Appliance
+	stats     *openapi.ApplianceWithStatus
+}
+
+func (s filterSubject) field(name string) (filterValue, error) {
+	f := filterFields[name]
+	if s.appliance != nil && f.appliance != nil {
+		return f.appliance(*s.appliance), nil
+	}
+	if s.stats == nil {
+		return filterValue{}, fmt.Errorf("filter field '%s' is only available on commands that use the appliance stats, such as 'sdpctl appliance stats'", name)
+	}
+	return f.stats(*s.stats), nil
+}
+
+// ParseFilterExpression parses a filter expression. The error is a *FilterExpressionError that points out where the
+// expression is invalid.
+func ParseFilterExpression(input string) (*FilterExpression, error) {
+	p := &filterParser{input: input, fields: make(map[string]bool)}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	if p.peek().kind == tokenEOF {
+		return nil, p.errorf(p.peek(), "expected an expression")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s, expected '&&', '||' or the end of the expression", t)
+	}
+	return &FilterExpression{input: input, root: root, fields: p.fields}, nil
+}
+
+func (e *FilterExpression) String() string {
+	return e.input
+}
+
+// usesStats returns true if the expression uses a field that only exists on the appliance stats
+func (e *FilterExpression) usesStats() bool {
+	for name := range e.fields {
+		if filterFields[name].appliance == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAppliance returns true if the appliance matches the expression
+func (e *FilterExpression) MatchAppliance(a openapi.Appliance) (bool, error) {
+	return e.root.eval(filterSubject{appliance: &a})
+}
+
+// MatchApplianceStats returns true if the appliance stats matches the expression
+func (e *FilterExpression) MatchApplianceStats(s openapi.ApplianceWithStatus) (bool, error) {
+	return e.root.eval(filterSubject{stats: &s})
+}
+
+// filterExpressionFromFilter returns the expression given with '--filter', which ParseFilteringFlags adds to the filter,
+// or nil if there is none
+func filterExpressionFromFilter(filter map[string]map[string]string) (*FilterExpression, error) {
+	input := filter[util.FilterExpressionKey][util.FilterExpressionKey]
+	if len(strings.TrimSpace(input)) == 0 {
+		return nil, nil
+	}
+	return ParseFilterExpression(input)
+}
+
+// applyApplianceFilterExpression returns the appliances that match the expression. If the expression uses a stats
+// field, the stats of each appliance are taken from stats, and appliances without stats don't match.
+func applyApplianceFilterExpression(appliances []openapi.Appliance, stats map[string]openapi.ApplianceWithStatus, expression *FilterExpression) ([]openapi.Appliance, error) {
+	filtered := make([]openapi.Appliance, 0, len(appliances))
+	usesStats := stats != nil && expression.usesStats()
+	for _, a := range appliances {
+		subject := filterSubject{appliance: &a}
+		if usesStats {
+			s, ok := stats[a.GetId()]
+			if !ok {
+				continue
+			}
+			subject.stats = &s
+		}
+		match, err := expression.root.eval(subject)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered, nil
+}
+
+func applyApplianceStatsFilterExpression(stats []openapi.ApplianceWithStatus, expression *FilterExpression) ([]openapi.ApplianceWithStatus, error) {
+	filtered := make([]openapi.ApplianceWithStatus, 0, len(stats))
+	for _, s := range stats {
+		match, err := expression.MatchApplianceStats(s)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered, nil
+}
+
+type filterNode interface {
+	eval(s filterSubject) (bool, error)
+}
+
+type filterOperand interface {
+	value(s filterSubject) (filterValue, error)
+}
+
+type filterAnd struct{ left, right filterNode }
+type filterOr struct{ left, right filterNode }
+type filterNot struct{ node filterNode }
+
+func (n filterAnd) eval(s filterSubject) (bool, error) {
+	l, err := n.left.eval(s)
+	if err != nil || !l {
+		return false, err
+	}
+	return n.right.eval(s)
+}
+
+func (n filterOr) eval(s filterSubject) (bool, error) {
+	l, err := n.left.eval(s)
+	if err != nil || l {
+		return l, err
+	}
+	return n.right.eval(s)
+}
+
+func (n filterNot) eval(s filterSubject) (bool, error) {
+	v, err := n.node.eval(s)
+	return !v, err
+}
+
+type filterFieldOperand struct{ name string }
+type filterLiteral struct{ v filterValue }
+
+func (o filterFieldOperand) value(s filterSubject) (filterValue, error) { return s.field(o.name) }
+func (o filterLiteral) value(s filterSubject) (filterValue, error)      { return o.v, nil }
+
+// filterBoolean is a field or literal used as a condition on its own, such as 'activated'
+type filterBoolean struct{ operand filterOperand }
+
+func (n filterBoolean) eval(s filterSubject) (bool, error) {
+	v, err := n.operand.value(s)
+	return v.b, err
+}
+
+// filterContains is a call to one of the filterFunctions, which is true if any of the arguments is in the list
+type filterContains struct {
+	field string
+	args  []string
+}
+
+func (n filterContains) eval(s filterSubject) (bool, error) {
+	v, err := s.field(n.field)
+	if err != nil {
+		return false, err
+	}
+	for _, arg := range n.args {
+		if listContains(v.list, arg) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type filterCompare struct {
+	op          string
+	left, right filterOperand
+	regex       *regexp.Regexp
+}
+
+func (n filterCompare) eval(s filterSubject) (bool, error) {
+	l, err := n.left.value(s)
+	if err != nil {
+		return false, err
+	}
+	r, err := n.right.value(s)
+	if err != nil {
+		return false, err
+	}
+	if (l.kind == filterVersion || r.kind == filterVersion) && n.regex == nil {
+		return compareFilterVersions(n.op, l, r), nil
+	}
+	switch n.op {
+	case "=~", "!~":
+		match := false
+		if l.kind == filterList {
+			for _, e := range l.list {
+				match = match || n.regex.MatchString(e)
+			}
+		} else {
+			match = n.regex.MatchString(l.str)
+		}
+		return match == (n.op == "=~"), nil
+	case "==", "!=":
+		return equalFilterValues(l, r) == (n.op == "=="), nil
+	}
+	c, err := compareFilterValues(l, r)
+	if err != nil {
+		return false, err
+	}
+	switch n.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+// equalFilterValues compares strings ignoring case, and a list is equal to a string if it contains the string
+func equalFilterValues(l, r filterValue) bool {
+	switch {
+	case l.kind == filterList:
+		return listContains(l.list, r.str)
+	case r.kind == filterList:
+		return listContains(r.list, l.str)
+	case l.kind == filterNumber:
+		return l.num == r.num
+	case l.kind == filterBool:
+		return l.b == r.b
+	}
+	return strings.EqualFold(l.str, r.str)
+}
+
+func compareFilterValues(l, r filterValue) (int, error) {
+	if l.kind != filterNumber || r.kind != filterNumber {
+		return 0, fmt.Errorf("can't order %s and %s values, '<', '<=', '>' and '>=' require numbers", filterValueKindNames[l.kind], filterValueKindNames[r.kind])
+	}
+	switch {
+	case l.num < r.num:
+		return -1, nil
+	case l.num > r.num:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// compareFilterVersions compares appliance versions as semver, where "6.2" is equal to "6.2.0". A version that can't be
+// parsed, such as the version of an offline appliance, doesn't match any comparison.
+func compareFilterVersions(op string, l, r filterValue) bool {
+	lv, err := ParseVersionString(l.str)
+	if err != nil {
+		return false
+	}
+	rv, err := ParseVersionString(r.str)
+	if err != nil {
+		return false
+	}
+	c := lv.Compare(rv)
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+// filterVersionRange is a call to version(), which is true if the appliance version is in any of the version ranges
+type filterVersionRange struct {
+	ranges []string
+}
+
+func (n filterVersionRange) eval(s filterSubject) (bool, error) {
+	v, err := s.field("version")
+	if err != nil {
+		return false, err
+	}
+	return matchVersionRange(v.str, strings.Join(n.ranges, FilterDelimiter))
+}
+
+func listContains(list []string, s string) bool {
+	for _, e := range list {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type filterTokenKind int
+
+const (
+	tokenEOF filterTokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenVersion
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+	num  float64
+}
+
+func (t filterToken) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+var filterOperators = []string{"&&", "||", "==", "!=", "=~", "!~", "<=", ">=", "<", ">", "!"}
+
+type filterParser struct {
+	input  string
+	tokens []filterToken
+	next   int
+	// fields are the fields used in the expression
+	fields map[string]bool
+}
+
+func (p *filterParser) errorf(t filterToken, format string, args ...interface{}) error {
+	return &FilterExpressionError{Expression: p.input, Position: t.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) tokenize() error {
+	input := p.input
+	i := 0
+	for i < len(input) {
+		c := rune(input[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')' || c == ',':
+			kind := map[rune]filterTokenKind{'(': tokenLParen, ')': tokenRParen, ',': tokenComma}[c]
+			p.tokens = append(p.tokens, filterToken{kind: kind, text: string(c), pos: i})
+			i++
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(input) && rune(input[j]) != c; j++ {
+				if input[j] == '\\' && j+1 < len(input) {
+					j++
+				}
+				b.WriteByte(input[j])
+			}
+			if j >= len(input) {
+				return &FilterExpressionError{Expression: input, Position: i, Message: "unterminated string"}
+			}
+			p.tokens = append(p.tokens, filterToken{kind: tokenString, text: b.String(), pos: i})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(input) && unicode.IsDigit(rune(input[i+1]))):
+			j := i
+			for j < len(input) && (unicode.IsDigit(rune(input[j])) || input[j] == '.') {
+				j++
+			}
+			n, err := strconv.ParseFloat(input[i:j], 64)
+			if err != nil {
+				// a number with more than one dot, such as 6.2.1, is a version
+				if _, vErr := ParseVersionString(input[i:j]); vErr == nil && strings.Count(input[i:j], ".") > 1 {
+					p.tokens = append(p.tokens, filterToken{kind: tokenVersion, text: input[i:j], pos: i})
+					i = j
+					continue
+				}
+				return &FilterExpressionError{Expression: input, Position: i, Message: fmt.Sprintf("invalid number '%s'", input[i:j])}
+			}
+			p.tokens = append(p.tokens, filterToken{kind: tokenNumber, text: input[i:j], pos: i, num: n})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(input) && (unicode.IsLetter(rune(input[j])) || unicode.IsDigit(rune(input[j])) || input[j] == '_' || input[j] == '-') {
+				j++
+			}
+			p.tokens = append(p.tokens, filterToken{kind: tokenIdent, text: input[i:j], pos: i})
+			i = j
+		default:
+			op := ""
+			for _, o := range filterOperators {
+				if strings.HasPrefix(input[i:], o) {
+					op = o
+					break
+				}
+			}
+			if len(op) == 0 {
+				message := fmt.Sprintf("unexpected character '%c'", c)
+				if c == '=' || c == '&' || c == '|' {
+					message += fmt.Sprintf(", did you mean '%c%c'?", c, c)
+				}
+				return &FilterExpressionError{Expression: input, Position: i, Message: message}
+			}
+			p.tokens = append(p.tokens, filterToken{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	p.tokens = append(p.tokens, filterToken{kind: tokenEOF, pos: len(input)})
+	return nil
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.next]
+}
+
+func (p *filterParser) consume() filterToken {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *filterParser) isOperator(op string) bool {
+	t := p.peek()
+	return t.kind == tokenOperator && t.text == op
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("||") {
+		p.consume()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = filterOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("&&") {
+		p.consume()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = filterAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if p.isOperator("!") {
+		p.consume()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{node: node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (filterNode, error) {
+	t := p.peek()
+	if t.kind == tokenLParen {
+		p.consume()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.consume(); r.kind != tokenRParen {
+			return nil, p.errorf(r, "unexpected %s, expected ')' to close the '(' at position %d", r, t.pos+1)
+		}
+		return node, nil
+	}
+	if t.kind == tokenIdent && p.tokens[p.next+1].kind == tokenLParen {
+		return p.parseCall()
+	}
+
+	left, leftKind, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek()
+	if op.kind != tokenOperator || op.text == "&&" || op.text == "||" || op.text == "!" {
+		if leftKind != filterBool {
+			return nil, p.errorf(t, "%s is not a condition, expected a comparison like '%s == \"value\"'", t, t.text)
+		}
+		return filterBoolean{operand: left}, nil
+	}
+	p.consume()
+	rt := p.peek()
+	right, rightKind, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	node := filterCompare{op: op.text, left: left, right: right}
+	if (leftKind == filterVersion || rightKind == filterVersion) && op.text != "=~" && op.text != "!~" {
+		if err := p.checkVersionComparison(t, left, leftKind, rt, right, rightKind); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	switch op.text {
+	case "=~", "!~":
+		if rt.kind != tokenString {
+			return nil, p.errorf(rt, "expected a regular expression in quotes after '%s'", op.text)
+		}
+		if node.regex, err = regexp.Compile(rt.text); err != nil {
+			return nil, p.errorf(rt, "invalid regular expression: %s", err)
+		}
+		if leftKind != filterString && leftKind != filterList && leftKind != filterVersion {
+			return nil, p.errorf(op, "'%s' requires a string on the left side", op.text)
+		}
+	case "<", "<=", ">", ">=":
+		if leftKind != filterNumber || rightKind != filterNumber {
+			return nil, p.errorf(op, "'%s' requires numbers on both sides", op.text)
+		}
+	default:
+		if leftKind != rightKind && leftKind != filterList && rightKind != filterList {
+			return nil, p.errorf(rt, "can't compare %s %s with %s %s", filterValueKindNames[leftKind], t, filterValueKindNames[rightKind], rt)
+		}
+	}
+	return node, nil
+}
+
+// checkVersionComparison checks that a version is compared with a string, number or version literal that is a valid
+// version, so that 'version >= 6.2' and 'version < "6.4"' work
+func (p *filterParser) checkVersionComparison(lt filterToken, left filterOperand, leftKind filterValueKind, rt filterToken, right filterOperand, rightKind filterValueKind) error {
+	operands := []struct {
+		token   filterToken
+		operand filterOperand
+		kind    filterValueKind
+	}{{lt, left, leftKind}, {rt, right, rightKind}}
+	for _, o := range operands {
+		if o.kind != filterVersion && o.kind != filterString && o.kind != filterNumber {
+			return p.errorf(rt, "can't compare %s %s with %s %s", filterValueKindNames[leftKind], lt, filterValueKindNames[rightKind], rt)
+		}
+		if literal, ok := o.operand.(filterLiteral); ok {
+			if _, err := ParseVersionString(literal.v.str); err != nil {
+				return p.errorf(o.token, "invalid version %s", o.token)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *filterParser) parseOperand() (filterOperand, filterValueKind, error) {
+	t := p.consume()
+	switch t.kind {
+	case tokenString:
+		return filterLiteral{v: stringValue(t.text)}, filterString, nil
+	case tokenNumber:
+		// the text is kept for comparisons with the version, where 6.2 is a version and not a number
+		return filterLiteral{v: filterValue{kind: filterNumber, num: t.num, str: t.text}}, filterNumber, nil
+	case tokenVersion:
+		return filterLiteral{v: versionValue(t.text)}, filterVersion, nil
+	case tokenIdent:
+		if t.text == "true" || t.text == "false" {
+			return filterLiteral{v: boolValue(t.text == "true")}, filterBool, nil
+		}
+		name := strings.ToLower(t.text)
+		if alias, ok := filterFieldAliases[name]; ok {
+			name = alias
+		}
+		f, ok := filterFields[name]
+		if !ok {
+			return nil, 0, p.errorf(t, "unknown field '%s', available fields are: %s", t.text, strings.Join(filterFieldNames(), ", "))
+		}
+		p.fields[name] = true
+		return filterFieldOperand{name: name}, f.kind, nil
+	case tokenEOF:
+		return nil, 0, p.errorf(t, "unexpected end of expression, expected a field or a value")
+	}
+	return nil, 0, p.errorf(t, "unexpected %s, expected a field or a value", t)
+}
+
+func (p *filterParser) parseCall() (filterNode, error) {
+	name := p.consume()
+	field, ok := filterFunctions[strings.ToLower(name.text)]
+	if !ok {
+		functions := make([]string, 0, len(filterFunctions))
+		for f := range filterFunctions {
+			functions = append(functions, f+"()")
+		}
+		sort.Strings(functions)
+		return nil, p.errorf(name, "unknown function '%s', available functions are: %s", name.text, strings.Join(functions, ", "))
+	}
+	p.consume() // '('
+	p.fields[field] = true
+	if field == "version" {
+		return p.parseVersionCall(name)
+	}
+	node := filterContains{field: field}
+	for {
+		arg := p.consume()
+		if arg.kind != tokenString {
+			return nil, p.errorf(arg, "unexpected %s, expected a string argument to %s()", arg, name.text)
+		}
+		node.args = append(node.args, arg.text)
+		next := p.consume()
+		if next.kind == tokenRParen {
+			return node, nil
+		}
+		if next.kind != tokenComma {
+			return nil, p.errorf(next, "unexpected %s, expected ',' or ')'", next)
+		}
+	}
+}
+
+// parseVersionCall parses the arguments of version(), which are version ranges like ">=6.2;<6.4"
+func (p *filterParser) parseVersionCall(name filterToken) (filterNode, error) {
+	node := filterVersionRange{}
+	for {
+		arg := p.consume()
+		if arg.kind != tokenString {
+			return nil, p.errorf(arg, "unexpected %s, expected a version range like \">=6.2;<6.4\" as argument to %s()", arg, name.text)
+		}
+		if _, err := parseVersionRange(arg.text); err != nil {
+			return nil, p.errorf(arg, "%s", err)
+		}
+		node.ranges = append(node.ranges, arg.text)
+		next := p.consume()
+		if next.kind == tokenRParen {
+			return node, nil
+		}
+		if next.kind != tokenComma {
+			return nil, p.errorf(next, "unexpected %s, expected ',' or ')'", next)
+		}
+	}
+}
+
+func filterFieldNames() []string {
+	names := make([]string, 0, len(filterFields))
+	for name := range filterFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,236 @@
+package appliance
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/util"
+)
+
+func TestParseFilterExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		position int
+		message  string
+	}{
+		{
+			input:    `nmae == "gateway"`,
+			position: 0,
+			message:  "unknown field 'nmae', available fields are: activated, cpu, disk, function, hostname, id, memory, name, sessions, site, site_id, state, status, tags, version",
+		},
+		{
+			input:    `function = "gateway"`,
+			position: 9,
+			message:  "unexpected character '=', did you mean '=='?",
+		},
+		{
+			input:    `name == "gateway`,
+			position: 8,
+			message:  "unterminated string",
+		},
+		{
+			input:    `(name == "a" || name == "b"`,
+			position: 27,
+			message:  "unexpected end of expression, expected ')' to close the '(' at position 1",
+		},
+		{
+			input:    `name =~ "gate(way"`,
+			position: 8,
+			message:  "invalid regular expression: error parsing regexp: missing closing ): `gate(way`",
+		},
+		{
+			input:    `cpu == "high"`,
+			position: 7,
+			message:  "can't compare number 'cpu' with string \"high\"",
+		},
+		{
+			input:    `version >= "abc"`,
+			position: 11,
+			message:  `invalid version "abc"`,
+		},
+		{
+			input:    "",
+			position: 0,
+			message:  "expected an expression",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseFilterExpression(tt.input)
+			var exprErr *FilterExpressionError
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("expected a FilterExpressionError, got %v", err)
+			}
+			if exprErr.Position != tt.position {
+				t.Errorf("got position %d, want %d", exprErr.Position, tt.position)
+			}
+			if exprErr.Message != tt.message {
+				t.Errorf("got message %q, want %q", exprErr.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestFilterExpressionMatchAppliance(t *testing.T) {
+	controller := openapi.Appliance{
+		Name:      "primary controller",
+		Id:        openapi.PtrString("4c07bc67-57ea-42dd-b702-c2d6c45419fc"),
+		SiteName:  openapi.PtrString("Stockholm"),
+		Activated: openapi.PtrBool(true),
+		Tags:      []string{"primary"},
+		Controller: &openapi.ApplianceAllOfController{
+			Enabled: openapi.PtrBool(true),
+		},
+	}
+	gateway := openapi.Appliance{
+		Name:      "gateway one",
+		Id:        openapi.PtrString("ee639d70-e075-4f01-596b-930d5f24f569"),
+		SiteName:  openapi.PtrString("Stockholm"),
+		Activated: openapi.PtrBool(true),
+		Tags:      []string{"canary", "dmz"},
+		Gateway: &openapi.ApplianceAllOfGateway{
+			Enabled: openapi.PtrBool(true),
+		},
+	}
+	tests := []struct {
+		input string
+		want  []bool
+	}{
+		{input: `function == "gateway" && site == "Stockholm"`, want: []bool{false, true}},
+		{input: `function == "gateway" && site == "Stockholm" && !tag("canary")`, want: []bool{false, false}},
+		{input: `tag("primary", "dmz")`, want: []bool{true, true}},
+		{input: `name =~ "^gateway" || (function == "controller" && activated == true)`, want: []bool{true, true}},
+		{input: `name !~ "controller$" && tags != "dmz"`, want: []bool{false, false}},
+		{input: `!(site == "London")`, want: []bool{true, true}},
+		{input: `activated && !function("Controller")`, want: []bool{false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expression, err := ParseFilterExpression(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, a := range []openapi.Appliance{controller, gateway} {
+				got, err := expression.MatchAppliance(a)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want[i] {
+					t.Errorf("%s: got %v, want %v", a.GetName(), got, tt.want[i])
+				}
+			}
+		})
+	}
+
+	expression, err := ParseFilterExpression(`cpu > 50`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expression.MatchAppliance(controller); err == nil {
+		t.Error("expected an error when using a stats field on an appliance")
+	}
+}
+
+func TestFilterExpressionMatchApplianceStats(t *testing.T) {
+	stats := []openapi.ApplianceWithStatus{
+		{
+			Name:             "controller",
+			Status:           openapi.PtrString("healthy"),
+			ApplianceVersion: openapi.PtrString("6.2.1-12345-release"),
+			Cpu:              openapi.PtrFloat32(12.5),
+			Memory:           openapi.PtrFloat32(10),
+		},
+		{
+			Name:             "gateway",
+			Status:           openapi.PtrString("warning"),
+			ApplianceVersion: openapi.PtrString("6.2.1-12345-release"),
+			Cpu:              openapi.PtrFloat32(78.1),
+			Memory:           openapi.PtrFloat32(60),
+		},
+	}
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: `cpu > 50`, want: []string{"gateway"}},
+		{input: `cpu <= 12.5`, want: []string{"controller"}},
+		{input: `status != "healthy" || memory >= 50`, want: []string{"gateway"}},
+		{input: `version =~ "^6\\.2"`, want: []string{"controller", "gateway"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expression, err := ParseFilterExpression(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := applyApplianceStatsFilterExpression(stats, expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, 0, len(got))
+			for _, s := range got {
+				names = append(names, s.GetName())
+			}
+			if len(names) != len(tt.want) {
+				t.Fatalf("got %v, want %v", names, tt.want)
+			}
+			for i := range names {
+				if names[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", names, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterAppliancesWithExpression(t *testing.T) {
+	appliances := []openapi.Appliance{
+		{
+			Name:     "controller",
+			Id:       openapi.PtrString("one"),
+			SiteName: openapi.PtrString("Stockholm"),
+			Controller: &openapi.ApplianceAllOfController{
+				Enabled: openapi.PtrBool(true),
+			},
+		},
+		{
+			Name:     "gateway sthlm",
+			Id:       openapi.PtrString("two"),
+			SiteName: openapi.PtrString("Stockholm"),
+			Gateway: &openapi.ApplianceAllOfGateway{
+				Enabled: openapi.PtrBool(true),
+			},
+		},
+		{
+			Name:     "gateway london",
+			Id:       openapi.PtrString("three"),
+			SiteName: openapi.PtrString("London"),
+			Gateway: &openapi.ApplianceAllOfGateway{
+				Enabled: openapi.PtrBool(true),
+			},
+		},
+	}
+	filter := map[string]map[string]string{
+		"include": {"function": "gateway"},
+		"exclude": {},
+		util.FilterExpressionKey: {
+			util.FilterExpressionKey: `site != "London"`,
+		},
+	}
+	include, exclude, err := FilterAppliances(appliances, filter, []string{"name"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(include) != 1 || include[0].GetId() != "two" {
+		t.Errorf("expected only 'gateway sthlm' to be included, got %v", include)
+	}
+	if len(exclude) != 2 {
+		t.Errorf("expected 2 excluded appliances, got %d", len(exclude))
+	}
+
+	filter[util.FilterExpressionKey][util.FilterExpressionKey] = `site = "London"`
+	if _, _, err := FilterAppliances(appliances, filter, nil, false); err == nil {
+		t.Error("expected an error for an invalid expression")
+	}
+}
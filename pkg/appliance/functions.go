@@ -332,6 +332,14 @@ var DefaultCommandFilter = map[string]map[string]string{
 }
 
 func FilterAppliances(appliances []openapi.Appliance, filter map[string]map[string]string, orderBy []string, descending bool) ([]openapi.Appliance, []openapi.Appliance, error) {
+	return FilterAppliancesWithStats(appliances, nil, filter, orderBy, descending)
+}
+
+// FilterAppliancesWithStats works like FilterAppliances, but can also filter and order the appliances on the live stats,
+// such as '--include cpu=>80', '--include version=>=6.2;<6.4' or '--order-by sessions'. Appliances that are missing
+// from the stats never match a stats keyword. Use NeedsApplianceStats to check if the stats are needed.
+func FilterAppliancesWithStats(appliances []openapi.Appliance, stats []openapi.ApplianceWithStatus, filter map[string]map[string]string, orderBy []string, descending bool) ([]openapi.Appliance, []openapi.Appliance, error) {
+	statsByID := applianceStatsByID(stats)
 	include := make([]openapi.Appliance, len(appliances))
 	copy(include, appliances)
 	var errs *multierror.Error
@@ -345,17 +353,28 @@ func FilterAppliances(appliances []openapi.Appliance, filter map[string]map[stri
 
 	// apply normal filter
 	if len(filter["include"]) > 0 {
-		include, err = applyApplianceFilter(include, filter["include"])
+		include, err = applyApplianceFilter(include, statsByID, filter["include"])
 		if err != nil {
 			errs = multierror.Append(errs, err)
 		}
 	}
+
+	// apply the '--filter' expression, which is combined with '--include' using AND
+	expression, err := filterExpressionFromFilter(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if expression != nil {
+		if include, err = applyApplianceFilterExpression(include, statsByID, expression); err != nil {
+			return nil, nil, err
+		}
+	}
 	for _, i := range include {
 		delete(notInclude, i.GetId())
 	}
 
 	// apply exclusion filter
-	exclude, err := applyApplianceFilter(include, filter["exclude"])
+	exclude, err := applyApplianceFilter(include, statsByID, filter["exclude"])
 	if err != nil {
 		errs = multierror.Append(errs, err)
 	}
@@ -373,11 +392,11 @@ func FilterAppliances(appliances []openapi.Appliance, filter map[string]map[stri
 	}
 
 	// Sort appliances
-	include, err = orderAppliances(include, orderBy, descending)
+	include, err = orderAppliancesWithStats(include, statsByID, orderBy, descending)
 	if err != nil {
 		errs = multierror.Append(errs, err)
 	}
-	exclude, err = orderAppliances(exclude, orderBy, descending)
+	exclude, err = orderAppliancesWithStats(exclude, statsByID, orderBy, descending)
 	if err != nil {
 		errs = multierror.Append(errs, err)
 	}
@@ -417,7 +436,9 @@ func AppendUniqueApplianceStats(stats []openapi.ApplianceWithStatus, stat openap
 	return filtered
 }
 
-func applyApplianceFilter(appliances []openapi.Appliance, filter map[string]string) ([]openapi.Appliance, error) {
+// applyApplianceFilter returns the appliances that match any of the keywords in filter. stats is only used by the
+// stats keywords, and is nil when the stats haven't been fetched.
+func applyApplianceFilter(appliances []openapi.Appliance, stats map[string]openapi.ApplianceWithStatus, filter map[string]string) ([]openapi.Appliance, error) {
 	var filteredAppliances []openapi.Appliance
 	var warnings []string
 
@@ -454,7 +475,40 @@ func applyApplianceFilter(appliances []openapi.Appliance, filter map[string]stri
 						filteredAppliances = AppendUniqueAppliance(filteredAppliances, a)
 					}
 				}
+			case "cpu", "memory", "mem", "disk", "sessions":
+				stat, ok, err := applianceStatsFor(stats, a, k)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+				value, _ := applianceStatsNumber(stat, k)
+				match, err := matchNumericFilter(value, s)
+				if err != nil {
+					return nil, err
+				}
+				if match {
+					filteredAppliances = AppendUniqueAppliance(filteredAppliances, a)
+				}
 			case "version":
+				if isVersionRange(s) {
+					stat, ok, err := applianceStatsFor(stats, a, k)
+					if err != nil {
+						return nil, err
+					}
+					if !ok {
+						continue
+					}
+					match, err := matchVersionRange(stat.GetApplianceVersion(), s)
+					if err != nil {
+						return nil, err
+					}
+					if match {
+						filteredAppliances = AppendUniqueAppliance(filteredAppliances, a)
+					}
+					continue
+				}
 				vList := strings.Split(s, FilterDelimiter)
 				for _, v := range vList {
 					regex, err := regexp.Compile(v)
@@ -524,6 +578,12 @@ func applyApplianceFilter(appliances []openapi.Appliance, filter map[string]stri
 }
 
 func orderAppliances(appliances []openapi.Appliance, orderBy []string, descending bool) ([]openapi.Appliance, error) {
+	return orderAppliancesWithStats(appliances, nil, orderBy, descending)
+}
+
+// orderAppliancesWithStats orders the appliances like orderAppliances, and can also order on the stats keywords.
+// Appliances that are missing from the stats are ordered first.
+func orderAppliancesWithStats(appliances []openapi.Appliance, stats map[string]openapi.ApplianceWithStatus, orderBy []string, descending bool) ([]openapi.Appliance, error) {
 	var errs *multierror.Error
 	// reverse loop the slice to prioritize the ordering. First entered has priority
 	for i := len(orderBy) - 1; i >= 0; i-- {
@@ -542,6 +602,35 @@ func orderAppliances(appliances []openapi.Appliance, orderBy []string, descendin
 			sort.SliceStable(appliances, func(i, j int) bool {
 				return appliances[i].GetActivated() && appliances[i].GetActivated() != appliances[j].GetActivated()
 			})
+		case "cpu", "memory", "mem", "disk", "sessions":
+			if stats == nil {
+				errs = multierror.Append(errs, fmt.Errorf("keyword not sortable without the appliance stats: %s", orderBy[i]))
+				continue
+			}
+			keyword := strings.ToLower(orderBy[i])
+			sort.SliceStable(appliances, func(i, j int) bool {
+				si, iok := stats[appliances[i].GetId()]
+				sj, jok := stats[appliances[j].GetId()]
+				if !iok || !jok {
+					return !iok && jok
+				}
+				vi, _ := applianceStatsNumber(si, keyword)
+				vj, _ := applianceStatsNumber(sj, keyword)
+				return vi < vj
+			})
+		case "version":
+			if stats == nil {
+				errs = multierror.Append(errs, fmt.Errorf("keyword not sortable without the appliance stats: %s", orderBy[i]))
+				continue
+			}
+			sort.SliceStable(appliances, func(i, j int) bool {
+				si, iok := stats[appliances[i].GetId()]
+				sj, jok := stats[appliances[j].GetId()]
+				if !iok || !jok {
+					return !iok && jok
+				}
+				return compareApplianceVersions(si.GetApplianceVersion(), sj.GetApplianceVersion()) < 0
+			})
 		default:
 			errs = multierror.Append(errs, fmt.Errorf("keyword not sortable: %s", orderBy[i]))
 		}
@@ -571,6 +660,17 @@ func FilterApplianceStats(stats []openapi.ApplianceWithStatus, filter map[string
 			errs = multierror.Append(errs, err)
 		}
 	}
+
+	// apply the '--filter' expression, which is combined with '--include' using AND
+	expression, err := filterExpressionFromFilter(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if expression != nil {
+		if include, err = applyApplianceStatsFilterExpression(include, expression); err != nil {
+			return nil, nil, err
+		}
+	}
 	for _, i := range include {
 		delete(notInclude, i.GetId())
 	}
@@ -665,6 +765,36 @@ func applyApplianceStatsFilter(stats []openapi.ApplianceWithStatus, filter map[s
 						filtered = append(filtered, s)
 					}
 				}
+			case "cpu", "memory", "mem", "disk", "sessions":
+				value, _ := applianceStatsNumber(s, k)
+				match, err := matchNumericFilter(value, v)
+				if err != nil {
+					return nil, err
+				}
+				if match {
+					filtered = AppendUniqueApplianceStats(filtered, s)
+				}
+			case "version":
+				if isVersionRange(v) {
+					match, err := matchVersionRange(s.GetApplianceVersion(), v)
+					if err != nil {
+						return nil, err
+					}
+					if match {
+						filtered = AppendUniqueApplianceStats(filtered, s)
+					}
+					continue
+				}
+				versionList := strings.Split(v, FilterDelimiter)
+				for _, ver := range versionList {
+					regex, err := regexp.Compile(ver)
+					if err != nil {
+						return nil, err
+					}
+					if regex.MatchString(s.GetApplianceVersion()) {
+						filtered = AppendUniqueApplianceStats(filtered, s)
+					}
+				}
 			default:
 				msg := fmt.Sprintf("'%s' is not a filterable keyword. Ignoring", k)
 				if !util.InSlice(msg, warnings) {
@@ -696,7 +826,9 @@ func orderApplianceStats(stats []openapi.ApplianceWithStatus, orderBy []string,
 		case "cpu":
 			sort.SliceStable(stats, func(i, j int) bool { return stats[i].GetCpu() < stats[j].GetCpu() })
 		case "version":
-			sort.SliceStable(stats, func(i, j int) bool { return stats[i].GetVersion() < stats[j].GetVersion() })
+			sort.SliceStable(stats, func(i, j int) bool {
+				return compareApplianceVersions(stats[i].GetApplianceVersion(), stats[j].GetApplianceVersion()) < 0
+			})
 		case "net-in":
 			sort.SliceStable(stats, func(i, j int) bool {
 				inet := stats[i].GetDetails().Network.GetDetails()[stats[i].GetDetails().Network.GetBusiestNic()]
@@ -8,14 +8,48 @@ import (
 	"io"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/hashicorp/go-version"
 )
 
 var (
 	versionRegex = regexp.MustCompile(`(([\d]+[.]?){1,3})[-|+]?([\d|\w]+)?[-|+]?([\d|\w]+)?(\.img\.zip)?$`)
+
+	networkSpeedUnits = map[string]float64{
+		"bps":  1,
+		"kbps": 1e3,
+		"mbps": 1e6,
+		"gbps": 1e9,
+		"tbps": 1e12,
+	}
 )
 
+// ExactFloat64 converts the float32 values from the API without adding digits,
+// so that 0.8 becomes 0.8 and not 0.800000011920929
+func ExactFloat64(v float32) float64 {
+	f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+	return f
+}
+
+// ParseNetworkSpeed parses the rx and tx speeds reported in the appliance stats, for example '0.26 Kbps',
+// and returns the speed in bits per second
+func ParseNetworkSpeed(input string) (float64, error) {
+	fields := strings.Fields(input)
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("failed to parse network speed '%s'", input)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse network speed '%s': %w", input, err)
+	}
+	multiplier, ok := networkSpeedUnits[strings.ToLower(fields[1])]
+	if !ok {
+		return 0, fmt.Errorf("failed to parse network speed '%s': unknown unit %s", input, fields[1])
+	}
+	return value * multiplier, nil
+}
+
 // ParseVersionString tries to determine appliance version based on the input filename,
 // It assumes the file is has the standard naming convention of
 // appgate-5.4.4-26245-release.img.zip
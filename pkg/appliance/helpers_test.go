@@ -1,6 +1,7 @@
 package appliance
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/hashicorp/go-version"
@@ -143,3 +144,38 @@ func TestParseVersionString(t *testing.T) {
 		})
 	}
 }
+
+func TestParseNetworkSpeed(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{input: "0.26 Kbps", want: 260},
+		{input: "12 bps", want: 12},
+		{input: "1.5 Mbps", want: 1.5e6},
+		{input: "2 Gbps", want: 2e9},
+		{input: "", wantErr: true},
+		{input: "fast Mbps", wantErr: true},
+		{input: "12 MB/s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseNetworkSpeed(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseNetworkSpeed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseNetworkSpeed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExactFloat64(t *testing.T) {
+	for _, v := range []float32{0.8, 48.8, 75, 0} {
+		if got, want := ExactFloat64(v), fmt.Sprintf("%g", v); fmt.Sprintf("%g", got) != want {
+			t.Errorf("ExactFloat64(%g) = %g", v, got)
+		}
+	}
+}
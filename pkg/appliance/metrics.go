@@ -0,0 +1,191 @@
+package appliance
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MetricSample is a single sample from the Prometheus metrics of an appliance
+type MetricSample struct {
+	Appliance string            `json:"appliance,omitempty"`
+	Name      string            `json:"name"`
+	Type      string            `json:"type,omitempty"`
+	Labels    map[string]string `json:"labels"`
+	Value     MetricValue       `json:"value"`
+	Timestamp *int64            `json:"timestamp,omitempty"`
+}
+
+// MetricValue is the value of a sample. NaN and infinite values, which are valid
+// in the Prometheus text format but not in JSON, are encoded as strings.
+type MetricValue float64
+
+func (v MetricValue) MarshalJSON() ([]byte, error) {
+	f := float64(v)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return json.Marshal(v.String())
+	}
+	return json.Marshal(f)
+}
+
+func (v MetricValue) String() string {
+	f := float64(v)
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// LabelString returns the labels of the sample sorted by name, for example 'code="200",method="get"'
+func (s MetricSample) LabelString() string {
+	keys := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, s.Labels[k]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// metricTypeSuffixes are added to the family name by the samples of histograms and summaries
+var metricTypeSuffixes = []string{"_bucket", "_sum", "_count"}
+
+// ParseMetrics parses metrics in the Prometheus text exposition format, as returned by
+// the appliance metrics API, into samples
+func ParseMetrics(text string) ([]MetricSample, error) {
+	types := map[string]string{}
+	samples := make([]MetricSample, 0)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				types[fields[2]] = fields[3]
+			}
+			continue
+		}
+		sample, err := parseMetricLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		sample.Type = metricType(types, sample.Name)
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+func metricType(types map[string]string, name string) string {
+	if t, ok := types[name]; ok {
+		return t
+	}
+	for _, suffix := range metricTypeSuffixes {
+		if t, ok := types[strings.TrimSuffix(name, suffix)]; ok && strings.HasSuffix(name, suffix) {
+			return t
+		}
+	}
+	return ""
+}
+
+// parseMetricLine parses a sample line, for example 'http_requests_total{method="post",code="200"} 1027 1395066363000'
+func parseMetricLine(line string) (MetricSample, error) {
+	sample := MetricSample{Labels: map[string]string{}}
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return sample, fmt.Errorf("invalid sample %q", line)
+	}
+	sample.Name = line[:end]
+	rest := line[end:]
+	if strings.HasPrefix(rest, "{") {
+		labels, remaining, err := parseMetricLabels(rest[1:])
+		if err != nil {
+			return sample, fmt.Errorf("invalid labels in %q: %w", line, err)
+		}
+		sample.Labels = labels
+		rest = remaining
+	}
+	fields := strings.Fields(rest)
+	if len(fields) < 1 || len(fields) > 2 {
+		return sample, fmt.Errorf("invalid sample %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, fmt.Errorf("invalid value in %q: %w", line, err)
+	}
+	sample.Value = MetricValue(value)
+	if len(fields) == 2 {
+		timestamp, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return sample, fmt.Errorf("invalid timestamp in %q: %w", line, err)
+		}
+		sample.Timestamp = &timestamp
+	}
+	return sample, nil
+}
+
+// parseMetricLabels parses the labels after the opening brace, and returns the text after the closing brace
+func parseMetricLabels(s string) (map[string]string, string, error) {
+	labels := map[string]string{}
+	i := 0
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return nil, "", fmt.Errorf("missing '}'")
+		}
+		if s[i] == '}' {
+			return labels, s[i+1:], nil
+		}
+		eq := strings.IndexByte(s[i:], '=')
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("expected label name at %q", s[i:])
+		}
+		name := strings.TrimSpace(s[i : i+eq])
+		i += eq + 1
+		if i >= len(s) || s[i] != '"' {
+			return nil, "", fmt.Errorf("expected quoted value for label %s", name)
+		}
+		i++
+		var value strings.Builder
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, "", fmt.Errorf("unterminated value for label %s", name)
+		}
+		i++
+		labels[name] = value.String()
+	}
+}
@@ -0,0 +1,86 @@
+package appliance
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParseMetrics(t *testing.T) {
+	text := `
+# HELP audit_event Total audit event count
+# TYPE audit_event counter
+audit_event{collective_id="8dda5969-e9de-4d0f-b4e8-38954c7c0507", appliance_id="ecb7d7ed-ec6a-4d39-4271-6b8b785520d3", type="appliance_status_changed"} 3.0
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="+Inf"} 12
+request_duration_seconds_sum 1.5e-3 1395066363000
+vpn_total_sessions 5
+escaped{path="C:\\dir",msg="say \"hi\"\n"} NaN
+`
+	samples, err := ParseMetrics(text)
+	if err != nil {
+		t.Fatalf("ParseMetrics() unexpected error %s", err)
+	}
+	if len(samples) != 5 {
+		t.Fatalf("expected 5 samples, got %d: %+v", len(samples), samples)
+	}
+
+	audit := samples[0]
+	if audit.Name != "audit_event" || audit.Type != "counter" || audit.Value != 3 {
+		t.Errorf("unexpected sample %+v", audit)
+	}
+	if audit.Labels["type"] != "appliance_status_changed" || len(audit.Labels) != 3 {
+		t.Errorf("unexpected labels %v", audit.Labels)
+	}
+
+	bucket, sum := samples[1], samples[2]
+	if bucket.Type != "histogram" || bucket.Labels["le"] != "+Inf" {
+		t.Errorf("unexpected sample %+v", bucket)
+	}
+	if sum.Type != "histogram" || sum.Value != 1.5e-3 || sum.Timestamp == nil || *sum.Timestamp != 1395066363000 {
+		t.Errorf("unexpected sample %+v", sum)
+	}
+
+	if untyped := samples[3]; untyped.Type != "" || len(untyped.Labels) != 0 || untyped.Value != 5 {
+		t.Errorf("unexpected sample %+v", untyped)
+	}
+
+	escaped := samples[4]
+	if escaped.Labels["path"] != `C:\dir` || escaped.Labels["msg"] != "say \"hi\"\n" {
+		t.Errorf("unexpected labels %q", escaped.Labels)
+	}
+	if !math.IsNaN(float64(escaped.Value)) {
+		t.Errorf("expected NaN, got %v", escaped.Value)
+	}
+	b, err := json.Marshal(escaped.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"NaN"` {
+		t.Errorf("got JSON %s, want \"NaN\"", b)
+	}
+}
+
+func TestParseMetricsInvalid(t *testing.T) {
+	tests := []string{
+		`metric{label="value" 1`,
+		`metric{label=value} 1`,
+		`metric{label="value} 1`,
+		`metric one`,
+		`metric 1 2 3`,
+		`metric 1 now`,
+	}
+	for _, text := range tests {
+		if _, err := ParseMetrics(text); err == nil {
+			t.Errorf("ParseMetrics(%q) expected error", text)
+		}
+	}
+}
+
+func TestMetricSampleLabelString(t *testing.T) {
+	s := MetricSample{Labels: map[string]string{"type": "a\"b", "appliance_id": "1"}}
+	want := `appliance_id="1",type="a\"b"`
+	if got := s.LabelString(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,177 @@
+package appliance
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/hashicorp/go-version"
+)
+
+var (
+	// numericFilterRegex matches the values of numeric keywords, for example '>80', '<=10' or '90%'
+	numericFilterRegex = regexp.MustCompile(`^\s*(==|!=|>=|<=|>|<|=)?\s*(-?[0-9]*\.?[0-9]+)\s*%?\s*$`)
+	// versionConstraintRegex matches each constraint of a version range, for example '>=6.2' and '<6.4' in '>=6.2;<6.4'
+	versionConstraintRegex = regexp.MustCompile(`(==|!=|>=|<=|~>|>|<|=)?\s*v?[0-9][0-9A-Za-z.+-]*`)
+)
+
+// statsOrderKeywords are the '--order-by' keywords that require the appliance stats when ordering appliances
+var statsOrderKeywords = []string{"cpu", "memory", "mem", "disk", "sessions", "version"}
+
+// statsFilterKeywords are the '--include' and '--exclude' keywords that require the appliance stats when filtering
+// appliances. 'version' only requires the stats when it's a range, since the plain value matches the config version.
+var statsFilterKeywords = []string{"cpu", "memory", "mem", "disk", "sessions"}
+
+// NeedsApplianceStats returns true if the filter or order uses the live stats of the appliances, such as the CPU
+// usage or the number of sessions, and FilterAppliancesWithStats must be used instead of FilterAppliances.
+func NeedsApplianceStats(filter map[string]map[string]string, orderBy []string) bool {
+	for _, o := range orderBy {
+		for _, k := range statsOrderKeywords {
+			if strings.EqualFold(o, k) {
+				return true
+			}
+		}
+	}
+	for _, mode := range []string{"include", "exclude"} {
+		for k, v := range filter[mode] {
+			for _, s := range statsFilterKeywords {
+				if k == s {
+					return true
+				}
+			}
+			if k == "version" && isVersionRange(v) {
+				return true
+			}
+		}
+	}
+	if expression, err := filterExpressionFromFilter(filter); err == nil && expression != nil {
+		return expression.usesStats()
+	}
+	return false
+}
+
+// applianceStatsNumber returns the numeric stats value for a keyword
+func applianceStatsNumber(s openapi.ApplianceWithStatus, keyword string) (float64, bool) {
+	switch keyword {
+	case "cpu":
+		return ExactFloat64(s.GetCpu()), true
+	case "memory", "mem":
+		return ExactFloat64(s.GetMemory()), true
+	case "disk":
+		return ExactFloat64(s.GetDisk()), true
+	case "sessions":
+		return float64(s.GetNumberOfSessions()), true
+	}
+	return 0, false
+}
+
+// matchNumericFilter returns true if value matches any of the comparisons in filter, separated by FilterDelimiter.
+// A comparison without an operator is an equality check.
+func matchNumericFilter(value float64, filter string) (bool, error) {
+	for _, f := range strings.Split(filter, FilterDelimiter) {
+		m := numericFilterRegex.FindStringSubmatch(f)
+		if m == nil {
+			return false, fmt.Errorf("invalid numeric filter '%s', expected a number with an optional comparison such as '>80' or '<=10'", f)
+		}
+		n, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid numeric filter '%s': %w", f, err)
+		}
+		var match bool
+		switch m[1] {
+		case ">":
+			match = value > n
+		case ">=":
+			match = value >= n
+		case "<":
+			match = value < n
+		case "<=":
+			match = value <= n
+		case "!=":
+			match = value != n
+		default:
+			match = value == n
+		}
+		if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isVersionRange returns true if the filter value is a version range like '>=6.2;<6.4', instead of a regular expression
+func isVersionRange(filter string) bool {
+	f := strings.TrimSpace(filter)
+	return len(f) > 0 && strings.ContainsAny(f[:1], "<>=!~")
+}
+
+// parseVersionRange parses a version range where the constraints are separated by semicolons, for example '>=6.2;<6.4'.
+// Commas and spaces are accepted as well, but a comma can't be used in '--include' and '--exclude', where it separates
+// the key-value pairs.
+func parseVersionRange(filter string) (version.Constraints, error) {
+	constraints := versionConstraintRegex.FindAllString(filter, -1)
+	if len(constraints) == 0 {
+		return nil, fmt.Errorf("invalid version range '%s'", filter)
+	}
+	c, err := version.NewConstraint(strings.Join(constraints, ","))
+	if err != nil {
+		return nil, fmt.Errorf("invalid version range '%s': %w", filter, err)
+	}
+	return c, nil
+}
+
+// matchVersionRange returns true if the appliance version matches any of the version ranges in filter, separated by
+// FilterDelimiter. An appliance without a known version never matches.
+func matchVersionRange(applianceVersion string, filter string) (bool, error) {
+	v, err := ParseVersionString(applianceVersion)
+	for _, f := range strings.Split(filter, FilterDelimiter) {
+		c, cErr := parseVersionRange(f)
+		if cErr != nil {
+			return false, cErr
+		}
+		if err == nil && c.Check(v) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// compareApplianceVersions compares two appliance versions as semver. Versions that can't be parsed are compared as
+// strings and ordered before the valid versions.
+func compareApplianceVersions(a, b string) int {
+	va, errA := ParseVersionString(a)
+	vb, errB := ParseVersionString(b)
+	switch {
+	case errA == nil && errB == nil:
+		return va.Compare(vb)
+	case errA != nil && errB == nil:
+		return -1
+	case errA == nil && errB != nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// applianceStatsByID returns the stats keyed by the appliance ID
+func applianceStatsByID(stats []openapi.ApplianceWithStatus) map[string]openapi.ApplianceWithStatus {
+	if stats == nil {
+		return nil
+	}
+	result := make(map[string]openapi.ApplianceWithStatus, len(stats))
+	for _, s := range stats {
+		result[s.GetId()] = s
+	}
+	return result
+}
+
+// applianceStatsFor returns the stats of the appliance, for filtering or ordering on a stats keyword. ok is false if
+// the appliance is missing from the stats, and the error is set if the stats haven't been fetched at all.
+func applianceStatsFor(stats map[string]openapi.ApplianceWithStatus, appliance openapi.Appliance, keyword string) (openapi.ApplianceWithStatus, bool, error) {
+	if stats == nil {
+		return openapi.ApplianceWithStatus{}, false, fmt.Errorf("'%s' requires the appliance stats, which are not available for this command", keyword)
+	}
+	s, ok := stats[appliance.GetId()]
+	return s, ok, nil
+}
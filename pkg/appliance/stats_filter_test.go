@@ -0,0 +1,259 @@
+package appliance
+
+import (
+	"testing"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/util"
+)
+
+func TestMatchNumericFilter(t *testing.T) {
+	tests := []struct {
+		value   float64
+		filter  string
+		want    bool
+		wantErr bool
+	}{
+		{value: 85, filter: ">80", want: true},
+		{value: 80, filter: ">80", want: false},
+		{value: 80, filter: ">=80%", want: true},
+		{value: 9, filter: "<10", want: true},
+		{value: 10, filter: "<=10", want: true},
+		{value: 0.8, filter: "0.8", want: true},
+		{value: 50, filter: "<10&>90", want: false},
+		{value: 95, filter: "<10&>90", want: true},
+		{value: 3, filter: "!=3", want: false},
+		{value: 3, filter: "high", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			got, err := matchNumericFilter(tt.value, tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("matchNumericFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("matchNumericFilter(%v, %q) = %v, want %v", tt.value, tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchVersionRange(t *testing.T) {
+	tests := []struct {
+		version string
+		filter  string
+		want    bool
+		wantErr bool
+	}{
+		{version: "6.2.1-12345-release", filter: ">=6.2;<6.4", want: true},
+		{version: "6.4.0-23456-release", filter: ">=6.2;<6.4", want: false},
+		{version: "6.2.1-12345-release", filter: ">=6.2,<6.4", want: true},
+		{version: "6.3.0-23456-beta", filter: ">= 6.2 < 6.4", want: true},
+		{version: "6.1.5-12345-release", filter: ">=6.2&<6.0", want: false},
+		{version: "5.5.9-12345-release", filter: ">=6.2&<6.0", want: true},
+		{version: "", filter: ">=6.2", want: false},
+		{version: "6.2.1", filter: ">=", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.filter, func(t *testing.T) {
+			got, err := matchVersionRange(tt.version, tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("matchVersionRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("matchVersionRange(%q, %q) = %v, want %v", tt.version, tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsApplianceStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  map[string]map[string]string
+		orderBy []string
+		want    bool
+	}{
+		{
+			name:    "static keywords",
+			filter:  map[string]map[string]string{"include": {"function": "gateway", "version": "16"}},
+			orderBy: []string{"name"},
+			want:    false,
+		},
+		{
+			name:   "sessions keyword",
+			filter: map[string]map[string]string{"exclude": {"sessions": ">10"}},
+			want:   true,
+		},
+		{
+			name:   "version range",
+			filter: map[string]map[string]string{"include": {"version": ">=6.2;<6.4"}},
+			want:   true,
+		},
+		{
+			name:    "order by disk",
+			orderBy: []string{"name", "Disk"},
+			want:    true,
+		},
+		{
+			name:   "expression with stats field",
+			filter: map[string]map[string]string{util.FilterExpressionKey: {util.FilterExpressionKey: `function == "gateway" && cpu > 50`}},
+			want:   true,
+		},
+		{
+			name:   "expression with version range",
+			filter: map[string]map[string]string{util.FilterExpressionKey: {util.FilterExpressionKey: `version(">=6.2;<6.4")`}},
+			want:   true,
+		},
+		{
+			name:   "expression without stats field",
+			filter: map[string]map[string]string{util.FilterExpressionKey: {util.FilterExpressionKey: `site == "Stockholm"`}},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NeedsApplianceStats(tt.filter, tt.orderBy); got != tt.want {
+				t.Errorf("NeedsApplianceStats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterAppliancesWithStats(t *testing.T) {
+	appliances := []openapi.Appliance{
+		{
+			Name: "controller",
+			Id:   openapi.PtrString("one"),
+			Controller: &openapi.ApplianceAllOfController{
+				Enabled: openapi.PtrBool(true),
+			},
+		},
+		{
+			Name: "gateway busy",
+			Id:   openapi.PtrString("two"),
+			Gateway: &openapi.ApplianceAllOfGateway{
+				Enabled: openapi.PtrBool(true),
+			},
+		},
+		{
+			Name: "gateway idle",
+			Id:   openapi.PtrString("three"),
+			Gateway: &openapi.ApplianceAllOfGateway{
+				Enabled: openapi.PtrBool(true),
+			},
+		},
+		{
+			Name: "gateway offline",
+			Id:   openapi.PtrString("four"),
+			Gateway: &openapi.ApplianceAllOfGateway{
+				Enabled: openapi.PtrBool(true),
+			},
+		},
+	}
+	stats := []openapi.ApplianceWithStatus{
+		{
+			Name:             "controller",
+			Id:               openapi.PtrString("one"),
+			ApplianceVersion: openapi.PtrString("6.3.1-34567-release"),
+			Disk:             openapi.PtrFloat32(85),
+		},
+		{
+			Name:             "gateway busy",
+			Id:               openapi.PtrString("two"),
+			ApplianceVersion: openapi.PtrString("6.2.10-23456-release"),
+			Disk:             openapi.PtrFloat32(40),
+			Cpu:              openapi.PtrFloat32(90),
+		},
+		{
+			Name:             "gateway idle",
+			Id:               openapi.PtrString("three"),
+			ApplianceVersion: openapi.PtrString("6.2.9-12345-release"),
+			Disk:             openapi.PtrFloat32(12.5),
+			Cpu:              openapi.PtrFloat32(2),
+		},
+	}
+	names := func(appliances []openapi.Appliance) []string {
+		result := make([]string, 0, len(appliances))
+		for _, a := range appliances {
+			result = append(result, a.GetName())
+		}
+		return result
+	}
+	tests := []struct {
+		name       string
+		filter     map[string]map[string]string
+		orderBy    []string
+		descending bool
+		want       []string
+	}{
+		{
+			name:    "disk above 80%",
+			filter:  map[string]map[string]string{"include": {"disk": ">80%"}, "exclude": {}},
+			orderBy: []string{"name"},
+			want:    []string{"controller"},
+		},
+		{
+			name: "gateways with low cpu",
+			filter: map[string]map[string]string{
+				"include":                {"function": "gateway"},
+				"exclude":                {},
+				util.FilterExpressionKey: {util.FilterExpressionKey: `cpu < 10`},
+			},
+			orderBy: []string{"name"},
+			want:    []string{"gateway idle"},
+		},
+		{
+			name:    "version range",
+			filter:  map[string]map[string]string{"include": {"version": ">=6.2.10;<6.4"}, "exclude": {}},
+			orderBy: []string{"name"},
+			want:    []string{"controller", "gateway busy"},
+		},
+		{
+			name: "version expression",
+			filter: map[string]map[string]string{
+				"include":                {},
+				"exclude":                {},
+				util.FilterExpressionKey: {util.FilterExpressionKey: `version < 6.2.10 || version("~>6.3.0")`},
+			},
+			orderBy: []string{"name"},
+			want:    []string{"controller", "gateway idle"},
+		},
+		{
+			name:       "order by version",
+			filter:     map[string]map[string]string{"include": {}, "exclude": {"name": "offline"}},
+			orderBy:    []string{"version"},
+			descending: true,
+			want:       []string{"controller", "gateway busy", "gateway idle"},
+		},
+		{
+			name:    "order by disk",
+			filter:  map[string]map[string]string{"include": {}, "exclude": {}},
+			orderBy: []string{"disk"},
+			want:    []string{"gateway offline", "gateway idle", "gateway busy", "controller"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := make([]openapi.Appliance, len(appliances))
+			copy(a, appliances)
+			got, _, err := FilterAppliancesWithStats(a, stats, tt.filter, tt.orderBy, tt.descending)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotNames := names(got)
+			if len(gotNames) != len(tt.want) {
+				t.Fatalf("got %v, want %v", gotNames, tt.want)
+			}
+			for i := range gotNames {
+				if gotNames[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", gotNames, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, _, err := FilterAppliances(appliances, map[string]map[string]string{"include": {"sessions": "<10"}}, nil, false); err == nil {
+		t.Error("expected an error when filtering on stats without the stats")
+	}
+}
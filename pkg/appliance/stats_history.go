@@ -0,0 +1,121 @@
+package appliance
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/filesystem"
+	log "github.com/sirupsen/logrus"
+)
+
+// StatsSnapshot is the stats of the appliances at one point in time, as recorded by 'sdpctl appliance stats record'.
+// The snapshots are stored as one JSON document per line, so that recording only ever appends to the file.
+type StatsSnapshot struct {
+	Time       time.Time     `json:"time"`
+	Appliances []StatsSample `json:"appliances"`
+}
+
+// StatsSample is the subset of ApplianceWithStatus that is useful to follow over time
+type StatsSample struct {
+	Name       string  `json:"name"`
+	ID         string  `json:"id"`
+	Status     string  `json:"status"`
+	Functions  string  `json:"functions,omitempty"`
+	Version    string  `json:"version,omitempty"`
+	CPU        float64 `json:"cpu"`
+	Memory     float64 `json:"memory"`
+	Disk       float64 `json:"disk"`
+	Sessions   int64   `json:"sessions"`
+	NetworkOut float64 `json:"network_out_bps"`
+	NetworkIn  float64 `json:"network_in_bps"`
+}
+
+// DefaultStatsHistoryFile is where the stats are recorded if no other file is given
+func DefaultStatsHistoryFile() string {
+	return filepath.Join(filesystem.DataDir(), "stats.jsonl")
+}
+
+// NewStatsSnapshot returns a snapshot of the stats taken at t
+func NewStatsSnapshot(t time.Time, stats []openapi.ApplianceWithStatus) StatsSnapshot {
+	snapshot := StatsSnapshot{
+		Time:       t.UTC(),
+		Appliances: make([]StatsSample, 0, len(stats)),
+	}
+	for _, s := range stats {
+		sample := StatsSample{
+			Name:      s.GetName(),
+			ID:        s.GetId(),
+			Status:    s.GetStatus(),
+			Functions: ApplianceActiveFunctions(s),
+			Version:   s.GetApplianceVersion(),
+			CPU:       ExactFloat64(s.GetCpu()),
+			Memory:    ExactFloat64(s.GetMemory()),
+			Disk:      ExactFloat64(s.GetDisk()),
+			Sessions:  int64(s.GetNumberOfSessions()),
+		}
+		if n := s.GetDetails().Network; n != nil {
+			if nic, ok := n.GetDetails()[n.GetBusiestNic()]; ok {
+				sample.NetworkOut, _ = ParseNetworkSpeed(nic.GetTxSpeed())
+				sample.NetworkIn, _ = ParseNetworkSpeed(nic.GetRxSpeed())
+			}
+		}
+		snapshot.Appliances = append(snapshot.Appliances, sample)
+	}
+	return snapshot
+}
+
+// AppendStatsSnapshot appends the snapshot to the history file, creating the file if it does not exist
+func AppendStatsSnapshot(path string, snapshot StatsSnapshot) error {
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadStatsSnapshots returns the snapshots in the history file taken at or after since, in the order they were recorded.
+// Lines that can't be parsed, such as a line cut short by a full disk, are skipped.
+func ReadStatsSnapshots(path string, since time.Time) ([]StatsSnapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errors.New("no stats have been recorded in " + path + ", run 'sdpctl appliance stats record' first")
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	snapshots := make([]StatsSnapshot, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var snapshot StatsSnapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			log.WithError(err).WithField("line", line).Warn("skipping invalid stats snapshot")
+			continue
+		}
+		if snapshot.Time.Before(since) {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, scanner.Err()
+}
@@ -0,0 +1,63 @@
+package appliance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+)
+
+func TestStatsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "stats.jsonl")
+	start := time.Date(2024, time.March, 10, 14, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		stats := []openapi.ApplianceWithStatus{
+			{
+				Name:             "gateway",
+				Id:               openapi.PtrString("ee639d70-e075-4f01-596b-930d5f24f569"),
+				Status:           openapi.PtrString("healthy"),
+				ApplianceVersion: openapi.PtrString("6.2.1"),
+				Cpu:              openapi.PtrFloat32(float32(10 * (i + 1))),
+				Memory:           openapi.PtrFloat32(48.8),
+				Disk:             openapi.PtrFloat32(4.9),
+			},
+		}
+		if err := AppendStatsSnapshot(path, NewStatsSnapshot(start.Add(time.Duration(i)*time.Hour), stats)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a line cut short while writing is skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2024-03-10T17:00:00Z","applian`)
+	f.Close()
+
+	snapshots, err := ReadStatsSnapshots(path, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ReadStatsSnapshots() unexpected error %s", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("expected 2 snapshots since %s, got %d", start.Add(time.Hour), len(snapshots))
+	}
+	got := snapshots[1].Appliances[0]
+	want := StatsSample{
+		Name:    "gateway",
+		ID:      "ee639d70-e075-4f01-596b-930d5f24f569",
+		Status:  "healthy",
+		Version: "6.2.1",
+		CPU:     30,
+		Memory:  48.8,
+		Disk:    4.9,
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := ReadStatsSnapshots(filepath.Join(t.TempDir(), "missing.jsonl"), start); err == nil {
+		t.Error("expected error for a missing history file")
+	}
+}
@@ -80,7 +80,7 @@ func NewUpgradePlan(
 	}
 	plan.primary = primary
 
-	finalApplianceList, filtered, err := FilterAppliances(appliances, filter, orderBy, descending)
+	finalApplianceList, filtered, err := FilterAppliancesWithStats(appliances, stats.GetData(), filter, orderBy, descending)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/google/shlex"
+)
+
+var contextKeyCredentialProcess = authContext("credentialProcess")
+
+// ErrCredentialProcess is returned when the credential_process command fails or prints invalid credentials
+var ErrCredentialProcess = errors.New("credential_process failed")
+
+// processCredentials is the JSON printed by the credential_process command
+//
+//	{"username": "admin", "password": "secret", "otp": "123456"}
+type processCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	OTP      string `json:"otp,omitempty"`
+}
+
+// credentialProcess returns the credential_process command from the context set by Signin, if any
+func credentialProcess(ctx context.Context) string {
+	if v, ok := ctx.Value(contextKeyCredentialProcess).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// runCredentialProcess runs the credential_process command and parses the credentials from its output. The command
+// is split into arguments like a shell would, but isn't run by a shell. The command can prompt on the terminal, for
+// example to unlock a password manager, since only stdout is read by sdpctl.
+func runCredentialProcess(ctx context.Context, command string) (*processCredentials, error) {
+	args, err := shlex.Split(command)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCredentialProcess, err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%w: empty command", ErrCredentialProcess)
+	}
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrCredentialProcess, args[0], err)
+	}
+	var credentials processCredentials
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), &credentials); err != nil {
+		return nil, fmt.Errorf("%w: %s printed invalid JSON: %s", ErrCredentialProcess, args[0], err)
+	}
+	credentials.Username = strings.TrimSpace(credentials.Username)
+	credentials.OTP = strings.TrimSpace(credentials.OTP)
+	return &credentials, nil
+}
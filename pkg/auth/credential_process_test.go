@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/httpmock"
+	"github.com/appgate/sdpctl/pkg/keyring"
+	zkeyring "github.com/zalando/go-keyring"
+)
+
+// credentialProcessStub writes a script that prints output and exits with code, and returns the command to run it
+func credentialProcessStub(t *testing.T, output string, code int) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the credential_process stub is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "credentials.sh")
+	script := fmt.Sprintf("#!/bin/sh\ncat <<'EOF'\n%s\nEOF\nexit %d\n", output, code)
+	if err := os.WriteFile(path, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("%q --profile production", path)
+}
+
+func TestRunCredentialProcess(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		code    int
+		want    processCredentials
+		wantErr bool
+	}{
+		{
+			name:   "username and password",
+			output: `{"username": "bob", "password": "alice"}`,
+			want:   processCredentials{Username: "bob", Password: "alice"},
+		},
+		{
+			name:   "with otp",
+			output: `{"username": "bob", "password": "alice", "otp": "123456"}`,
+			want:   processCredentials{Username: "bob", Password: "alice", OTP: "123456"},
+		},
+		{
+			name:    "invalid json",
+			output:  "bob alice",
+			wantErr: true,
+		},
+		{
+			name:    "command fails",
+			output:  `{"username": "bob", "password": "alice"}`,
+			code:    1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := credentialProcessStub(t, tt.output, tt.code)
+			got, err := runCredentialProcess(context.Background(), command)
+			if tt.wantErr {
+				if !errors.Is(err, ErrCredentialProcess) {
+					t.Fatalf("runCredentialProcess() error = %v, want %v", err, ErrCredentialProcess)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("runCredentialProcess() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSigninCredentialProcess(t *testing.T) {
+	zkeyring.MockInit()
+	t.Setenv("SDPCTL_CONFIG_DIR", t.TempDir())
+	registry := httpmock.NewRegistry(t)
+	registry.Register("/admin/authentication", func(rw http.ResponseWriter, r *http.Request) {
+		if v, ok := r.Header["Accept"]; ok && v[0] == "application/vnd.appgate.peer-v5+json" {
+			authenticationResponse.Responder(rw, r)
+			return
+		}
+		var login openapi.LoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&login); err != nil || login.GetUsername() != "bob" || login.GetPassword() != "alice" {
+			unauthorizedResponse.Responder(rw, r)
+			return
+		}
+		authenticationResponse.Responder(rw, r)
+	})
+	for _, v := range []httpmock.Stub{identityProviderNames, authorizationGET} {
+		registry.Register(v.URL, v.Responder)
+	}
+	defer registry.Teardown()
+	registry.Serve()
+
+	f := &factory.Factory{
+		Config: &configuration.Config{
+			URL:               fmt.Sprintf("http://appgate.test:%d", registry.Port),
+			CredentialProcess: credentialProcessStub(t, `{"username": "bob", "password": "alice"}`, 0),
+		},
+		StdErr: os.Stderr,
+	}
+	f.DisablePrompt(true)
+	f.APIClient = func(c *configuration.Config) (*openapi.APIClient, error) {
+		return registry.Client, nil
+	}
+	t.Cleanup(func() {
+		if err := f.Config.ClearCredentials(); err != nil {
+			t.Errorf("Failed to clear mock credentials after test %s", err)
+		}
+	})
+	if err := Signin(f); err != nil {
+		t.Fatalf("Signin() error = %v", err)
+	}
+	prefix, err := f.Config.KeyringPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the credentials from the credential_process are not copied to the keyring
+	if v, err := keyring.GetPassword(prefix); err == nil && len(v) > 0 {
+		t.Errorf("expected no password in the keyring, got %q", v)
+	}
+}
+
+func TestAuthAndOTPCredentialProcess(t *testing.T) {
+	registry := httpmock.NewRegistry(t)
+	for _, v := range []httpmock.Stub{authorizationGETNeedOTP, authorizationInitAlreadySeeded, authorizationOtpAccepted} {
+		registry.Register(v.URL, v.Responder)
+	}
+	defer registry.Teardown()
+	registry.Serve()
+
+	command := credentialProcessStub(t, `{"username": "bob", "password": "alice", "otp": "123456"}`, 0)
+	ctx := context.WithValue(context.Background(), contextKeyCanPrompt, false)
+	ctx = context.WithValue(ctx, contextKeyCredentialProcess, command)
+	ctx = context.WithValue(ctx, openapi.ContextAccessToken, "token")
+	got, err := authAndOTP(ctx, NewAuth(registry.Client), openapi.PtrString("alice"), io.Discard)
+	if err != nil {
+		t.Fatalf("authAndOTP() error = %v", err)
+	}
+	if *got != "newToken" {
+		t.Errorf("authAndOTP() = %s, want newToken", *got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/configuration"
 	"github.com/appgate/sdpctl/pkg/factory"
 	"github.com/appgate/sdpctl/pkg/prompt"
 )
@@ -19,16 +20,34 @@ func NewLocal(f *factory.Factory) *Local {
 }
 
 func (l Local) signin(ctx context.Context, loginOpts openapi.LoginRequest, provider openapi.IdentityProvidersNamesGet200ResponseDataInner) (*signInResponse, error) {
-	cfg := l.Factory.Config
-	canPrompt := l.Factory.CanPrompt()
-	client, err := l.Factory.APIClient(cfg)
+	return credentialsSignin(ctx, l.Factory, loginOpts)
+}
+
+// credentialsSignin authenticates with username and password, from the credential_process, the keyring,
+// environment variables or prompt
+func credentialsSignin(ctx context.Context, f *factory.Factory, loginOpts openapi.LoginRequest) (*signInResponse, error) {
+	cfg := f.Config
+	canPrompt := f.CanPrompt()
+	client, err := f.APIClient(cfg)
 	if err != nil {
 		return nil, err
 	}
 	authenticator := NewAuth(client)
-	credentials, err := cfg.LoadCredentials()
-	if err != nil {
-		return nil, err
+	var credentials *configuration.Credentials
+	if len(cfg.CredentialProcess) > 0 {
+		c, err := runCredentialProcess(ctx, cfg.CredentialProcess)
+		if err != nil {
+			return nil, err
+		}
+		credentials = &configuration.Credentials{
+			Username: c.Username,
+			Password: c.Password,
+		}
+	} else {
+		credentials, err = cfg.LoadCredentials()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if len(credentials.Username) <= 0 && canPrompt {
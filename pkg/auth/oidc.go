@@ -185,7 +185,7 @@ func (o OpenIDConnect) refreshToken(clientID, tokenURL, refreshToken string) (*o
 
 var ErrPlatformNotSupported = errors.New("Provider with OpenID Connect is not supported on your system")
 
-func (o OpenIDConnect) signin(ctx context.Context, loginOpts openapi.LoginRequest, provider openapi.IdentityProvidersNamesGet200ResponseDataInner) (*signInResponse, error) {
+func (o *OpenIDConnect) signin(ctx context.Context, loginOpts openapi.LoginRequest, provider openapi.IdentityProvidersNamesGet200ResponseDataInner) (*signInResponse, error) {
 	authenticator := NewAuth(o.Client)
 	prefix, err := o.Factory.Config.KeyringPrefix()
 	if err != nil {
@@ -214,6 +214,10 @@ func (o OpenIDConnect) signin(ctx context.Context, loginOpts openapi.LoginReques
 		}
 	}
 
+	if o.Factory.Config.OIDCDeviceCode {
+		return o.deviceCodeSignin(ctx, authenticator, prefix, loginOpts, provider)
+	}
+
 	mux := http.NewServeMux()
 	o.httpServer = &http.Server{
 		Addr:    oidcPort,
@@ -255,30 +259,57 @@ func (o OpenIDConnect) signin(ctx context.Context, loginOpts openapi.LoginReques
 	}()
 	browser.Stderr = io.Discard
 	if err := browser.OpenURL(oidcRedirectAddress); err != nil {
-		return nil, ErrPlatformNotSupported
+		// without a browser, for example over SSH, the sign in can be completed on another device instead
+		o.Close()
+		fmt.Fprintln(o.Factory.StdErr, "Could not open a browser, signing in with a device code instead")
+		return o.deviceCodeSignin(ctx, authenticator, prefix, loginOpts, provider)
 	}
 	select {
 	case err := <-o.errors:
 		return nil, err
 	case t := <-o.response:
+		return o.authenticate(ctx, authenticator, prefix, loginOpts, t)
+	}
+}
 
-		loginOpts.IdToken = &t.IDToken
-		loginOpts.AccessToken = &t.AccessToken
+// deviceCodeSignin signs in with the OAuth device authorization grant, where the user opens the verification URL
+// on any device with a browser and enters the user code, while sdpctl polls the token endpoint.
+func (o *OpenIDConnect) deviceCodeSignin(ctx context.Context, authenticator *Auth, prefix string, loginOpts openapi.LoginRequest, provider openapi.IdentityProvidersNamesGet200ResponseDataInner) (*signInResponse, error) {
+	client := &http.Client{}
+	endpoint, err := deviceAuthorizationURL(ctx, client, provider.GetAuthUrl())
+	if err != nil {
+		return nil, err
+	}
+	device, err := requestDeviceCode(ctx, client, endpoint, provider.GetClientId(), provider.GetScope())
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(o.Factory.StdErr, "To sign in, open %s in a browser and enter the code %s\n", device.verificationURI(), device.UserCode)
+	t, err := pollDeviceToken(ctx, client, provider.GetTokenUrl(), provider.GetClientId(), device)
+	if err != nil {
+		return nil, err
+	}
+	return o.authenticate(ctx, authenticator, prefix, loginOpts, *t)
+}
 
-		if err := keyring.SetRefreshToken(prefix, t.RefreshToken); err != nil {
-			return nil, ErrPlatformNotSupported
-		}
+// authenticate signs in to the Controller with the tokens from the OpenID Connect provider and stores the refresh token
+func (o *OpenIDConnect) authenticate(ctx context.Context, authenticator *Auth, prefix string, loginOpts openapi.LoginRequest, t oIDCResponse) (*signInResponse, error) {
+	loginOpts.IdToken = &t.IDToken
+	loginOpts.AccessToken = &t.AccessToken
 
-		loginResponse, _, err := authenticator.Authentication(ctx, loginOpts)
-		if err != nil {
-			return nil, err
-		}
+	if err := keyring.SetRefreshToken(prefix, t.RefreshToken); err != nil {
+		return nil, ErrPlatformNotSupported
+	}
 
-		response := &signInResponse{
-			Token:     loginResponse.GetToken(),
-			Expires:   time.Now().Local().Add(time.Second * time.Duration(t.ExpiresIn)),
-			LoginOpts: &loginOpts,
-		}
-		return response, nil
+	loginResponse, _, err := authenticator.Authentication(ctx, loginOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &signInResponse{
+		Token:     loginResponse.GetToken(),
+		Expires:   time.Now().Local().Add(time.Second * time.Duration(t.ExpiresIn)),
+		LoginOpts: &loginOpts,
 	}
+	return response, nil
 }
@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// deviceCodeGrantType is the grant type used to poll the token endpoint
+// https://datatracker.ietf.org/doc/html/rfc8628#section-3.4
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// deviceCodeIntervalUnit is the unit of the polling interval from the device authorization response, it's only
+// changed in tests.
+var deviceCodeIntervalUnit = time.Second
+
+var (
+	ErrDeviceCodeNotSupported = errors.New("could not find a device authorization endpoint for the OpenID Connect provider")
+	ErrDeviceCodeExpired      = errors.New("the device code expired before the sign in was completed")
+	ErrDeviceCodeDenied       = errors.New("the sign in was denied")
+)
+
+// deviceAuthorizationResponse is the Device Authorization Response body.
+// https://datatracker.ietf.org/doc/html/rfc8628#section-3.2
+type deviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+	// VerificationURL is used instead of verification_uri by some providers, such as Azure AD
+	VerificationURL string `json:"verification_url,omitempty"`
+}
+
+func (d deviceAuthorizationResponse) verificationURI() string {
+	if len(d.VerificationURIComplete) > 0 {
+		return d.VerificationURIComplete
+	}
+	if len(d.VerificationURI) > 0 {
+		return d.VerificationURI
+	}
+	return d.VerificationURL
+}
+
+// deviceAuthorizationURL finds the device authorization endpoint in the OpenID Connect discovery document.
+func deviceAuthorizationURL(ctx context.Context, client *http.Client, authURL string) (string, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", err
+	}
+	// Azure AD has the device authorization endpoint next to the authorization endpoint
+	if strings.HasSuffix(u.Path, "/oauth2/v2.0/authorize") {
+		u.Path = strings.TrimSuffix(u.Path, "authorize") + "devicecode"
+		u.RawQuery = ""
+		return u.String(), nil
+	}
+	endpoint, err := discoverEndpoint(ctx, client, u, func(c openIDConfiguration) string {
+		return c.DeviceAuthorizationEndpoint
+	})
+	if err != nil {
+		return "", ErrDeviceCodeNotSupported
+	}
+	return endpoint, nil
+}
+
+// openIDConfiguration is the part of the OpenID Connect discovery document used by sdpctl
+// https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type openIDConfiguration struct {
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	RevocationEndpoint          string `json:"revocation_endpoint"`
+}
+
+var errEndpointNotFound = errors.New("endpoint not found in the OpenID Connect discovery document")
+
+// discoverEndpoint returns the endpoint selected from the OpenID Connect discovery document.
+// The identity provider only has the authorization URL, so the issuer is found by removing a path segment
+// at the time, for example https://idp.example.com/realms/acme/protocol/openid-connect/auth is tried as
+// https://idp.example.com/realms/acme/protocol/openid-connect, https://idp.example.com/realms/acme/protocol and so on.
+func discoverEndpoint(ctx context.Context, client *http.Client, authURL *url.URL, endpoint func(openIDConfiguration) string) (string, error) {
+	for p := path.Dir(authURL.Path); ; p = path.Dir(p) {
+		issuer := *authURL
+		issuer.RawQuery = ""
+		issuer.Path = strings.TrimSuffix(p, "/") + "/.well-known/openid-configuration"
+		if c, err := discoverOpenIDConfiguration(ctx, client, issuer.String()); err == nil && len(endpoint(*c)) > 0 {
+			return endpoint(*c), nil
+		}
+		if p == "/" || p == "." {
+			break
+		}
+	}
+	return "", errEndpointNotFound
+}
+
+func discoverOpenIDConfiguration(ctx context.Context, client *http.Client, discoveryURL string) (*openIDConfiguration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", discoveryURL, resp.Status)
+	}
+	var discovery openIDConfiguration
+	if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+		return nil, err
+	}
+	return &discovery, nil
+}
+
+// postForm sends the form to the OpenID Connect provider and decodes the JSON response body into v.
+// The error response of the provider is returned as oIDCError.
+func postForm(ctx context.Context, client *http.Client, endpoint string, form url.Values, v interface{}) (*oIDCError, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResponse oIDCError
+		if err := json.Unmarshal(body, &errResponse); err != nil {
+			return nil, fmt.Errorf("%w %s", ErrInvalidRequest, resp.Status)
+		}
+		return &errResponse, nil
+	}
+	return nil, json.Unmarshal(body, v)
+}
+
+// requestDeviceCode starts the device authorization grant
+// https://datatracker.ietf.org/doc/html/rfc8628#section-3.1
+func requestDeviceCode(ctx context.Context, client *http.Client, endpoint, clientID, scope string) (*deviceAuthorizationResponse, error) {
+	form := url.Values{}
+	form.Add("client_id", clientID)
+	if len(scope) > 0 {
+		form.Add("scope", scope)
+	}
+	var data deviceAuthorizationResponse
+	errResponse, err := postForm(ctx, client, endpoint, form, &data)
+	if err != nil {
+		return nil, err
+	}
+	if errResponse != nil {
+		return nil, fmt.Errorf("%w %s %s", ErrInvalidRequest, errResponse.Error, errResponse.ErrorDescription)
+	}
+	if len(data.DeviceCode) == 0 || len(data.verificationURI()) == 0 {
+		return nil, fmt.Errorf("%w missing device_code or verification_uri", ErrInvalidRequest)
+	}
+	return &data, nil
+}
+
+// pollDeviceToken polls the token endpoint until the user has completed the sign in on the verification URI,
+// the device code has expired or the sign in was denied.
+// https://datatracker.ietf.org/doc/html/rfc8628#section-3.5
+func pollDeviceToken(ctx context.Context, client *http.Client, tokenURL, clientID string, device *deviceAuthorizationResponse) (*oIDCResponse, error) {
+	interval := device.Interval
+	if interval <= 0 {
+		interval = 5
+	}
+	expiresIn := device.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = 300
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(expiresIn)*deviceCodeIntervalUnit)
+	defer cancel()
+
+	form := url.Values{}
+	form.Add("client_id", clientID)
+	form.Add("grant_type", deviceCodeGrantType)
+	form.Add("device_code", device.DeviceCode)
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, ErrDeviceCodeExpired
+			}
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(interval) * deviceCodeIntervalUnit):
+		}
+
+		var data oIDCResponse
+		errResponse, err := postForm(ctx, client, tokenURL, form, &data)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			return nil, err
+		}
+		if errResponse == nil {
+			return &data, nil
+		}
+		switch errResponse.Error {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5
+		case "access_denied":
+			return nil, ErrDeviceCodeDenied
+		case "expired_token":
+			return nil, ErrDeviceCodeExpired
+		default:
+			return nil, fmt.Errorf("%w %s %s", ErrInvalidRequest, errResponse.Error, errResponse.ErrorDescription)
+		}
+	}
+}
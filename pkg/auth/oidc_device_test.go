@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeviceAuthorizationURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/realms/acme/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+            "issuer": "https://idp.example.com/realms/acme",
+            "device_authorization_endpoint": "https://idp.example.com/realms/acme/protocol/openid-connect/auth/device"
+        }`)
+	})
+	mux.HandleFunc("/no-device/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"issuer": "https://idp.example.com/no-device"}`)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	tests := []struct {
+		name    string
+		authURL string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "discovery",
+			authURL: svr.URL + "/realms/acme/protocol/openid-connect/auth?prompt=login",
+			want:    "https://idp.example.com/realms/acme/protocol/openid-connect/auth/device",
+		},
+		{
+			name:    "azure",
+			authURL: "https://login.microsoftonline.com/tenant/oauth2/v2.0/authorize",
+			want:    "https://login.microsoftonline.com/tenant/oauth2/v2.0/devicecode",
+		},
+		{
+			name:    "no device authorization endpoint",
+			authURL: svr.URL + "/no-device/oauth2/authorize",
+			wantErr: ErrDeviceCodeNotSupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := deviceAuthorizationURL(context.Background(), svr.Client(), tt.authURL)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("deviceAuthorizationURL() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("deviceAuthorizationURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestDeviceCode(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("client_id") != "abc123" || r.PostForm.Get("scope") != "openid" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_client", "error_description": "unknown client"}`)
+			return
+		}
+		fmt.Fprint(w, `{
+            "device_code": "GmRhmhcxhwAzkoEqiMEg_DnyEysNkuNhszIySk9eS",
+            "user_code": "WDJB-MJHT",
+            "verification_uri": "https://idp.example.com/device",
+            "expires_in": 1800,
+            "interval": 5
+        }`)
+	}))
+	defer svr.Close()
+
+	device, err := requestDeviceCode(context.Background(), svr.Client(), svr.URL, "abc123", "openid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if device.UserCode != "WDJB-MJHT" || device.verificationURI() != "https://idp.example.com/device" {
+		t.Errorf("unexpected device authorization response %+v", device)
+	}
+
+	_, err = requestDeviceCode(context.Background(), svr.Client(), svr.URL, "unknown", "openid")
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected invalid request error, got %v", err)
+	}
+}
+
+func TestPollDeviceToken(t *testing.T) {
+	deviceCodeIntervalUnit = time.Millisecond
+	t.Cleanup(func() {
+		deviceCodeIntervalUnit = time.Second
+	})
+
+	tests := []struct {
+		name      string
+		responses []string
+		expiresIn int
+		wantErr   error
+	}{
+		{
+			name: "pending then token",
+			responses: []string{
+				`{"error": "authorization_pending"}`,
+				`{"error": "slow_down"}`,
+				`{"access_token": "abc123", "id_token": "long-id-token", "refresh_token": "8xLOxBtZp8", "expires_in": 3600}`,
+			},
+			expiresIn: 1000,
+		},
+		{
+			name: "denied",
+			responses: []string{
+				`{"error": "authorization_pending"}`,
+				`{"error": "access_denied"}`,
+			},
+			expiresIn: 1000,
+			wantErr:   ErrDeviceCodeDenied,
+		},
+		{
+			name: "expired",
+			responses: []string{
+				`{"error": "authorization_pending"}`,
+			},
+			expiresIn: 20,
+			wantErr:   ErrDeviceCodeExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != deviceCodeGrantType || r.PostForm.Get("device_code") != "device-code" {
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprint(w, `{"error": "invalid_grant"}`)
+					return
+				}
+				n := int(atomic.AddInt32(&calls, 1)) - 1
+				if n >= len(tt.responses) {
+					n = len(tt.responses) - 1
+				}
+				if n < len(tt.responses)-1 || tt.wantErr != nil {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+				fmt.Fprint(w, tt.responses[n])
+			}))
+			defer svr.Close()
+
+			device := &deviceAuthorizationResponse{
+				DeviceCode: "device-code",
+				UserCode:   "WDJB-MJHT",
+				ExpiresIn:  tt.expiresIn,
+				Interval:   1,
+			}
+			got, err := pollDeviceToken(context.Background(), svr.Client(), svr.URL, "abc123", device)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("pollDeviceToken() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.AccessToken != "abc123" || got.IDToken != "long-id-token" {
+				t.Errorf("unexpected token response %+v", got)
+			}
+		})
+	}
+}
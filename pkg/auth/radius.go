@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/prompt"
+)
+
+// radiusChallengeType is the one-time password type used when the RADIUS server responds with an Access-Challenge
+const radiusChallengeType = "RadiusChallenge"
+
+// radiusResponseEnv holds the answers to the RADIUS challenges when no TTY is present, one per line if the RADIUS
+// server sends more than one challenge. A response can't contain a line break, so any other character is allowed.
+const radiusResponseEnv = "SDPCTL_RADIUS_RESPONSE"
+
+var ErrInvalidRadiusResponse = errors.New("RADIUS challenge failed, the response was not accepted")
+
+type Radius struct {
+	Factory *factory.Factory
+}
+
+func NewRadius(f *factory.Factory) *Radius {
+	return &Radius{
+		Factory: f,
+	}
+}
+
+// signin authenticates with username and password like the local provider. If the RADIUS server responds with an
+// Access-Challenge, the challenge is answered after the authentication, see radiusChallengeResponse.
+func (r Radius) signin(ctx context.Context, loginOpts openapi.LoginRequest, provider openapi.IdentityProvidersNamesGet200ResponseDataInner) (*signInResponse, error) {
+	return credentialsSignin(ctx, r.Factory, loginOpts)
+}
+
+// hasRadiusResponseEnv returns true if the RADIUS challenge responses are set in the environment
+func hasRadiusResponseEnv() bool {
+	v, ok := os.LookupEnv(radiusResponseEnv)
+	return ok && len(v) > 0
+}
+
+// radiusResponsesFromEnv returns the RADIUS challenge responses from the environment, in the order they are answered
+func radiusResponsesFromEnv() []string {
+	responses := make([]string, 0)
+	for _, r := range strings.Split(os.Getenv(radiusResponseEnv), "\n") {
+		if r = strings.TrimSuffix(r, "\r"); len(r) > 0 {
+			responses = append(responses, r)
+		}
+	}
+	return responses
+}
+
+// radiusChallengeResponse answers the Access-Challenge of the RADIUS server, relayed by the login API, and returns
+// the new token. The server may chain challenges, for example a PIN followed by a one-time password, so the new
+// token is only returned once it's authorized. In interactive mode, each challenge is prompted for with the message
+// from the RADIUS server and a rejected response can be retried, otherwise the responses are read from
+// SDPCTL_RADIUS_RESPONSE. Rejected responses are reported on stderr.
+func radiusChallengeResponse(ctx context.Context, authenticator *Auth, password *string, otp *openapi.AuthenticationOtpInitializePost200Response, stderr io.Writer) (*string, error) {
+	canPrompt := true
+	if v, ok := ctx.Value(contextKeyCanPrompt).(bool); ok && !v {
+		canPrompt = false
+	}
+	responses := radiusResponsesFromEnv()
+	attempts := 0
+	for {
+		message := otp.GetResponseMessage()
+		if len(message) == 0 {
+			message = "Please enter your RADIUS challenge response:"
+		}
+		var answer string
+		switch {
+		case len(responses) > 0:
+			answer, responses = responses[0], responses[1:]
+		case canPrompt:
+			var err error
+			if answer, err = prompt.PromptPassword(message); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("RADIUS challenge %q requires a response, but a TTY prompt is not allowed, set the responses in %s", message, radiusResponseEnv)
+		}
+
+		newToken, err := authenticator.PushOTP(ctx, answer)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidOneTimePassword) {
+				return nil, err
+			}
+			attempts++
+			if !canPrompt || attempts >= 3 {
+				return nil, ErrInvalidRadiusResponse
+			}
+			fmt.Fprintf(stderr, "[error] %s\n", ErrInvalidRadiusResponse)
+			// the RADIUS server discards the challenge when the response is rejected, so a new challenge is needed
+			if otp, err = authenticator.InitializeOTP(ctx, password); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		ctx = context.WithValue(ctx, openapi.ContextAccessToken, newToken.GetToken())
+		_, err = authenticator.Authorization(ctx)
+		if err == nil {
+			token := newToken.GetToken()
+			return &token, nil
+		}
+		if !errors.Is(err, ErrPreConditionFailed) {
+			return nil, err
+		}
+		if otp, err = authenticator.InitializeOTP(ctx, password); err != nil {
+			return nil, err
+		}
+		if otp.GetType() != radiusChallengeType {
+			return nil, fmt.Errorf("expected another RADIUS challenge, got one-time password type %s", otp.GetType())
+		}
+	}
+}
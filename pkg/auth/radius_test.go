@@ -0,0 +1,346 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	expect "github.com/Netflix/go-expect"
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/configuration"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/httpmock"
+	"github.com/appgate/sdpctl/pkg/prompt"
+	pseudotty "github.com/creack/pty"
+	"github.com/google/go-cmp/cmp"
+	"github.com/hinshun/vt10x"
+	zkeyring "github.com/zalando/go-keyring"
+)
+
+var identityProviderRadius = httpmock.Stub{
+	URL: "/admin/identity-providers/names",
+	Responder: func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusOK)
+			fmt.Fprint(rw, string(`{
+                "data": [
+                    {
+                        "name": "radius",
+                        "displayName": "RADIUS",
+                        "type": "Radius"
+                    }
+                ]
+            }`))
+		}
+	}}
+
+// radiusChallengeStubs returns the login API stubs for a RADIUS server that sends a challenge for each of the
+// answers, in order, and authorizes the token after the last one.
+func radiusChallengeStubs(challenges []string, answers []string) []httpmock.Stub {
+	// the token after answering n challenges
+	token := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return fmt.Sprintf("radiusToken%d", n)
+	}
+	answered := func(r *http.Request) int {
+		for i := range answers {
+			if r.Header.Get("Authorization") == "Bearer "+token(i+1) {
+				return i + 1
+			}
+		}
+		return 0
+	}
+	return []httpmock.Stub{
+		{
+			URL: "/admin/authorization",
+			Responder: func(rw http.ResponseWriter, r *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				if answered(r) == len(answers) {
+					rw.WriteHeader(http.StatusOK)
+					fmt.Fprint(rw, string(`{
+                        "user": {"name": "bob", "needTwoFactorAuth": false, "canAccessAuditLogs": false, "privileges": []},
+                        "token": "authorizedRadiusToken",
+                        "expires": "2022-02-01T15:07:04.451882Z"
+                    }`))
+					return
+				}
+				rw.WriteHeader(http.StatusPreconditionFailed)
+				fmt.Fprint(rw, string(`{
+                    "id": "precondition failed",
+                    "message": "Administrative authorization requires two-factor authentication.",
+                    "otpRequired": true,
+                    "username": "bob"
+                }`))
+			},
+		},
+		{
+			URL: "/admin/authentication/otp/initialize",
+			Responder: func(rw http.ResponseWriter, r *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(http.StatusOK)
+				fmt.Fprintf(rw, `{
+                    "type": "RadiusChallenge",
+                    "inputType": "Numeric",
+                    "responseMessage": %q,
+                    "state": "challenge-state"
+                }`, challenges[answered(r)])
+			},
+		},
+		{
+			URL: "/admin/authentication/otp",
+			Responder: func(rw http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Otp string `json:"otp"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					rw.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				rw.Header().Set("Content-Type", "application/json")
+				n := answered(r)
+				if body.Otp != answers[n] {
+					rw.WriteHeader(http.StatusUnauthorized)
+					fmt.Fprint(rw, string(`{
+                        "id": "unauthorized",
+                        "message": "Invalid one-time password.",
+                        "failureType": "Mfa"
+                    }`))
+					return
+				}
+				rw.WriteHeader(http.StatusOK)
+				fmt.Fprintf(rw, `{
+                    "user": {"name": "bob", "needTwoFactorAuth": false, "canAccessAuditLogs": false, "privileges": []},
+                    "token": %q,
+                    "expires": "2022-02-01T15:07:04.451882Z"
+                }`, token(n+1))
+			},
+		},
+	}
+}
+
+func TestSigninRadius(t *testing.T) {
+	tests := []struct {
+		name                 string
+		askStubs             func(*prompt.PromptStubber)
+		environmentVariables map[string]string
+		disablePrompt        bool
+		wantErr              error
+		wantErrMessage       string
+	}{
+		{
+			name: "prompt credentials and challenge",
+			askStubs: func(s *prompt.PromptStubber) {
+				s.StubPrompt("Username:").AnswerWith("bob")
+				s.StubPrompt("Password:").AnswerWith("alice")
+				s.StubPrompt("Enter the code sent to your phone:").AnswerWith("123456")
+			},
+		},
+		{
+			name: "retry rejected challenge response",
+			askStubs: func(s *prompt.PromptStubber) {
+				s.StubPrompt("Username:").AnswerWith("bob")
+				s.StubPrompt("Password:").AnswerWith("alice")
+				s.StubPrompt("Enter the code sent to your phone:").AnswerWith("000000")
+				s.StubPrompt("Enter the code sent to your phone:").AnswerWith("123456")
+			},
+		},
+		{
+			name: "no prompt with environment variables",
+			environmentVariables: map[string]string{
+				"SDPCTL_USERNAME":        "bob",
+				"SDPCTL_PASSWORD":        "alice",
+				"SDPCTL_RADIUS_RESPONSE": "123456",
+			},
+			disablePrompt: true,
+		},
+		{
+			name: "no prompt with rejected response",
+			environmentVariables: map[string]string{
+				"SDPCTL_USERNAME":        "bob",
+				"SDPCTL_PASSWORD":        "alice",
+				"SDPCTL_RADIUS_RESPONSE": "000000",
+			},
+			disablePrompt: true,
+			wantErr:       ErrInvalidRadiusResponse,
+		},
+		{
+			name: "no prompt without challenge response",
+			environmentVariables: map[string]string{
+				"SDPCTL_USERNAME": "bob",
+				"SDPCTL_PASSWORD": "alice",
+			},
+			disablePrompt: true,
+			wantErr:       ErrCantPromptOTP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zkeyring.MockInit()
+			registry := httpmock.NewRegistry(t)
+			stubs := append([]httpmock.Stub{authenticationResponse, identityProviderRadius}, radiusChallengeStubs(
+				[]string{"Enter the code sent to your phone:"},
+				[]string{"123456"},
+			)...)
+			for _, v := range stubs {
+				registry.Register(v.URL, v.Responder)
+			}
+			for k, v := range tt.environmentVariables {
+				t.Setenv(k, v)
+			}
+			t.Setenv("SDPCTL_CONFIG_DIR", t.TempDir())
+			defer registry.Teardown()
+			registry.Serve()
+			pty, tty, err := pseudotty.Open()
+			if err != nil {
+				t.Fatalf("failed to open pseudotty: %v", err)
+			}
+			term := vt10x.New(vt10x.WithWriter(tty))
+			c, err := expect.NewConsole(expect.WithStdin(pty), expect.WithStdout(term), expect.WithCloser(pty, tty))
+			if err != nil {
+				t.Fatalf("failed to create console: %v", err)
+			}
+			defer c.Close()
+
+			f := &factory.Factory{
+				Config: &configuration.Config{
+					Debug: false,
+					URL:   fmt.Sprintf("http://appgate.test:%d", registry.Port),
+				},
+				IOOutWriter: tty,
+				Stdin:       pty,
+				StdErr:      pty,
+			}
+			if tt.disablePrompt {
+				f.DisablePrompt(true)
+			}
+			t.Cleanup(func() {
+				if err := f.Config.ClearCredentials(); err != nil {
+					t.Errorf("Failed to clear mock credentials after test %s", err)
+				}
+			})
+			f.APIClient = func(c *configuration.Config) (*openapi.APIClient, error) {
+				return registry.Client, nil
+			}
+
+			stubber, teardown := prompt.InitStubbers(t)
+			defer teardown()
+			if tt.askStubs != nil {
+				tt.askStubs(stubber)
+			}
+			err = Signin(f)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Signin() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Signin() error = %v", err)
+			}
+			if got := f.Config.BearerToken; got == nil || *got != "authorizedRadiusToken" {
+				t.Errorf("got bearer token %v, want authorizedRadiusToken", got)
+			}
+		})
+	}
+}
+
+func TestRadiusChainedChallenges(t *testing.T) {
+	tests := []struct {
+		name                 string
+		canPrompt            bool
+		askStubs             func(*prompt.PromptStubber)
+		environmentVariables map[string]string
+		wantErr              string
+		wantStderr           string
+	}{
+		{
+			name:      "prompt each challenge",
+			canPrompt: true,
+			askStubs: func(s *prompt.PromptStubber) {
+				s.StubPrompt("Enter your PIN:").AnswerWith("1234")
+				s.StubPrompt("Enter the code sent to your phone:").AnswerWith("123456")
+			},
+		},
+		{
+			name:      "retry rejected response",
+			canPrompt: true,
+			askStubs: func(s *prompt.PromptStubber) {
+				s.StubPrompt("Enter your PIN:").AnswerWith("0000")
+				s.StubPrompt("Enter your PIN:").AnswerWith("1234")
+				s.StubPrompt("Enter the code sent to your phone:").AnswerWith("123456")
+			},
+			wantStderr: "[error] " + ErrInvalidRadiusResponse.Error() + "\n",
+		},
+		{
+			name: "responses from environment",
+			environmentVariables: map[string]string{
+				"SDPCTL_RADIUS_RESPONSE": "1234\n123456",
+			},
+		},
+		{
+			name: "missing response in environment",
+			environmentVariables: map[string]string{
+				"SDPCTL_RADIUS_RESPONSE": "1234",
+			},
+			wantErr: `RADIUS challenge "Enter the code sent to your phone:" requires a response, but a TTY prompt is not allowed, set the responses in SDPCTL_RADIUS_RESPONSE`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := httpmock.NewRegistry(t)
+			for _, v := range radiusChallengeStubs(
+				[]string{"Enter your PIN:", "Enter the code sent to your phone:"},
+				[]string{"1234", "123456"},
+			) {
+				registry.Register(v.URL, v.Responder)
+			}
+			defer registry.Teardown()
+			registry.Serve()
+			for k, v := range tt.environmentVariables {
+				t.Setenv(k, v)
+			}
+			stubber, teardown := prompt.InitStubbers(t)
+			defer teardown()
+			if tt.askStubs != nil {
+				tt.askStubs(stubber)
+			}
+
+			ctx := context.WithValue(context.Background(), contextKeyCanPrompt, tt.canPrompt)
+			ctx = context.WithValue(ctx, openapi.ContextAccessToken, "")
+			stderr := &bytes.Buffer{}
+			got, err := authAndOTP(ctx, NewAuth(registry.Client), openapi.PtrString("alice"), stderr)
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("got stderr %q, want %q", stderr, tt.wantStderr)
+			}
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("authAndOTP() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("authAndOTP() error = %v", err)
+			}
+			if *got != "radiusToken2" {
+				t.Errorf("authAndOTP() = %s, want radiusToken2", *got)
+			}
+		})
+	}
+}
+
+func TestRadiusResponsesFromEnv(t *testing.T) {
+	t.Setenv("SDPCTL_RADIUS_RESPONSE", "12,34\r\n\n 5678")
+	got := radiusResponsesFromEnv()
+	want := []string{"12,34", " 5678"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("radiusResponsesFromEnv() mismatch (-want +got):\n%s", diff)
+	}
+}
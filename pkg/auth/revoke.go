@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/appgate/sdpctl/pkg/keyring"
+)
+
+// ErrRevocationNotSupported is returned by RevokeRefreshToken when there is no refresh token stored for the profile,
+// or the identity provider has no revocation endpoint.
+var ErrRevocationNotSupported = errors.New("token revocation is not supported by the identity provider")
+
+// RevokeRefreshToken revokes the OpenID Connect refresh token stored in the keyring under prefix with the identity
+// provider, so it can't be used to sign in again. The Controller has no API to revoke the bearer token itself,
+// it's valid until it expires.
+// https://datatracker.ietf.org/doc/html/rfc7009
+func RevokeRefreshToken(ctx context.Context, f *factory.Factory, prefix string) error {
+	cfg := f.Config
+	if cfg.Provider == nil || len(*cfg.Provider) == 0 {
+		return ErrRevocationNotSupported
+	}
+	refreshToken, err := keyring.GetRefreshToken(prefix)
+	if err != nil || len(refreshToken) == 0 {
+		return ErrRevocationNotSupported
+	}
+	client, err := f.APIClient(cfg)
+	if err != nil {
+		return err
+	}
+	acceptCtx := context.WithValue(ctx, openapi.ContextAcceptHeader, fmt.Sprintf("application/vnd.appgate.peer-v%d+json", cfg.Version))
+	providers, err := NewAuth(client).ProviderNames(acceptCtx)
+	if err != nil {
+		return err
+	}
+	for _, provider := range providers {
+		if provider.GetName() != *cfg.Provider || provider.GetType() != OidcProvider {
+			continue
+		}
+		authURL, err := url.Parse(provider.GetAuthUrl())
+		if err != nil {
+			return err
+		}
+		httpClient := &http.Client{}
+		endpoint, err := discoverEndpoint(ctx, httpClient, authURL, func(c openIDConfiguration) string {
+			return c.RevocationEndpoint
+		})
+		if err != nil {
+			return ErrRevocationNotSupported
+		}
+		return revokeToken(ctx, httpClient, endpoint, provider.GetClientId(), refreshToken)
+	}
+	return ErrRevocationNotSupported
+}
+
+// revokeToken sends the token revocation request for a refresh token
+// https://datatracker.ietf.org/doc/html/rfc7009#section-2.1
+func revokeToken(ctx context.Context, client *http.Client, endpoint, clientID, token string) error {
+	form := url.Values{}
+	form.Add("token", token)
+	form.Add("token_type_hint", "refresh_token")
+	form.Add("client_id", clientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var errResponse oIDCError
+		if err := json.NewDecoder(resp.Body).Decode(&errResponse); err == nil && len(errResponse.Error) > 0 {
+			return fmt.Errorf("%w %s %s", ErrInvalidRequest, errResponse.Error, errResponse.ErrorDescription)
+		}
+		return fmt.Errorf("%w %s", ErrInvalidRequest, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/factory"
+	"github.com/pkg/browser"
+)
+
+type Saml struct {
+	Factory    *factory.Factory
+	Client     *openapi.APIClient
+	httpServer *http.Server
+	response   chan string
+	errors     chan error
+}
+
+func NewSaml(f *factory.Factory, client *openapi.APIClient) *Saml {
+	s := &Saml{
+		Factory: f,
+		Client:  client,
+	}
+	s.response = make(chan string)
+	s.errors = make(chan error)
+
+	return s
+}
+
+func (s *Saml) Close() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+var (
+	ErrMissingSamlResponse      = errors.New("missing SAMLResponse in form")
+	ErrSamlPlatformNotSupported = errors.New("Provider with SAML is not supported on your system")
+)
+
+// samlHandler receives the SAML response the identity provider posts to http://localhost:29001/saml,
+// the same port and path as the client uses, so the identity provider can be configured the same way for both.
+type samlHandler struct {
+	Response chan string
+	errors   chan error
+}
+
+func (h samlHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		h.errors <- err
+		return
+	}
+	samlResponse := r.PostForm.Get("SAMLResponse")
+	if len(samlResponse) < 1 {
+		w.WriteHeader(http.StatusInternalServerError)
+		h.errors <- ErrMissingSamlResponse
+		return
+	}
+	h.Response <- samlResponse
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, OpenIDConnectHTML)
+}
+
+func (s *Saml) signin(ctx context.Context, loginOpts openapi.LoginRequest, provider openapi.IdentityProvidersNamesGet200ResponseDataInner) (*signInResponse, error) {
+	authenticator := NewAuth(s.Client)
+	redirectURL := provider.GetRedirectUrl()
+	if len(redirectURL) == 0 {
+		return nil, fmt.Errorf("%s SAML identity provider has no redirect URL", provider.GetName())
+	}
+
+	mux := http.NewServeMux()
+	s.httpServer = &http.Server{
+		Addr:    oidcPort,
+		Handler: mux,
+	}
+	mux.Handle("/", redirectHandler{
+		RedirectURL: redirectURL,
+	})
+	mux.Handle("/saml", samlHandler{
+		Response: s.response,
+		errors:   s.errors,
+	})
+
+	go func() {
+		if err := s.httpServer.ListenAndServe(); err != nil {
+			if !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintf(s.Factory.StdErr, "[error] %s\n", err)
+			}
+		}
+	}()
+	browser.Stderr = io.Discard
+	if err := browser.OpenURL(oidcRedirectAddress); err != nil {
+		return nil, ErrSamlPlatformNotSupported
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case err := <-s.errors:
+		return nil, err
+	case samlResponse := <-s.response:
+		loginOpts.SamlResponse = &samlResponse
+
+		loginResponse, _, err := authenticator.Authentication(ctx, loginOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		response := &signInResponse{
+			Token:     loginResponse.GetToken(),
+			Expires:   loginResponse.GetExpires(),
+			LoginOpts: &loginOpts,
+		}
+		return response, nil
+	}
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+)
+
+func TestSamlHandlerServeHTTP(t *testing.T) {
+	h := samlHandler{
+		Response: make(chan string),
+		errors:   make(chan error),
+	}
+	form := url.Values{}
+	form.Set("SAMLResponse", "PHNhbWxwOlJlc3BvbnNlPjwvc2FtbHA6UmVzcG9uc2U+")
+	form.Set("RelayState", "client")
+	req, err := http.NewRequest(http.MethodPost, "/saml", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response := make(chan string)
+	go func() {
+		select {
+		case r := <-h.Response:
+			response <- r
+		case err := <-h.errors:
+			t.Errorf("expected SAML response, got error %s", err)
+			response <- ""
+		case <-time.After(time.Second * 1):
+			t.Errorf("expected SAML response, got none")
+			response <- ""
+		}
+	}()
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(h.ServeHTTP).ServeHTTP(rr, req)
+
+	if got := <-response; got != "PHNhbWxwOlJlc3BvbnNlPjwvc2FtbHA6UmVzcG9uc2U+" {
+		t.Errorf("got SAML response %q", got)
+	}
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+}
+
+func TestSamlHandlerServeHTTPMissingSamlResponse(t *testing.T) {
+	h := samlHandler{
+		Response: make(chan string),
+		errors:   make(chan error),
+	}
+	req, err := http.NewRequest(http.MethodPost, "/saml", strings.NewReader("RelayState=client"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	errs := make(chan error)
+	go func() {
+		select {
+		case err := <-h.errors:
+			errs <- err
+		case <-time.After(time.Second * 1):
+			errs <- nil
+		}
+	}()
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(h.ServeHTTP).ServeHTTP(rr, req)
+
+	if err := <-errs; err != ErrMissingSamlResponse {
+		t.Fatalf("Expected %s got %v", ErrMissingSamlResponse, err)
+	}
+	if status := rr.Code; status != http.StatusInternalServerError {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInternalServerError)
+	}
+}
+
+func TestSamlSigninMissingRedirectURL(t *testing.T) {
+	s := NewSaml(nil, nil)
+	defer s.Close()
+	provider := openapi.IdentityProvidersNamesGet200ResponseDataInner{}
+	provider.SetName("SAML Admin")
+	provider.SetType(SamlProvider)
+	_, err := s.signin(context.Background(), openapi.LoginRequest{}, provider)
+	if err == nil || err.Error() != "SAML Admin SAML identity provider has no redirect URL" {
+		t.Fatalf("expected missing redirect URL error, got %v", err)
+	}
+}
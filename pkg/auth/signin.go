@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -91,8 +92,9 @@ type authContext string
 var contextKeyCanPrompt = authContext("canPrompt")
 
 // Signin support interactive signin if a valid TTY is present, otherwise it requires environment variables to authenticate,
-// this is only supported by 'local' auth provider
-// If OTP is required, a prompt will appear and await user input
+// this is only supported by 'local' and 'radius' auth providers
+// The credentials and the OTP can also be printed by the credential_process command of the profile, instead of prompting
+// If OTP is required, a prompt will appear and await user input, RADIUS challenges can also be answered with SDPCTL_RADIUS_RESPONSE
 // Signin is done in several steps
 // - Compute correct peer api version to use, based on login response body, which gives us a range of supported peer api to use
 // - If there are more than 1 auth provider supported, prompt user to select (requires TTY | error shown if no TTY)
@@ -120,6 +122,14 @@ func Signin(f *factory.Factory) error {
 	authenticator := NewAuth(client)
 
 	ctx := context.WithValue(context.Background(), contextKeyCanPrompt, f.CanPrompt())
+	ctx = context.WithValue(ctx, contextKeyCredentialProcess, cfg.CredentialProcess)
+	if cfg.TOTPGenerate {
+		prefix, err := cfg.KeyringPrefix()
+		if err != nil {
+			return err
+		}
+		ctx = context.WithValue(ctx, contextKeyTOTPPrefix, prefix)
+	}
 
 	acceptHeaderFormatString := "application/vnd.appgate.peer-v%d+json"
 
@@ -164,7 +174,7 @@ func Signin(f *factory.Factory) error {
 	promptProvider := len(providers) > 1 && len(loginOpts.ProviderName) == 0
 
 	if !f.CanPrompt() {
-		if !hasRequiredEnv() {
+		if !hasRequiredEnv() && len(cfg.CredentialProcess) == 0 {
 			return ErrSignInNotSupported
 		}
 		if promptProvider {
@@ -186,6 +196,7 @@ func Signin(f *factory.Factory) error {
 	var p Authenticate
 	switch selectedProvider.GetType() {
 	case RadiusProvider:
+		p = NewRadius(f)
 	case LocalProvider:
 		p = NewLocal(f)
 	case OidcProvider:
@@ -195,6 +206,10 @@ func Signin(f *factory.Factory) error {
 		oidc := NewOpenIDConnect(f, client)
 		defer oidc.Close()
 		p = oidc
+	case SamlProvider:
+		saml := NewSaml(f, client)
+		defer saml.Close()
+		p = saml
 	default:
 		return fmt.Errorf("%s %s identity provider is not supported", selectedProvider.GetName(), selectedProvider.GetType())
 	}
@@ -204,7 +219,7 @@ func Signin(f *factory.Factory) error {
 	}
 	ctxWithAcceptAndToken := context.WithValue(ctxWithAccept, openapi.ContextAccessToken, response.Token)
 
-	newToken, err := authAndOTP(ctxWithAcceptAndToken, authenticator, response.LoginOpts.Password)
+	newToken, err := authAndOTP(ctxWithAcceptAndToken, authenticator, response.LoginOpts.Password, f.StdErr)
 	if err != nil {
 		return err
 	}
@@ -241,8 +256,9 @@ func Signin(f *factory.Factory) error {
 		fmt.Fprintln(f.StdErr, KeyringWarningMessage)
 	}
 
-	// store username and password if any in keyring, in practice only applicable on local provider
-	if len(response.LoginOpts.GetUsername()) > 1 && len(response.LoginOpts.GetPassword()) > 1 {
+	// store username and password if any in keyring, in practice only applicable on local and radius providers
+	// credentials from the credential_process are kept in the secret manager only
+	if len(response.LoginOpts.GetUsername()) > 1 && len(response.LoginOpts.GetPassword()) > 1 && len(cfg.CredentialProcess) == 0 {
 		if err := cfg.StoreCredentials(response.LoginOpts.GetUsername(), response.LoginOpts.GetPassword()); err != nil {
 			fmt.Fprintf(f.StdErr, "[warning] %s\n", err)
 			fmt.Fprintln(f.StdErr, KeyringWarningMessage)
@@ -274,18 +290,54 @@ func Signin(f *factory.Factory) error {
 
 var ErrCantPromptOTP = errors.New("authentication requires one-time-password, but a TTY prompt is not allowed, can't continue")
 
-// authAndOTP returns the authorized bearer header value and prompt user for OTP if its required
-func authAndOTP(ctx context.Context, authenticator *Auth, password *string) (*string, error) {
+// authAndOTP returns the authorized bearer header value and prompt user for OTP if its required.
+// Rejected one-time passwords are reported on stderr before the next attempt.
+func authAndOTP(ctx context.Context, authenticator *Auth, password *string, stderr io.Writer) (*string, error) {
 	authToken := ctx.Value(openapi.ContextAccessToken).(string)
 	_, err := authenticator.Authorization(ctx)
 	if errors.Is(err, ErrPreConditionFailed) {
+		canPrompt := true
 		if v, ok := ctx.Value(contextKeyCanPrompt).(bool); ok && !v {
+			canPrompt = false
+		}
+		command := credentialProcess(ctx)
+		totp := totpPrefix(ctx)
+		// without a TTY, only a RADIUS challenge can be answered, using environment variables, or an OTP printed by
+		// the credential_process or generated from the TOTP secret
+		if !canPrompt && !hasRadiusResponseEnv() && len(command) == 0 && len(totp) == 0 {
 			return nil, ErrCantPromptOTP
 		}
 		otp, err := authenticator.InitializeOTP(ctx, password)
 		if err != nil {
 			return nil, err
 		}
+		if otp.GetType() == radiusChallengeType {
+			return radiusChallengeResponse(ctx, authenticator, password, otp, stderr)
+		}
+		if len(totp) > 0 {
+			token, err := totpSignin(ctx, authenticator, totp, otp)
+			if err != nil || token != nil {
+				return token, err
+			}
+		}
+		// a new secret must be scanned by the user, so the credential_process can't have an OTP for it yet
+		if len(command) > 0 && otp.GetType() != "Secret" {
+			credentials, err := runCredentialProcess(ctx, command)
+			if err != nil {
+				return nil, err
+			}
+			if len(credentials.OTP) > 0 {
+				newToken, err := authenticator.PushOTP(ctx, credentials.OTP)
+				if err != nil {
+					return nil, err
+				}
+				t := newToken.GetToken()
+				return &t, nil
+			}
+		}
+		if !canPrompt {
+			return nil, ErrCantPromptOTP
+		}
 		testOTP := func() (*openapi.LoginAuthenticationResponse, error) {
 			answer, err := prompt.PromptPassword("Please enter your one-time password:")
 			if err != nil {
@@ -293,7 +345,7 @@ func authAndOTP(ctx context.Context, authenticator *Auth, password *string) (*st
 			}
 			return authenticator.PushOTP(ctx, answer)
 		}
-		// TODO add support for RadiusChallenge, Push
+		// TODO add support for Push
 		switch otpType := otp.GetType(); otpType {
 		case "Secret":
 			barcodeFile, err := BarcodeHTMLfile(otp.GetBarcode(), otp.GetSecret())
@@ -319,7 +371,7 @@ func authAndOTP(ctx context.Context, authenticator *Auth, password *string) (*st
 						return nil, err
 					}
 					if errors.Is(err, ErrInvalidOneTimePassword) {
-						fmt.Fprintf(os.Stderr, "[error] %s\n", err)
+						fmt.Fprintf(stderr, "[error] %s\n", err)
 						continue
 					}
 				}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -759,7 +760,7 @@ func TestAuthAndOTP(t *testing.T) {
 				tt.askStubs = nil
 			}
 			ctx := context.WithValue(tt.args.ctx, openapi.ContextAccessToken, tt.args.token)
-			got, err := authAndOTP(ctx, authenticator, tt.args.password)
+			got, err := authAndOTP(ctx, authenticator, tt.args.password, io.Discard)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("authAndOTP() error = %v, wantErr %v", err, tt.wantErr)
 				return
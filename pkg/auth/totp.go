@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/keyring"
+	log "github.com/sirupsen/logrus"
+)
+
+// contextKeyTOTPPrefix is the keyring prefix of the TOTP secret, only set if the profile has opted in with totp_generate
+var contextKeyTOTPPrefix = authContext("totpPrefix")
+
+const totpPeriod = 30 * time.Second
+
+// totpRetryStep returns the time step to try once after the code for t was rejected, to tolerate a clock that is one
+// step behind or ahead of the Controller. The closest step is tried, since each rejected code counts towards the
+// Controller lockout for invalid one-time passwords.
+func totpRetryStep(t time.Time) time.Duration {
+	if t.Sub(t.Truncate(totpPeriod)) < totpPeriod/2 {
+		return -totpPeriod
+	}
+	return totpPeriod
+}
+
+// storeTOTPSecret stores the new secret and reads it back, since the keyring may be disabled or unavailable. The
+// Controller only shows the secret once, so the error includes it.
+func storeTOTPSecret(prefix, secret string) error {
+	err := keyring.SetTOTPSecret(prefix, secret)
+	if err == nil {
+		var stored string
+		stored, err = keyring.GetTOTPSecret(prefix)
+		if err == nil && stored != secret {
+			err = errors.New("the keyring did not return the stored secret")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("could not store the new TOTP secret in the keyring: %w\nThe Controller only shows the secret once, set SDPCTL_TOTP_SECRET=%s to sign in with it", err, secret)
+	}
+	return nil
+}
+
+// totpPrefix returns the keyring prefix of the TOTP secret from the context set by Signin, if any
+func totpPrefix(ctx context.Context) string {
+	if v, ok := ctx.Value(contextKeyTOTPPrefix).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// generateTOTP returns the RFC 6238 one-time password at t for the base32 encoded secret, using HMAC-SHA1 with
+// a 30 second period and 6 digits like authenticator apps
+func generateTOTP(secret string, t time.Time) (string, error) {
+	secret = strings.ToUpper(strings.Join(strings.Fields(secret), ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/int64(totpPeriod.Seconds())))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000), nil
+}
+
+// totpSignin answers the one-time password challenge with codes generated from the TOTP secret. A new secret from
+// the Controller is stored in the keyring first, so it's enrolled without scanning the barcode. If it can't be stored,
+// the enrollment is still completed and an error with the secret is returned. Returns nil without an error if there is
+// no secret to generate codes from.
+func totpSignin(ctx context.Context, authenticator *Auth, prefix string, otp *openapi.AuthenticationOtpInitializePost200Response) (*string, error) {
+	secret := otp.GetSecret()
+	var storeErr error
+	if otp.GetType() == "Secret" && len(secret) > 0 {
+		storeErr = storeTOTPSecret(prefix, secret)
+		if storeErr == nil {
+			log.Info("stored new TOTP secret in the keyring")
+		}
+	} else {
+		v, err := keyring.GetTOTPSecret(prefix)
+		if err != nil || len(v) == 0 {
+			log.WithError(err).Info("no TOTP secret in the keyring")
+			return nil, nil
+		}
+		secret = v
+	}
+	now := time.Now()
+	token, err := pushTOTP(ctx, authenticator, secret, now)
+	if errors.Is(err, ErrInvalidOneTimePassword) {
+		skew := totpRetryStep(now)
+		log.WithField("skew", skew).Info("generated one-time password was not accepted, retrying with the closest time step")
+		token, err = pushTOTP(ctx, authenticator, secret, now.Add(skew))
+	}
+	if storeErr != nil {
+		return nil, storeErr
+	}
+	return token, err
+}
+
+func pushTOTP(ctx context.Context, authenticator *Auth, secret string, t time.Time) (*string, error) {
+	code, err := generateTOTP(secret, t)
+	if err != nil {
+		return nil, err
+	}
+	newToken, err := authenticator.PushOTP(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	token := newToken.GetToken()
+	return &token, nil
+}
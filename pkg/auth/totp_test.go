@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/httpmock"
+	"github.com/appgate/sdpctl/pkg/keyring"
+	zkeyring "github.com/zalando/go-keyring"
+)
+
+func TestGenerateTOTP(t *testing.T) {
+	// test vectors from RFC 6238 appendix B for SHA1, truncated to 6 digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.unix), func(t *testing.T) {
+			got, err := generateTOTP(secret, time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("generateTOTP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	// authenticator apps show the secret in lower case groups
+	if got, err := generateTOTP("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", time.Unix(59, 0)); err != nil || got != "287082" {
+		t.Errorf("generateTOTP() = %s, %v, want 287082", got, err)
+	}
+	if _, err := generateTOTP("not base32!", time.Now()); err == nil {
+		t.Error("expected an error for an invalid secret")
+	}
+}
+
+func TestTOTPRetryStep(t *testing.T) {
+	if got := totpRetryStep(time.Unix(61, 0)); got != -totpPeriod {
+		t.Errorf("totpRetryStep() = %s at the start of a step, want the previous step", got)
+	}
+	if got := totpRetryStep(time.Unix(89, 0)); got != totpPeriod {
+		t.Errorf("totpRetryStep() = %s at the end of a step, want the next step", got)
+	}
+}
+
+func TestAuthAndOTPTOTP(t *testing.T) {
+	secret := "6XOEKS6WZASFPA5A"
+	tests := []struct {
+		name       string
+		otpType    string
+		stored     string
+		noKeyring  bool
+		rejected   int
+		wantPushed int
+		wantErr    string
+	}{
+		{
+			name:       "enroll new secret",
+			otpType:    "Secret",
+			wantPushed: 1,
+		},
+		{
+			name:       "enroll new secret without keyring",
+			otpType:    "Secret",
+			noKeyring:  true,
+			wantPushed: 1,
+			wantErr:    "SDPCTL_TOTP_SECRET=" + secret,
+		},
+		{
+			name:       "already seeded",
+			otpType:    "AlreadySeeded",
+			stored:     secret,
+			wantPushed: 1,
+		},
+		{
+			name:       "clock skew",
+			otpType:    "AlreadySeeded",
+			stored:     secret,
+			rejected:   1,
+			wantPushed: 2,
+		},
+		{
+			name:       "both codes rejected",
+			otpType:    "AlreadySeeded",
+			stored:     secret,
+			rejected:   3,
+			wantPushed: 2,
+			wantErr:    ErrInvalidOneTimePassword.Error(),
+		},
+		{
+			name:    "no secret in keyring",
+			otpType: "AlreadySeeded",
+			wantErr: ErrCantPromptOTP.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zkeyring.MockInit()
+			if tt.noKeyring {
+				t.Setenv("SDPCTL_NO_KEYRING", "true")
+			}
+			prefix := "controller.devops"
+			if len(tt.stored) > 0 {
+				if err := keyring.SetTOTPSecret(prefix, tt.stored); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := time.Now()
+			pushed := []string{}
+			registry := httpmock.NewRegistry(t)
+			registry.Register(authorizationGETNeedOTP.URL, authorizationGETNeedOTP.Responder)
+			registry.Register("/admin/authentication/otp/initialize", func(rw http.ResponseWriter, r *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(http.StatusOK)
+				fmt.Fprintf(rw, `{"type": %q, "secret": %q, "barcode": "string"}`, tt.otpType, secret)
+			})
+			registry.Register("/admin/authentication/otp", func(rw http.ResponseWriter, r *http.Request) {
+				var body openapi.AuthenticationOtpPostRequest
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatal(err)
+				}
+				pushed = append(pushed, body.GetOtp())
+				if len(pushed) <= tt.rejected {
+					authorizationOtpDenied.Responder(rw, r)
+					return
+				}
+				authorizationOtpAccepted.Responder(rw, r)
+			})
+			defer registry.Teardown()
+			registry.Serve()
+
+			ctx := context.WithValue(context.Background(), contextKeyCanPrompt, false)
+			ctx = context.WithValue(ctx, contextKeyTOTPPrefix, prefix)
+			ctx = context.WithValue(ctx, openapi.ContextAccessToken, "token")
+			got, err := authAndOTP(ctx, NewAuth(registry.Client), openapi.PtrString("alice"), io.Discard)
+			if len(pushed) != tt.wantPushed {
+				t.Fatalf("expected %d one-time passwords, got %v", tt.wantPushed, pushed)
+			}
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("authAndOTP() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("authAndOTP() error = %v", err)
+			}
+			if *got != "newToken" {
+				t.Errorf("authAndOTP() = %s, want newToken", *got)
+			}
+			first, _ := generateTOTP(secret, before)
+			last, _ := generateTOTP(secret, time.Now())
+			if pushed[0] != first && pushed[0] != last {
+				t.Errorf("got one-time password %s, want %s", pushed[0], first)
+			}
+			stored, err := keyring.GetTOTPSecret(prefix)
+			if err != nil || stored != secret {
+				t.Errorf("expected the TOTP secret in the keyring, got %q %v", stored, err)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ var HideIncludeExcludeFlags = func(command *cobra.Command, strings []string) {
 	// Hide flag for this command
 	command.Flags().MarkHidden("exclude")
 	command.Flags().MarkHidden("include")
+	command.Flags().MarkHidden("filter")
+	command.Flags().MarkHidden("group")
 	// Call parent help func
 	command.Parent().HelpFunc()(command, strings)
 }
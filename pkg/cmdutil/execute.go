@@ -28,6 +28,29 @@ const (
 	ExitConfiguration ExitCode = 99
 )
 
+// Exit codes of monitoring plugins, following the Nagios plugin convention
+const (
+	ExitPluginOK       ExitCode = 0
+	ExitPluginWarning  ExitCode = 1
+	ExitPluginCritical ExitCode = 2
+	ExitPluginUnknown  ExitCode = 3
+)
+
+// PluginOutput is the annotation of monitoring plugin commands, its value is the prefix of the plugin output line.
+// Any error from such a command, including errors before it runs such as a failed sign in, exits with
+// ExitPluginUnknown and an UNKNOWN line, so the monitoring system doesn't report a WARNING or CRITICAL.
+const PluginOutput = "pluginOutput"
+
+// ExitCodeError makes the command exit with Code. The command is expected to have
+// written its own output, so the error is not printed.
+type ExitCodeError struct {
+	Code ExitCode
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", e.Code)
+}
+
 func privligeError(err *api.Error) error {
 	if err.StatusCode == http.StatusForbidden {
 		var result *multierror.Error
@@ -43,6 +66,16 @@ func privligeError(err *api.Error) error {
 func ExecuteCommand(cmd *cobra.Command) ExitCode {
 	cmd, err := cmd.ExecuteC()
 	if err != nil {
+		var exitErr *ExitCodeError
+		if errors.As(err, &exitErr) {
+			return exitErr.Code
+		}
+		if prefix, ok := cmd.Annotations[PluginOutput]; ok {
+			// the plugin output must be a single line
+			message := strings.Join(strings.Fields(err.Error()), " ")
+			fmt.Fprintln(cmd.OutOrStdout(), strings.TrimSpace(fmt.Sprintf("%s UNKNOWN - %s", prefix, message)))
+			return ExitPluginUnknown
+		}
 		var result *multierror.Error
 
 		if we := errors.Unwrap(err); we != nil {
@@ -60,6 +60,15 @@ func TestCommandErrorHandling(t *testing.T) {
 
 `,
 		},
+		{
+			name: "plugin exit code",
+			args: args{
+				cmd: &cobra.Command{RunE: func(cmd *cobra.Command, args []string) error {
+					return &ExitCodeError{Code: ExitPluginCritical}
+				}},
+			},
+			want: ExitPluginCritical,
+		},
 		{
 			name: "context DeadlineExceeded error",
 			args: args{
@@ -246,3 +255,63 @@ func TestCommandErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestPluginCommandErrorHandling(t *testing.T) {
+	tests := []struct {
+		name       string
+		preRunErr  error
+		runErr     error
+		want       ExitCode
+		wantOutput string
+	}{
+		{
+			name:       "sign in error before the command runs",
+			preRunErr:  fmt.Errorf("%w: Controller unreachable", ErrExitAuth),
+			want:       ExitPluginUnknown,
+			wantOutput: "APPLIANCE HEALTH UNKNOWN - no authentication: Controller unreachable\n",
+		},
+		{
+			name:       "multiline error",
+			runErr:     errors.New("invalid thresholds\nwarning is above critical"),
+			want:       ExitPluginUnknown,
+			wantOutput: "APPLIANCE HEALTH UNKNOWN - invalid thresholds warning is above critical\n",
+		},
+		{
+			name:   "plugin exit code",
+			runErr: &ExitCodeError{Code: ExitPluginWarning},
+			want:   ExitPluginWarning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &cobra.Command{
+				Use: "sdpctl",
+				PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+					return tt.preRunErr
+				},
+			}
+			root.AddCommand(&cobra.Command{
+				Use:         "health",
+				Annotations: map[string]string{PluginOutput: "APPLIANCE HEALTH"},
+				RunE: func(cmd *cobra.Command, args []string) error {
+					return tt.runErr
+				},
+			})
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			root.SetArgs([]string{"health"})
+			root.SetOut(stdout)
+			root.SetErr(stderr)
+			root.SilenceErrors = true
+			root.SilenceUsage = true
+			if got := ExecuteCommand(root); got != tt.want {
+				t.Errorf("ExecuteCommand() = %d, want %d", got, tt.want)
+			}
+			if diff := cmp.Diff(tt.wantOutput, stdout.String()); diff != "" {
+				t.Errorf("Diff (-want +got):\n%s", diff)
+			}
+			if stderr.Len() > 0 {
+				t.Errorf("expected no output on stderr, got %s", stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathPart is either literal text or an expression of a jsonpath template
+type jsonPathPart struct {
+	text  string
+	steps []jsonPathStep
+	expr  bool
+}
+
+type jsonPathStepKind int
+
+const (
+	jsonPathField jsonPathStepKind = iota
+	jsonPathIndex
+	jsonPathWildcard
+	jsonPathRecursive
+)
+
+type jsonPathStep struct {
+	kind  jsonPathStepKind
+	name  string
+	index int
+}
+
+// parseJSONPath parses a template in the kubectl jsonpath style, for example 'id={.data[0].id}'. Text outside braces
+// is printed as is, with '\n' and '\t' replaced by newline and tab. The expressions support fields '.name' or
+// ['name'], indexes '[0]' and '[-1]', the wildcards '[*]' and '.*', and recursive descent '..name'.
+func parseJSONPath(tpl string) ([]jsonPathPart, error) {
+	parts := make([]jsonPathPart, 0)
+	for len(tpl) > 0 {
+		start := strings.IndexByte(tpl, '{')
+		if start < 0 {
+			parts = append(parts, jsonPathPart{text: unescapeJSONPathText(tpl)})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, jsonPathPart{text: unescapeJSONPathText(tpl[:start])})
+		}
+		end := strings.IndexByte(tpl[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed expression %q", tpl[start:])
+		}
+		steps, err := parseJSONPathExpression(strings.TrimSpace(tpl[start+1 : start+end]))
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, jsonPathPart{steps: steps, expr: true})
+		tpl = tpl[start+end+1:]
+	}
+	return parts, nil
+}
+
+func unescapeJSONPathText(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t").Replace(s)
+}
+
+func parseJSONPathExpression(expr string) ([]jsonPathStep, error) {
+	steps := make([]jsonPathStep, 0)
+	i := 0
+	if strings.HasPrefix(expr, "$") {
+		i = 1
+	}
+	readName := func() string {
+		j := i
+		for j < len(expr) && expr[j] != '.' && expr[j] != '[' {
+			j++
+		}
+		name := expr[i:j]
+		i = j
+		return name
+	}
+	for i < len(expr) {
+		switch {
+		case strings.HasPrefix(expr[i:], ".."):
+			i += 2
+			name := readName()
+			if len(name) == 0 {
+				return nil, fmt.Errorf("missing field name after '..' in %q", expr)
+			}
+			steps = append(steps, jsonPathStep{kind: jsonPathRecursive, name: name})
+		case expr[i] == '.':
+			i++
+			name := readName()
+			switch {
+			case len(name) == 0 && i == len(expr):
+				// '{.}' is the whole value
+			case len(name) == 0:
+				return nil, fmt.Errorf("missing field name in %q", expr)
+			case name == "*":
+				steps = append(steps, jsonPathStep{kind: jsonPathWildcard})
+			default:
+				steps = append(steps, jsonPathStep{kind: jsonPathField, name: name})
+			}
+		case expr[i] == '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '[' in %q", expr)
+			}
+			content := strings.TrimSpace(expr[i+1 : i+end])
+			i += end + 1
+			if content == "*" {
+				steps = append(steps, jsonPathStep{kind: jsonPathWildcard})
+				continue
+			}
+			if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+				steps = append(steps, jsonPathStep{kind: jsonPathField, name: content[1 : len(content)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(content)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q in %q", content, expr)
+			}
+			steps = append(steps, jsonPathStep{kind: jsonPathIndex, index: index})
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", expr[i:], expr)
+		}
+	}
+	return steps, nil
+}
+
+// evalJSONPath executes a jsonpath template on the generic JSON value v. The results of an expression that matches
+// more than one value are separated by a space.
+func evalJSONPath(tpl string, v interface{}) (string, error) {
+	parts, err := parseJSONPath(tpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, part := range parts {
+		if !part.expr {
+			b.WriteString(part.text)
+			continue
+		}
+		values := []interface{}{v}
+		for _, step := range part.steps {
+			values = step.apply(values)
+		}
+		for i, value := range values {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			s, err := jsonPathString(value)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		}
+	}
+	return b.String(), nil
+}
+
+func (s jsonPathStep) apply(values []interface{}) []interface{} {
+	result := make([]interface{}, 0)
+	for _, v := range values {
+		switch s.kind {
+		case jsonPathField:
+			if m, ok := v.(map[string]interface{}); ok {
+				if e, ok := m[s.name]; ok {
+					result = append(result, e)
+				}
+			}
+		case jsonPathIndex:
+			if l, ok := v.([]interface{}); ok {
+				i := s.index
+				if i < 0 {
+					i += len(l)
+				}
+				if i >= 0 && i < len(l) {
+					result = append(result, l[i])
+				}
+			}
+		case jsonPathWildcard:
+			result = append(result, jsonPathChildren(v)...)
+		case jsonPathRecursive:
+			result = append(result, jsonPathDescendants(v, s.name)...)
+		}
+	}
+	return result
+}
+
+// jsonPathChildren returns the elements of a list, or the values of an object ordered by key
+func jsonPathChildren(v interface{}) []interface{} {
+	switch t := v.(type) {
+	case []interface{}:
+		return t
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		children := make([]interface{}, 0, len(t))
+		for _, k := range keys {
+			children = append(children, t[k])
+		}
+		return children
+	}
+	return nil
+}
+
+// jsonPathDescendants returns the values of the fields with the name in v and everything below it, or all values
+// below v if name is '*'
+func jsonPathDescendants(v interface{}, name string) []interface{} {
+	result := make([]interface{}, 0)
+	if m, ok := v.(map[string]interface{}); ok && name != "*" {
+		if e, ok := m[name]; ok {
+			result = append(result, e)
+		}
+	}
+	for _, child := range jsonPathChildren(v) {
+		if name == "*" {
+			result = append(result, child)
+		}
+		result = append(result, jsonPathDescendants(child, name)...)
+	}
+	return result
+}
+
+func jsonPathString(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,329 @@
+package cmdutil
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/appgate/sdpctl/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by the commands that use AddOutputFlags
+const (
+	OutputTable      = "table"
+	OutputJSON       = "json"
+	OutputYAML       = "yaml"
+	OutputCSV        = "csv"
+	OutputTSV        = "tsv"
+	OutputGoTemplate = "go-template"
+	OutputJSONPath   = "jsonpath"
+)
+
+var outputFormats = []string{OutputTable, OutputJSON, OutputYAML, OutputCSV, OutputTSV, OutputGoTemplate + "=<template>", OutputJSONPath + "=<expression>"}
+
+// OutputOptions are the values of the output flags of a command
+type OutputOptions struct {
+	Format    string
+	Columns   []string
+	NoHeaders bool
+	JSON      bool
+	flags     *pflag.FlagSet
+	// template is the argument of the go-template and jsonpath formats
+	template string
+}
+
+// AddOutputFlags adds the '--output', '--columns', '--no-headers' and '--json' flags to the command.
+// defaultFormat is the format used when neither '--output' nor '--json' is given.
+func AddOutputFlags(cmd *cobra.Command, defaultFormat string) *OutputOptions {
+	opts := AddOutputFlagsInheritJSON(cmd, defaultFormat)
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "Display in JSON format, same as '--output json'")
+	return opts
+}
+
+// AddOutputFlagsInheritJSON is AddOutputFlags for a command whose parent already has a persistent '--json' flag.
+// The '--json' flag of the parent is used instead of a local flag that would shadow it.
+func AddOutputFlagsInheritJSON(cmd *cobra.Command, defaultFormat string) *OutputOptions {
+	opts := &OutputOptions{flags: cmd.Flags()}
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.Format, "output", "o", defaultFormat, "Output format, one of "+strings.Join(outputFormats, ", "))
+	flags.StringSliceVar(&opts.Columns, "columns", nil, "Comma separated list of the columns to show in table, csv and tsv output")
+	flags.BoolVar(&opts.NoHeaders, "no-headers", false, "Don't print the column headers in table, csv and tsv output")
+	return opts
+}
+
+// Validate checks the output flags and resolves '--json' and the template of the go-template and jsonpath formats.
+// It is called by Print, but commands should call it before doing any work so that a typo in the format doesn't
+// waste an API call.
+func (o *OutputOptions) Validate() error {
+	if o.flags != nil {
+		// the flags of the command include the persistent '--json' flag of the parent once they are parsed
+		if json, err := o.flags.GetBool("json"); err == nil && json {
+			o.JSON = true
+		}
+	}
+	if o.JSON {
+		if o.flags != nil && o.flags.Changed("output") && o.Format != OutputJSON {
+			return fmt.Errorf("'--json' can't be combined with '--output %s'", o.Format)
+		}
+		o.Format = OutputJSON
+	}
+	format, tpl, hasTemplate := strings.Cut(o.Format, "=")
+	if !hasTemplate {
+		// Validate has already been called
+		tpl = o.template
+	}
+	switch format {
+	case OutputTable, OutputJSON, OutputYAML, OutputCSV, OutputTSV:
+		if hasTemplate {
+			return fmt.Errorf("output format %q doesn't take an argument", format)
+		}
+	case OutputGoTemplate, OutputJSONPath:
+		if len(tpl) == 0 {
+			return fmt.Errorf("output format %q requires an argument, for example '--output %s=...'", format, format)
+		}
+		if format == OutputGoTemplate {
+			if _, err := template.New("output").Funcs(util.TPLFuncMap).Parse(tpl); err != nil {
+				return fmt.Errorf("invalid go-template: %w", err)
+			}
+		} else if _, err := parseJSONPath(tpl); err != nil {
+			return fmt.Errorf("invalid jsonpath: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown output format %q, expected one of %s", o.Format, strings.Join(outputFormats, ", "))
+	}
+	o.Format, o.template = format, tpl
+	return nil
+}
+
+// IsDefaultTable is true if the output is a table without any column or header options, which lets a command
+// keep a custom human readable presentation
+func (o *OutputOptions) IsDefaultTable() bool {
+	return o.Format == OutputTable && len(o.Columns) == 0 && !o.NoHeaders
+}
+
+// Table is the tabular presentation of a command result, used by the table, csv and tsv formats.
+// Values are added in full, the table format only shows the first line of a value.
+type Table struct {
+	Headers []string
+	Rows    [][]interface{}
+}
+
+// NewTable returns an empty table with the headers
+func NewTable(headers ...string) *Table {
+	return &Table{Headers: headers}
+}
+
+// AddRow adds a row with one value for each header
+func (t *Table) AddRow(values ...interface{}) {
+	t.Rows = append(t.Rows, values)
+}
+
+// Print writes the result of a command in the output format. The table formats use table, all other formats use the
+// JSON representation of data.
+func (o *OutputOptions) Print(out io.Writer, data interface{}, table *Table) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	switch o.Format {
+	case OutputJSON:
+		return util.PrintJSON(out, data)
+	case OutputYAML:
+		v, err := jsonValue(data)
+		if err != nil {
+			return err
+		}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(b)
+		return err
+	case OutputGoTemplate:
+		v, err := jsonValue(data)
+		if err != nil {
+			return err
+		}
+		t, err := template.New("output").Funcs(util.TPLFuncMap).Parse(o.template)
+		if err != nil {
+			return fmt.Errorf("invalid go-template: %w", err)
+		}
+		var b bytes.Buffer
+		if err := t.Execute(&b, v); err != nil {
+			return err
+		}
+		return writeLine(out, b.String())
+	case OutputJSONPath:
+		v, err := jsonValue(data)
+		if err != nil {
+			return err
+		}
+		s, err := evalJSONPath(o.template, v)
+		if err != nil {
+			return err
+		}
+		return writeLine(out, s)
+	}
+
+	headers, rows, err := o.selectColumns(table)
+	if err != nil {
+		return err
+	}
+	switch o.Format {
+	case OutputCSV, OutputTSV:
+		w := csv.NewWriter(out)
+		if o.Format == OutputTSV {
+			w.Comma = '\t'
+		}
+		if !o.NoHeaders {
+			w.Write(headers)
+		}
+		for _, row := range rows {
+			record := make([]string, 0, len(row))
+			for _, v := range row {
+				record = append(record, cellString(v))
+			}
+			w.Write(record)
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		p := util.NewPrinter(out, 4)
+		if !o.NoHeaders {
+			h := make([]interface{}, 0, len(headers))
+			for _, header := range headers {
+				h = append(h, header)
+			}
+			p.AddHeader(h...)
+		}
+		for _, row := range rows {
+			line := make([]interface{}, 0, len(row))
+			for _, v := range row {
+				if s, ok := v.(string); ok {
+					v = util.StringAbbreviate(s)
+				}
+				line = append(line, v)
+			}
+			p.AddLine(line...)
+		}
+		p.Print()
+		return nil
+	}
+}
+
+// selectColumns returns the headers and rows of the columns given with '--columns', in that order.
+// Columns are matched on the header ignoring case, spaces and punctuation, so 'device-id' selects 'Device ID', and
+// a header with a remark in parenthesis like 'Disk (used / total)' can be selected without it.
+func (o *OutputOptions) selectColumns(table *Table) ([]string, [][]interface{}, error) {
+	if table == nil {
+		return nil, nil, fmt.Errorf("output format %q is not supported by this command", o.Format)
+	}
+	if len(o.Columns) == 0 {
+		return table.Headers, table.Rows, nil
+	}
+	indexes := make([]int, 0, len(o.Columns))
+	for _, column := range o.Columns {
+		index := -1
+		for i, header := range table.Headers {
+			name, _, _ := strings.Cut(header, "(")
+			if c := normalizeColumn(column); c == normalizeColumn(header) || c == normalizeColumn(name) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, nil, fmt.Errorf("unknown column %q, available columns are: %s", column, strings.Join(table.Headers, ", "))
+		}
+		indexes = append(indexes, index)
+	}
+	headers := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		headers = append(headers, table.Headers[i])
+	}
+	rows := make([][]interface{}, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		selected := make([]interface{}, 0, len(indexes))
+		for _, i := range indexes {
+			if i < len(row) {
+				selected = append(selected, row[i])
+			} else {
+				selected = append(selected, "")
+			}
+		}
+		rows = append(rows, selected)
+	}
+	return headers, rows, nil
+}
+
+func normalizeColumn(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+func cellString(v interface{}) string {
+	switch t := v.(type) {
+	case []string:
+		return strings.Join(t, ",")
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// jsonValue returns data as the generic maps and slices of its JSON representation, so that yaml, go-template and
+// jsonpath use the same field names as the JSON output
+func jsonValue(data interface{}) (interface{}, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	return convertJSONNumbers(v), nil
+}
+
+// convertJSONNumbers replaces json.Number with int64 or float64, to avoid large integers being printed in exponent form
+func convertJSONNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = convertJSONNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = convertJSONNumbers(e)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+		return t.String()
+	}
+	return v
+}
+
+func writeLine(out io.Writer, s string) error {
+	if !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	_, err := io.WriteString(out, s)
+	return err
+}
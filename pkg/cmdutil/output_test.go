@@ -0,0 +1,243 @@
+package cmdutil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+)
+
+type outputTestItem struct {
+	Name     string   `json:"name"`
+	ID       string   `json:"id"`
+	Sessions int64    `json:"sessions"`
+	Tags     []string `json:"tags"`
+}
+
+func TestOutputOptionsPrint(t *testing.T) {
+	items := []outputTestItem{
+		{Name: "controller", ID: "4c07bc67", Sessions: 0, Tags: []string{"primary"}},
+		{Name: "gateway", ID: "ee639d70", Sessions: 1500000, Tags: []string{"site1", "dmz"}},
+	}
+	table := NewTable("Name", "ID", "Sessions (active)", "Tags")
+	for _, i := range items {
+		table.AddRow(i.Name, i.ID, i.Sessions, i.Tags)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "table",
+			want: `Name          ID          Sessions (active)    Tags
+----          --          -----------------    ----
+controller    4c07bc67    0                    [primary]
+gateway       ee639d70    1500000              [site1 dmz]
+`,
+		},
+		{
+			name: "table columns without headers",
+			args: []string{"--columns", "sessions,NAME", "--no-headers"},
+			want: `0          controller
+1500000    gateway
+`,
+		},
+		{
+			name: "csv",
+			args: []string{"--output", "csv", "--columns", "name,tags"},
+			want: `Name,Tags
+controller,primary
+gateway,"site1,dmz"
+`,
+		},
+		{
+			name: "tsv",
+			args: []string{"-o", "tsv", "--no-headers", "--columns", "id"},
+			want: "4c07bc67\nee639d70\n",
+		},
+		{
+			name: "json alias",
+			args: []string{"--json"},
+			want: `[
+  {
+    "name": "controller",
+    "id": "4c07bc67",
+    "sessions": 0,
+    "tags": [
+      "primary"
+    ]
+  },
+  {
+    "name": "gateway",
+    "id": "ee639d70",
+    "sessions": 1500000,
+    "tags": [
+      "site1",
+      "dmz"
+    ]
+  }
+]
+`,
+		},
+		{
+			name: "yaml",
+			args: []string{"-o", "yaml"},
+			want: `- id: 4c07bc67
+  name: controller
+  sessions: 0
+  tags:
+    - primary
+- id: ee639d70
+  name: gateway
+  sessions: 1500000
+  tags:
+    - site1
+    - dmz
+`,
+		},
+		{
+			name: "go-template",
+			args: []string{"-o", `go-template={{range .}}{{.name}}={{.sessions}};{{end}}`},
+			want: "controller=0;gateway=1500000;\n",
+		},
+		{
+			name: "jsonpath",
+			args: []string{"-o", `jsonpath={[*].name}\n{[-1].tags[0]} {..sessions}`},
+			want: "controller gateway\nsite1 0 1500000\n",
+		},
+		{
+			name:    "unknown column",
+			args:    []string{"--columns", "name,status"},
+			wantErr: `unknown column "status", available columns are: Name, ID, Sessions (active), Tags`,
+		},
+		{
+			name:    "unknown format",
+			args:    []string{"-o", "xml"},
+			wantErr: `unknown output format "xml", expected one of table, json, yaml, csv, tsv, go-template=<template>, jsonpath=<expression>`,
+		},
+		{
+			name:    "missing template",
+			args:    []string{"-o", "go-template"},
+			wantErr: `output format "go-template" requires an argument, for example '--output go-template=...'`,
+		},
+		{
+			name:    "invalid jsonpath",
+			args:    []string{"-o", "jsonpath={.name"},
+			wantErr: `invalid jsonpath: unclosed expression "{.name"`,
+		},
+		{
+			name:    "json and output",
+			args:    []string{"--json", "-o", "yaml"},
+			wantErr: "'--json' can't be combined with '--output yaml'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			opts := AddOutputFlags(cmd, OutputTable)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			out := &bytes.Buffer{}
+			err := opts.Print(out, items, table)
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Print() unexpected error %s", err)
+			}
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("Print() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOutputOptionsDefaultFormat(t *testing.T) {
+	cmd := &cobra.Command{}
+	opts := AddOutputFlags(cmd, OutputJSON)
+	if err := cmd.ParseFlags(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Format != OutputJSON || opts.IsDefaultTable() {
+		t.Errorf("expected json format, got %s", opts.Format)
+	}
+	out := &bytes.Buffer{}
+	if err := opts.Print(out, map[string]string{"name": "su"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.ParseFlags([]string{"-o", "csv"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.Print(out, map[string]string{"name": "su"}, nil); err == nil {
+		t.Error("expected an error for csv output without a table")
+	}
+}
+
+func TestOutputOptionsInheritJSON(t *testing.T) {
+	var parentJSON bool
+	root := &cobra.Command{Use: "root"}
+	root.PersistentFlags().BoolVar(&parentJSON, "json", false, "Display in JSON format")
+	out := &bytes.Buffer{}
+	var opts *OutputOptions
+	cmd := &cobra.Command{
+		Use: "list",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Print(out, map[string]string{"name": "su"}, NewTable("Name"))
+		},
+	}
+	opts = AddOutputFlagsInheritJSON(cmd, OutputTable)
+	root.AddCommand(cmd)
+	root.SetArgs([]string{"list", "--json"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !parentJSON {
+		t.Error("expected the '--json' flag of the parent to be set")
+	}
+	if diff := cmp.Diff("{\n  \"name\": \"su\"\n}\n", out.String()); diff != "" {
+		t.Errorf("Print() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOutputOptionsMultilineValues(t *testing.T) {
+	table := NewTable("Name", "Description")
+	table.AddRow("site", "first line\nsecond line")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{
+			args: []string{"--no-headers"},
+			want: "site    first line [...]\n",
+		},
+		{
+			args: []string{"-o", "csv", "--no-headers"},
+			want: "site,\"first line\nsecond line\"\n",
+		},
+	}
+	for _, tt := range tests {
+		cmd := &cobra.Command{}
+		opts := AddOutputFlags(cmd, OutputTable)
+		if err := cmd.ParseFlags(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		out := &bytes.Buffer{}
+		if err := opts.Print(out, nil, table); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+			t.Errorf("Print(%v) mismatch (-want +got):\n%s", tt.args, diff)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package configuration
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// applianceGroupDelimiter separates the values of a keyword when a group and the '--include' or '--exclude' flags use
+// the same keyword, and is the same as appliance.FilterDelimiter
+const applianceGroupDelimiter = "&"
+
+// ApplianceGroup is a named selection of appliances stored in the profile configuration. The appliance commands
+// expand '--group <name>' into the '--include', '--exclude' and '--filter' flags of the group.
+type ApplianceGroup struct {
+	Name    string            `mapstructure:"name" json:"name"`
+	Include map[string]string `mapstructure:"include" json:"include,omitempty"`
+	Exclude map[string]string `mapstructure:"exclude" json:"exclude,omitempty"`
+	Filter  string            `mapstructure:"filter" json:"filter,omitempty"`
+}
+
+// IsEmpty returns true if the group doesn't select anything
+func (g ApplianceGroup) IsEmpty() bool {
+	return len(g.Include) == 0 && len(g.Exclude) == 0 && len(strings.TrimSpace(g.Filter)) == 0
+}
+
+// ApplianceGroupNames returns the names of the appliance groups in the profile, sorted
+func (c *Config) ApplianceGroupNames() []string {
+	names := make([]string, 0, len(c.ApplianceGroups))
+	for _, g := range c.ApplianceGroups {
+		names = append(names, g.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetApplianceGroup returns the appliance group with the name
+func (c *Config) GetApplianceGroup(name string) (*ApplianceGroup, error) {
+	for _, g := range c.ApplianceGroups {
+		if g.Name == name {
+			return &g, nil
+		}
+	}
+	if len(c.ApplianceGroups) == 0 {
+		return nil, fmt.Errorf("appliance group '%s' not found, there are no appliance groups in the current profile", name)
+	}
+	return nil, fmt.Errorf("appliance group '%s' not found, available groups are: %s", name, strings.Join(c.ApplianceGroupNames(), ", "))
+}
+
+// SaveApplianceGroups replaces the appliance groups of the profile and writes them to the configuration file
+func (c *Config) SaveApplianceGroups(groups []ApplianceGroup) error {
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	viper.Set("appliance_groups", groups)
+	if err := viper.WriteConfig(); err != nil {
+		if errors.As(err, &viper.ConfigFileNotFoundError{}) {
+			return errors.New("no sdpctl configuration found; run 'sdpctl configure' before adding appliance groups")
+		}
+		return err
+	}
+	c.ApplianceGroups = groups
+	return nil
+}
+
+// ApplyApplianceGroup expands the '--group' flag, if it's used, into the '--include', '--exclude' and '--filter'
+// flags. A keyword that is set both in the group and with a flag matches either value, and a filter expression
+// given with '--filter' must match together with the filter expression of the group.
+func (c *Config) ApplyApplianceGroup(flags *pflag.FlagSet) error {
+	flag := flags.Lookup("group")
+	if flag == nil || !flag.Changed || len(flag.Value.String()) == 0 {
+		return nil
+	}
+	group, err := c.GetApplianceGroup(flag.Value.String())
+	if err != nil {
+		return err
+	}
+	for name, values := range map[string]map[string]string{"include": group.Include, "exclude": group.Exclude} {
+		if len(values) == 0 || flags.Lookup(name) == nil {
+			continue
+		}
+		current, err := flags.GetStringToString(name)
+		if err != nil {
+			return err
+		}
+		merged := make(map[string]string, len(current)+len(values))
+		for k, v := range current {
+			merged[k] = v
+		}
+		for k, v := range values {
+			if existing, ok := merged[k]; ok && existing != v {
+				v = existing + applianceGroupDelimiter + v
+			}
+			merged[k] = v
+		}
+		value, err := stringToStringFlagValue(merged)
+		if err != nil {
+			return err
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("failed to apply appliance group '%s': %w", group.Name, err)
+		}
+	}
+	if len(group.Filter) > 0 && flags.Lookup("filter") != nil {
+		expression := group.Filter
+		if current, _ := flags.GetString("filter"); len(strings.TrimSpace(current)) > 0 {
+			expression = fmt.Sprintf("(%s) && (%s)", group.Filter, current)
+		}
+		if err := flags.Set("filter", expression); err != nil {
+			return fmt.Errorf("failed to apply appliance group '%s': %w", group.Name, err)
+		}
+	}
+	return nil
+}
+
+// stringToStringFlagValue formats the map as the comma separated 'key=value' pairs of a string to string flag,
+// quoting the pairs that contain commas
+func stringToStringFlagValue(m map[string]string) (string, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+m[k])
+	}
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.Write(pairs); err != nil {
+		return "", err
+	}
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n"), w.Error()
+}
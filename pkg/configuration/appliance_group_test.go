@@ -0,0 +1,97 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/pflag"
+)
+
+func TestApplyApplianceGroup(t *testing.T) {
+	cfg := &Config{
+		ApplianceGroups: []ApplianceGroup{
+			{
+				Name:    "gateways",
+				Include: map[string]string{"function": "gateway", "version": ">=6.2;<6.4"},
+				Exclude: map[string]string{"tag": "canary"},
+			},
+			{
+				Name:   "busy",
+				Filter: "sessions > 1000",
+			},
+		},
+	}
+	tests := []struct {
+		name        string
+		args        []string
+		wantInclude map[string]string
+		wantExclude map[string]string
+		wantFilter  string
+		wantErr     string
+	}{
+		{
+			name:        "no group",
+			args:        []string{"--include", "name=controller"},
+			wantInclude: map[string]string{"name": "controller"},
+			wantExclude: map[string]string{},
+		},
+		{
+			name:        "group",
+			args:        []string{"--group", "gateways"},
+			wantInclude: map[string]string{"function": "gateway", "version": ">=6.2;<6.4"},
+			wantExclude: map[string]string{"tag": "canary"},
+		},
+		{
+			name:        "group combined with flags",
+			args:        []string{"--group", "gateways", "--include", "function=portal,name=gw1", "--filter", "cpu > 50"},
+			wantInclude: map[string]string{"function": "portal&gateway", "version": ">=6.2;<6.4", "name": "gw1"},
+			wantExclude: map[string]string{"tag": "canary"},
+			wantFilter:  "cpu > 50",
+		},
+		{
+			name:        "group with filter expression",
+			args:        []string{"--group", "busy", "--filter", `site == "Stockholm"`},
+			wantInclude: map[string]string{},
+			wantExclude: map[string]string{},
+			wantFilter:  `(sessions > 1000) && (site == "Stockholm")`,
+		},
+		{
+			name:    "unknown group",
+			args:    []string{"--group", "portals"},
+			wantErr: "appliance group 'portals' not found, available groups are: busy, gateways",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.StringToStringP("include", "i", map[string]string{}, "")
+			flags.StringToStringP("exclude", "e", map[string]string{}, "")
+			flags.String("filter", "", "")
+			flags.String("group", "", "")
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			err := cfg.ApplyApplianceGroup(flags)
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			include, _ := flags.GetStringToString("include")
+			if diff := cmp.Diff(tt.wantInclude, include); diff != "" {
+				t.Errorf("include mismatch (-want +got):\n%s", diff)
+			}
+			exclude, _ := flags.GetStringToString("exclude")
+			if diff := cmp.Diff(tt.wantExclude, exclude); diff != "" {
+				t.Errorf("exclude mismatch (-want +got):\n%s", diff)
+			}
+			if filter, _ := flags.GetString("filter"); filter != tt.wantFilter {
+				t.Errorf("got filter %q, want %q", filter, tt.wantFilter)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package configuration
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/appgate/sdpctl/pkg/prompt"
+	"github.com/youmark/pkcs8"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ClientKeyPassphraseEnv is the passphrase of an encrypted PKCS#8 client key or PKCS#12 client certificate
+const ClientKeyPassphraseEnv = "SDPCTL_CLIENT_KEY_PASSPHRASE"
+
+var (
+	ErrClientCertificateExpired = errors.New("client certificate has expired")
+	ErrIncorrectPassphrase      = errors.New("incorrect passphrase for the client key")
+	ErrClientKeyPassphrase      = fmt.Errorf("the client key is encrypted, set %s", ClientKeyPassphraseEnv)
+	ErrLegacyEncryptedPEMKey    = errors.New("legacy encrypted PEM client keys are not supported, convert the key to PKCS#8, for example with 'openssl pkcs8 -topk8 -v2 aes256 -in client.key -out client_pkcs8.key'")
+)
+
+// ClientKeyPassphrase returns the passphrase of an encrypted client key from the environment, or prompts for it
+func ClientKeyPassphrase(canPrompt bool) func() (string, error) {
+	return func() (string, error) {
+		if v, ok := os.LookupEnv(ClientKeyPassphraseEnv); ok {
+			return v, nil
+		}
+		if !canPrompt {
+			return "", ErrClientKeyPassphrase
+		}
+		return prompt.PromptPassword("Passphrase for the client key:")
+	}
+}
+
+// LoadClientCertificate reads the client certificate used for mutual TLS. A PKCS#12 file includes the key, otherwise
+// the PEM key is read from keyPath, or from certPath if keyPath is empty. passphrase is only called if the PKCS#12
+// file or the PKCS#8 key is encrypted. Keys encrypted with the legacy OpenSSL PEM encryption are not supported.
+func LoadClientCertificate(certPath, keyPath string, passphrase func() (string, error)) (*tls.Certificate, error) {
+	certData, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read client certificate %w", err)
+	}
+	if block, _ := pem.Decode(certData); block == nil {
+		return loadPKCS12(certData, passphrase)
+	}
+	keyData := certData
+	if len(keyPath) > 0 {
+		if keyData, err = os.ReadFile(keyPath); err != nil {
+			return nil, fmt.Errorf("could not read client key %w", err)
+		}
+	}
+
+	var certPEM, keyPEM []byte
+	for rest := certData; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			certPEM = append(certPEM, pem.EncodeToMemory(block)...)
+		}
+	}
+	for rest := keyData; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			continue
+		}
+		//lint:ignore SA1019 only used to detect legacy encrypted keys
+		if x509.IsEncryptedPEMBlock(block) {
+			return nil, ErrLegacyEncryptedPEMKey
+		}
+		if block.Type == "ENCRYPTED PRIVATE KEY" {
+			var err error
+			if block, err = decryptPKCS8(block, passphrase); err != nil {
+				return nil, err
+			}
+		}
+		keyPEM = pem.EncodeToMemory(block)
+		break
+	}
+	if len(keyPEM) == 0 {
+		return nil, errors.New("no private key found for the client certificate")
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate or key: %w", err)
+	}
+	return &cert, nil
+}
+
+// decryptPKCS8 returns the unencrypted PKCS#8 key of an encrypted PKCS#8 PEM block
+func decryptPKCS8(block *pem.Block, passphrase func() (string, error)) (*pem.Block, error) {
+	password, err := passphrase()
+	if err != nil {
+		return nil, err
+	}
+	if len(password) == 0 {
+		return nil, ErrIncorrectPassphrase
+	}
+	key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+	if err != nil {
+		// pkcs8 has no error value for a wrong password, the decrypted key just doesn't parse
+		if strings.Contains(err.Error(), "incorrect password") {
+			return nil, ErrIncorrectPassphrase
+		}
+		return nil, fmt.Errorf("invalid encrypted client key: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted client key: %w", err)
+	}
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+}
+
+// CheckClientCertificate returns an error if the client certificate is not valid at now
+func CheckClientCertificate(cert *tls.Certificate, now time.Time) error {
+	leaf := cert.Leaf
+	if leaf == nil {
+		var err error
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return err
+		}
+	}
+	if now.After(leaf.NotAfter) {
+		return fmt.Errorf("%w on %s", ErrClientCertificateExpired, leaf.NotAfter)
+	}
+	if now.Before(leaf.NotBefore) {
+		return fmt.Errorf("client certificate is not valid until %s", leaf.NotBefore)
+	}
+	return nil
+}
+
+func loadPKCS12(data []byte, passphrase func() (string, error)) (*tls.Certificate, error) {
+	// PKCS#12 files are often exported without a password, so try that before asking for it
+	key, leaf, chain, err := pkcs12.DecodeChain(data, "")
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+		password, perr := passphrase()
+		if perr != nil {
+			return nil, perr
+		}
+		key, leaf, chain, err = pkcs12.DecodeChain(data, password)
+	}
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+		return nil, ErrIncorrectPassphrase
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid PKCS#12 client certificate: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported client key type")
+	}
+	if pub, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); !ok || !pub.Equal(signer.Public()) {
+		return nil, errors.New("invalid client certificate or key: private key does not match public key")
+	}
+	cert := &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+	for _, c := range chain {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	return cert, nil
+}
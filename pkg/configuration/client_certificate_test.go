@@ -0,0 +1,139 @@
+package configuration
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func passphrase(v string) func() (string, error) {
+	return func() (string, error) {
+		return v, nil
+	}
+}
+
+func noPassphrase() (string, error) {
+	return "", errors.New("unexpected passphrase prompt")
+}
+
+func TestLoadClientCertificate(t *testing.T) {
+	tests := []struct {
+		name       string
+		certPath   string
+		keyPath    string
+		passphrase func() (string, error)
+		wantErr    string
+	}{
+		{
+			name:       "pem certificate and key",
+			certPath:   "testdata/client.pem",
+			keyPath:    "testdata/client.key",
+			passphrase: noPassphrase,
+		},
+		{
+			name:       "encrypted pkcs8 key",
+			certPath:   "testdata/client.pem",
+			keyPath:    "testdata/client_encrypted.key",
+			passphrase: passphrase("secret"),
+		},
+		{
+			name:       "encrypted pkcs8 key with wrong passphrase",
+			certPath:   "testdata/client.pem",
+			keyPath:    "testdata/client_encrypted.key",
+			passphrase: passphrase("wrong"),
+			wantErr:    ErrIncorrectPassphrase.Error(),
+		},
+		{
+			name:       "encrypted pkcs8 key without passphrase",
+			certPath:   "testdata/client.pem",
+			keyPath:    "testdata/client_encrypted.key",
+			passphrase: ClientKeyPassphrase(false),
+			wantErr:    ErrClientKeyPassphrase.Error(),
+		},
+		{
+			name:       "legacy encrypted pem key",
+			certPath:   "testdata/client.pem",
+			keyPath:    "testdata/client_legacy.key",
+			passphrase: noPassphrase,
+			wantErr:    ErrLegacyEncryptedPEMKey.Error(),
+		},
+		{
+			name:       "pkcs12",
+			certPath:   "testdata/client.p12",
+			passphrase: passphrase("secret"),
+		},
+		{
+			name:       "pkcs12 with wrong passphrase",
+			certPath:   "testdata/client.p12",
+			passphrase: passphrase("wrong"),
+			wantErr:    ErrIncorrectPassphrase.Error(),
+		},
+		{
+			name:       "key doesn't match certificate",
+			certPath:   "testdata/client.pem",
+			keyPath:    "testdata/other.key",
+			passphrase: noPassphrase,
+			wantErr:    "private key does not match public key",
+		},
+		{
+			name:       "no key",
+			certPath:   "testdata/client.pem",
+			passphrase: noPassphrase,
+			wantErr:    "no private key found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, err := LoadClientCertificate(tt.certPath, tt.keyPath, tt.passphrase)
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadClientCertificate() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadClientCertificate() error = %v", err)
+			}
+			if cert.Leaf == nil || cert.Leaf.Subject.CommonName != "sdpctl client" {
+				t.Errorf("unexpected certificate %+v", cert.Leaf)
+			}
+		})
+	}
+}
+
+func TestCheckClientCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notBefore := time.Now().Add(-48 * time.Hour)
+	notAfter := time.Now().Add(-24 * time.Hour)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "expired"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	if err := CheckClientCertificate(cert, time.Now()); !errors.Is(err, ErrClientCertificateExpired) {
+		t.Errorf("CheckClientCertificate() error = %v, want %v", err, ErrClientCertificateExpired)
+	}
+	if err := CheckClientCertificate(cert, notBefore.Add(time.Hour)); err != nil {
+		t.Errorf("CheckClientCertificate() error = %v, want none", err)
+	}
+	if err := CheckClientCertificate(cert, notBefore.Add(-time.Hour)); err == nil {
+		t.Error("CheckClientCertificate() expected an error before the certificate is valid")
+	}
+}
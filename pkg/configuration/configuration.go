@@ -43,11 +43,23 @@ type Config struct {
 	DeviceID            string  `mapstructure:"device_id"`
 	PemFilePath         string  `mapstructure:"pem_filepath"` // deprecated in favor of pem_base64, kept for backwards compatibility
 	PemBase64           *string `mapstructure:"pem_base64"`
+	PinnedSPKI          string  `mapstructure:"pinned_spki"`        // base64 SHA-256 hash of the Controller certificate public key
+	PinnedCertBase64    *string `mapstructure:"pinned_certificate"` // the certificate that was pinned, to show what changed
+	PinMode             string  `mapstructure:"pin_mode"`           // fail (default) or warn on a pin mismatch
+	ClientCertificate   string  `mapstructure:"client_certificate"` // path to the PEM or PKCS#12 client certificate for mutual TLS
+	ClientKey           string  `mapstructure:"client_key"`         // path to the PEM client key, if not in ClientCertificate
 	DisableVersionCheck bool    `mapstructure:"disable_version_check"`
 	LastVersionCheck    string  `mapstructure:"last_version_check"`
+	OIDCDeviceCode      bool    `mapstructure:"oidc_device_code"`   // sign in to OpenID Connect providers with the device authorization grant
+	CredentialProcess   string  `mapstructure:"credential_process"` // command that prints the sign in credentials as JSON
+	KeyringBackend      string  `mapstructure:"keyring_backend"`    // auto, system or file
+	TOTPGenerate        bool    `mapstructure:"totp_generate"`      // generate one-time passwords from the TOTP secret in the keyring
 	NoInteractive       bool    `mapstructure:"-"`
 	CiMode              bool    `mapstructure:"-"`
 	EventsPath          string  `mapstructure:"-"`
+
+	// ApplianceGroups are the named appliance selections used with '--group'
+	ApplianceGroups []ApplianceGroup `mapstructure:"appliance_groups"`
 }
 
 type Credentials struct {
@@ -176,6 +188,32 @@ func (c *Config) GetBearTokenHeaderValue() (string, error) {
 	return v, nil
 }
 
+// Locations of the bearer token returned by TokenStorage
+const (
+	TokenStorageEnv     = "env"
+	TokenStorageConfig  = "config"
+	TokenStorageKeyring = "keyring"
+	TokenStorageNone    = "none"
+)
+
+// TokenStorage returns where GetBearTokenHeaderValue reads the bearer token from
+func (c *Config) TokenStorage() string {
+	if v, ok := os.LookupEnv("SDPCTL_BEARER"); ok && len(v) > 0 {
+		return TokenStorageEnv
+	}
+	if c.BearerToken != nil && len(*c.BearerToken) > 10 {
+		return TokenStorageConfig
+	}
+	prefix, err := c.KeyringPrefix()
+	if err != nil {
+		return TokenStorageNone
+	}
+	if v, err := keyring.GetBearer(prefix); err == nil && len(v) > 0 {
+		return TokenStorageKeyring
+	}
+	return TokenStorageNone
+}
+
 // DefaultDeviceID return a unique ID in UUID format.
 // machine.ID() tries to read
 // /etc/machine-id on Linux
@@ -285,11 +323,7 @@ func (c *Config) IsRequireAuthentication() bool {
 }
 
 func (c *Config) ExpiredAtValid() bool {
-	if c.ExpiresAt == nil {
-		return false
-	}
-	layout := "2006-01-02 15:04:05.999999999 -0700 MST"
-	t1, err := time.Parse(layout, *c.ExpiresAt)
+	t1, err := c.ExpiresAtTime()
 	if err != nil {
 		return false
 	}
@@ -297,6 +331,17 @@ func (c *Config) ExpiredAtValid() bool {
 	return t1.After(now)
 }
 
+var ErrNoExpiresAt = errors.New("no token expiration date set")
+
+// ExpiresAtTime returns the parsed expires_at value of the current bearer token
+func (c *Config) ExpiresAtTime() (time.Time, error) {
+	if c.ExpiresAt == nil || len(*c.ExpiresAt) == 0 {
+		return time.Time{}, ErrNoExpiresAt
+	}
+	layout := "2006-01-02 15:04:05.999999999 -0700 MST"
+	return time.Parse(layout, *c.ExpiresAt)
+}
+
 func (c *Config) LoadCredentials() (*Credentials, error) {
 	creds := &Credentials{}
 	prefix, err := c.KeyringPrefix()
@@ -403,6 +448,15 @@ func (c *Config) KeyringPrefix() (string, error) {
 	return h, nil
 }
 
+// ProfileKeyringPrefix is the KeyringPrefix of the configuration when profile is the current profile
+func (c *Config) ProfileKeyringPrefix(profile string) (string, error) {
+	h, err := c.GetHost()
+	if err != nil {
+		return "", err
+	}
+	return profile + h, nil
+}
+
 func (c *Config) CheckForUpdate(out io.Writer, client *http.Client, current string) (*Config, error) {
 	// Check if version check is disabled in configuration
 	if c.DisableVersionCheck {
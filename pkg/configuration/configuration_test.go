@@ -280,6 +280,64 @@ func TestClearCredentials(t *testing.T) {
 	}
 }
 
+func TestTokenStorage(t *testing.T) {
+	zkeyring.MockInit()
+	t.Setenv("SDPCTL_CONFIG_DIR", t.TempDir())
+	host := "storage.appgate.com"
+	token := "abc123456789"
+	tests := []struct {
+		name    string
+		env     string
+		bearer  *string
+		keyring bool
+		want    string
+	}{
+		{
+			name: "none",
+			want: TokenStorageNone,
+		},
+		{
+			name:    "keyring",
+			keyring: true,
+			want:    TokenStorageKeyring,
+		},
+		{
+			name:    "config",
+			bearer:  &token,
+			keyring: true,
+			want:    TokenStorageConfig,
+		},
+		{
+			name:    "env",
+			env:     "abc123456789",
+			keyring: true,
+			want:    TokenStorageEnv,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := keyring.DeleteBearer(host); err != nil {
+				t.Fatal(err)
+			}
+			if tt.keyring {
+				if err := keyring.SetBearer(host, token); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(tt.env) > 0 {
+				t.Setenv("SDPCTL_BEARER", tt.env)
+			}
+			cfg := Config{
+				URL:         "https://" + host,
+				BearerToken: tt.bearer,
+			}
+			if got := cfg.TokenStorage(); got != tt.want {
+				t.Errorf("TokenStorage() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCheckAPIVersionRestriction(t *testing.T) {
 	type args struct {
 		cmd        *cobra.Command
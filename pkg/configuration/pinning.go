@@ -0,0 +1,109 @@
+package configuration
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// PinModeFail refuses to connect when the Controller presents a certificate that doesn't match the pin
+	PinModeFail = "fail"
+	// PinModeWarn only warns when the Controller presents a certificate that doesn't match the pin
+	PinModeWarn = "warn"
+)
+
+// ErrCertificatePinMismatch is returned when the public key of the Controller certificate is not the pinned one
+var ErrCertificatePinMismatch = errors.New("the Controller presented a certificate that doesn't match the pinned public key")
+
+// SPKIPin returns the base64 encoded SHA-256 hash of the certificate public key (SubjectPublicKeyInfo), which stays
+// the same when a certificate is renewed with the same key
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// PinnedCertificate returns the certificate that was pinned, if it was saved with the pin
+func (c *Config) PinnedCertificate() (*x509.Certificate, error) {
+	if c.PinnedCertBase64 == nil || len(*c.PinnedCertBase64) == 0 {
+		return nil, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(*c.PinnedCertBase64)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode pinned certificate %w", err)
+	}
+	return x509.ParseCertificate(data)
+}
+
+// PinMismatchError is ErrCertificatePinMismatch with the pinned and the presented certificate
+type PinMismatchError struct {
+	Host      string
+	Pinned    *x509.Certificate // nil if only the pin is known
+	Presented *x509.Certificate
+}
+
+func (e *PinMismatchError) Error() string {
+	return fmt.Sprintf("%s for %s\n%s\nRun 'sdpctl configure trust-update' to review and accept the new certificate",
+		ErrCertificatePinMismatch, e.Host, CertificateDiff(e.Pinned, e.Presented))
+}
+
+func (e *PinMismatchError) Unwrap() error {
+	return ErrCertificatePinMismatch
+}
+
+// VerifyPin returns a PinMismatchError if the Controller certificate in cs doesn't match the pinned public key
+func (c *Config) VerifyPin(cs tls.ConnectionState) error {
+	if len(c.PinnedSPKI) == 0 || len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+	presented := cs.PeerCertificates[0]
+	if SPKIPin(presented) == c.PinnedSPKI {
+		return nil
+	}
+	pinned, _ := c.PinnedCertificate()
+	host, _ := c.GetHost()
+	return &PinMismatchError{Host: host, Pinned: pinned, Presented: presented}
+}
+
+// certificateFields returns the fields of CertificateDetails as label and value pairs, in order
+func certificateFields(cert *x509.Certificate) [][2]string {
+	fields := [][2]string{}
+	if cert == nil {
+		return fields
+	}
+	for _, line := range strings.Split(CertificateDetails(cert), "\n") {
+		if strings.HasPrefix(line, "[") {
+			fields = append(fields, [2]string{line, ""})
+		} else if len(fields) > 0 && len(strings.TrimSpace(line)) > 0 {
+			fields[len(fields)-1][1] = strings.TrimSpace(line)
+		}
+	}
+	return fields
+}
+
+// CertificateDiff returns the CertificateDetails of the old and current certificate, with the changed fields marked
+// with - and +. If old is nil, only the current certificate is shown.
+func CertificateDiff(old, current *x509.Certificate) string {
+	var sb strings.Builder
+	oldFields := map[string]string{}
+	for _, f := range certificateFields(old) {
+		oldFields[f[0]] = f[1]
+	}
+	for _, f := range certificateFields(current) {
+		label, value := f[0], f[1]
+		previous, ok := oldFields[label]
+		switch {
+		case old != nil && ok && previous == value:
+			sb.WriteString(fmt.Sprintf("  %s\n\t%s\n", label, value))
+		case old != nil && ok:
+			sb.WriteString(fmt.Sprintf("  %s\n-\t%s\n+\t%s\n", label, previous, value))
+		default:
+			sb.WriteString(fmt.Sprintf("  %s\n+\t%s\n", label, value))
+		}
+	}
+	return sb.String()
+}
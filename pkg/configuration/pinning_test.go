@@ -0,0 +1,91 @@
+package configuration
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyPin(t *testing.T) {
+	pinned, err := ReadPemFile("testdata/client.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := selfSignedCertificate(t, "sdpctl client rotated")
+	cert := base64.StdEncoding.EncodeToString(pinned.Raw)
+	cfg := &Config{
+		URL:              "https://controller.devops:8443/admin",
+		PinnedSPKI:       SPKIPin(pinned),
+		PinnedCertBase64: &cert,
+	}
+	if err := cfg.VerifyPin(tls.ConnectionState{ServerName: "controller.devops", PeerCertificates: []*x509.Certificate{pinned}}); err != nil {
+		t.Errorf("VerifyPin() error = %v, want none", err)
+	}
+	err = cfg.VerifyPin(tls.ConnectionState{ServerName: "controller.devops", PeerCertificates: []*x509.Certificate{rotated}})
+	if !errors.Is(err, ErrCertificatePinMismatch) {
+		t.Fatalf("VerifyPin() error = %v, want %v", err, ErrCertificatePinMismatch)
+	}
+	for _, want := range []string{
+		"controller.devops",
+		"-\tsdpctl client",
+		"+\tsdpctl client rotated",
+		"sdpctl configure trust-update",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error\n%s", want, err)
+		}
+	}
+
+	// without a pin, any certificate is accepted
+	if err := (&Config{}).VerifyPin(tls.ConnectionState{PeerCertificates: []*x509.Certificate{rotated}}); err != nil {
+		t.Errorf("VerifyPin() error = %v, want none", err)
+	}
+}
+
+func TestCertificateDiff(t *testing.T) {
+	cert, err := ReadPemFile("testdata/client.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := CertificateDiff(cert, cert)
+	if strings.Contains(got, "\n-") || strings.Contains(got, "\n+") {
+		t.Errorf("expected no changes for the same certificate\n%s", got)
+	}
+	got = CertificateDiff(nil, cert)
+	if !strings.Contains(got, "  [Subject]\n+\tsdpctl client\n") {
+		t.Errorf("expected only the new certificate\n%s", got)
+	}
+}
+
+// selfSignedCertificate returns a new certificate with another key than the one in testdata
+func selfSignedCertificate(t *testing.T, cn string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
@@ -10,7 +10,10 @@ used together with one of the available sub-commands listed below.`,
 		Short: "List all appliances",
 		Long: `List all appliances in the Collective. The appliances will be listed in no particular order. Using without arguments
 will print a table view with a limited set of information. Using the command with the provided '--json' flag will print out a more detailed
-list view in json format. The list command can also be combined with the global '--include' and '--exclude' flags`,
+list view in json format. The '--output' flag selects other formats: yaml, csv, tsv, a Go template with
+'go-template=<template>' or a JSONPath expression with 'jsonpath=<expression>'. The table, csv and tsv formats can be limited to
+some columns with '--columns' and printed without headers using '--no-headers'.
+The list command can also be combined with the global '--include', '--exclude' and '--filter' flags`,
 		Examples: []ExampleDoc{
 			{
 				Description: "Default list command",
@@ -28,6 +31,26 @@ gateway                                                ec3b6270-ad7e-447a-a6e6-8
 				Description: "Print a filtered list of appliances",
 				Command:     "sdpctl appliance list --include=<key>=<value>",
 			},
+			{
+				Description: "Print the gateways in a site that are not tagged 'canary' using a filter expression",
+				Command:     `sdpctl appliance list --filter 'function == "gateway" && site == "Stockholm" && !tag("canary")'`,
+			},
+			{
+				Description: "List the gateways with less than 10 sessions, ordered by CPU usage",
+				Command:     "sdpctl appliance list --include function=gateway --filter 'sessions < 10' --order-by cpu",
+			},
+			{
+				Description: "List the appliances running a version from 6.2 up to, but not including, 6.4",
+				Command:     `sdpctl appliance list --include 'version=>=6.2;<6.4'`,
+			},
+			{
+				Description: "Print the name and site of the appliances as CSV",
+				Command:     "sdpctl appliance list --output csv --columns name,site",
+			},
+			{
+				Description: "Print the ID of each appliance",
+				Command:     "sdpctl appliance list --output 'jsonpath={[*].id}'",
+			},
 		},
 	}
 	ApplianceBackupDoc = CommandDoc{
@@ -76,7 +99,10 @@ For more information on the backup process, go to: https://sdphelp.appgate.com/a
 		Short: "Controls the state of the Backup API",
 		Long: `This command controls the state of the Backup API on the Collective.
 You will be prompted for a passphrase for the backups when enabling the Backup API using this command.
-The passphrase is required.`,
+The passphrase is required.
+
+To automate the setup, the passphrase can be read from stdin or from a file using the '--passphrase-file' flag.
+If the Backup API is already enabled, use '--rotate' to set a new passphrase.`,
 		Examples: []ExampleDoc{
 			{
 				Description: "enable the Backup API",
@@ -86,6 +112,59 @@ The passphrase is required.`,
 				Description: "disable the Backup API",
 				Command:     "sdpctl appliance backup api --disable",
 			},
+			{
+				Description: "enable the Backup API or rotate the passphrase non-interactively, reading the passphrase from a file",
+				Command:     "sdpctl appliance backup api --passphrase-file /path/to/passphrase",
+			},
+			{
+				Description: "rotate the passphrase, reading it from stdin",
+				Command:     "cat /path/to/passphrase | sdpctl appliance backup api --rotate --no-interactive",
+			},
+		},
+	}
+	ApplianceBackupAPIStatusDoc = CommandDoc{
+		Short: "Show the state of the Backup API",
+		Long:  `Show if the Backup API is enabled in the Collective. The backup passphrase itself can't be read back from the Collective.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "show the Backup API state",
+				Command:     "sdpctl appliance backup api status",
+				Output:      "The Backup API is enabled",
+			},
+			{
+				Description: "show the Backup API state in JSON format",
+				Command:     "sdpctl appliance backup api status --json",
+				Output: `{
+  "enabled": true
+}`,
+			},
+		},
+	}
+	ApplianceBackupScheduleDoc = CommandDoc{
+		Short: "Take appliance backups on a schedule",
+		Long: `Run in the foreground as a long-lived process and take appliance backups on a schedule, suitable for running as a systemd unit
+or in a container. The schedule is either a cron expression using the '--cron' flag or a fixed interval using the '--every' flag.
+
+Appliances are selected using the same flags and arguments as the 'backup' command. The command never prompts for input, so the
+Backup API needs to be enabled in the Collective beforehand, using 'sdpctl appliance backup api'. When the bearer token expires,
+sdpctl will sign in again using the stored credentials or the SDPCTL_USERNAME and SDPCTL_PASSWORD environment variables.
+
+Use '--retention' to only keep the latest backup files for each appliance in the destination directory. The result of the last
+backup run can be written to a JSON file using '--status-file', or served on a HTTP health endpoint using '--health-listen'.
+The health endpoint responds with HTTP 503 if the last backup run failed.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "backup the primary Controller every night at 02:00 and keep the last 7 backups",
+				Command:     `sdpctl appliance backup schedule --cron "0 2 * * *" --primary --retention 7`,
+			},
+			{
+				Description: "backup all appliances every 24 hours, starting immediately",
+				Command:     "sdpctl appliance backup schedule --every 24h --all --run-on-start",
+			},
+			{
+				Description: "expose the status of the last run on a health endpoint and in a status file",
+				Command:     `sdpctl appliance backup schedule --cron "@daily" --primary --health-listen 127.0.0.1:9851 --status-file /var/lib/sdpctl/backup-status.json`,
+			},
 		},
 	}
 	ApplianceUpgradeDoc = CommandDoc{
@@ -207,7 +286,13 @@ and perform a reboot to make the second partition the primary.`,
 		Short: "Get all the Prometheus metrics for the given Appliance",
 		Long: `The 'metric' command will return a list of all the available metrics provided by an appliance for use in Prometheus.
 If no appliance ID is given as an argument, the command will prompt for which Appliance you want metrics for. A second argument can be used
-to get a specific metric name. This needs to be an exact match.`,
+to get a specific metric name. This needs to be an exact match, or a pattern such as 'vpn_*' to get all matching metrics.
+
+By default the metrics are printed as returned by the appliance. The '--json' and '--csv' flags parse the metrics into samples
+with a name, type, labels and value, and '--label' only shows the samples with the given label values.
+
+Use '--all-appliances' to get the metrics from all appliances matching '--include' and '--exclude'. Each sample is tagged
+with the name of the appliance it comes from.`,
 		Examples: []ExampleDoc{
 			{
 				Description: "list all available appliance metrics",
@@ -221,6 +306,14 @@ to get a specific metric name. This needs to be an exact match.`,
 				Description: "get a particular metric from an appliance",
 				Command:     "sdpctl appliance metric <appliance-id> <metric-name>",
 			},
+			{
+				Description: "get all VPN metrics with a label value from an appliance in JSON format",
+				Command:     "sdpctl appliance metric <appliance-id> 'vpn_*' --label direction=out --json",
+			},
+			{
+				Description: "get a metric from all Gateways in CSV format",
+				Command:     "sdpctl appliance metric vpn_total_sessions --all-appliances --include function=gateway --csv",
+			},
 		},
 	}
 	ApplianceResolveNameDoc = CommandDoc{
@@ -273,7 +366,15 @@ Clients and shows the resolution results`,
 		Long: `Show current stats, such as current system resource consumption, appliance version etc, for the appliances.
 Using the '--json' flag will return a more detailed list of stats in json format.
 
-NOTE: Although the '--include' and '--exclude' flags are provided as options here, they don't have any actual effect on the command.`,
+NOTE: Although the '--include' and '--exclude' flags are provided as options here, they don't have any actual effect on the command.
+
+Using the '--serve' flag will instead run an HTTP server that exposes the stats on '/metrics' in the Prometheus text format,
+including CPU, memory and disk usage, network speed of the busiest interface, number of sessions, online status, version
+and upgrade status of each appliance. The stats are fetched from the Controller at most once per '--scrape-interval', and
+sdpctl signs in again when the token is about to expire or has been revoked.
+
+Use 'sdpctl appliance stats record' to save the stats at a regular interval and 'sdpctl appliance stats history' to
+summarize the recorded stats.`,
 		Examples: []ExampleDoc{
 			{
 				Description: "default listing of stats",
@@ -283,12 +384,89 @@ NOTE: Although the '--include' and '--exclude' flags are provided as options her
 				Description: "print stats in JSON format",
 				Command:     "sdpctl appliance stats --json",
 			},
+			{
+				Description: "serve the stats as Prometheus metrics on port 9850, refreshed at most once a minute",
+				Command:     "sdpctl appliance stats --serve :9850 --scrape-interval 1m",
+			},
+		},
+	}
+
+	ApplianceStatsRecordDoc = CommandDoc{
+		Short: "Record appliance stats over time",
+		Long: `Take a snapshot of the appliance stats at a regular interval and append it to a file, so that the usage of the
+appliances can be followed over time with 'sdpctl appliance stats history'. Each snapshot is written as one JSON document
+per line and contains the status, version, CPU, memory and disk usage, number of sessions and network speed of the busiest
+interface of each appliance.
+
+The recording continues until it is interrupted, or until '--count' snapshots have been taken. A snapshot that fails, for
+example because the Controller can't be reached, is logged and skipped. sdpctl signs in again when the token is about to
+expire or has been revoked.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "record the stats of all appliances every minute to the default file",
+				Command:     "sdpctl appliance stats record",
+			},
+			{
+				Description: "record the stats of the Gateways every 5 minutes to a custom file",
+				Command:     "sdpctl appliance stats record --include function=gateway --interval 5m --out gateway-stats.jsonl",
+			},
+		},
+	}
+
+	ApplianceStatsHistoryDoc = CommandDoc{
+		Short: "Show a summary of recorded appliance stats",
+		Long: `Show the minimum, average, maximum and last value of the CPU, memory and disk usage, number of sessions and network
+speed of each appliance, from the snapshots recorded with 'sdpctl appliance stats record'. The 'Trend' column shows how
+the value has changed over the time range as a sparkline. A '--since' timestamp without a time zone is in the local time zone.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "show the stats recorded during the last 24 hours",
+				Command:     "sdpctl appliance stats history",
+			},
+			{
+				Description: "show the stats of the Gateways recorded during the last week",
+				Command:     "sdpctl appliance stats history --appliance '^gateway' --since 168h",
+			},
+			{
+				Description: "show the stats recorded since a point in time in JSON format",
+				Command:     "sdpctl appliance stats history --since '2024-03-10 14:00:00' --in gateway-stats.jsonl --json",
+			},
+		},
+	}
+
+	ApplianceHealthDoc = CommandDoc{
+		Short: "Check the health of the appliances for monitoring",
+		Long: `Check the health of the appliances and print the result as a single line in the Nagios plugin format, so that the
+command can be executed by Nagios, Icinga and other monitoring systems that support the same plugin convention.
+
+The status, CPU, memory and disk usage of each appliance is checked against the thresholds, together with the number of
+online Controllers and whether the appliances are running different versions. The output contains a summary, the problems
+found and performance data for each appliance.
+
+The exit code is 0 for OK, 1 for WARNING, 2 for CRITICAL and 3 for UNKNOWN, for example when the Controller can't be reached.
+Any other error, such as a failed sign in or an invalid flag, also results in UNKNOWN with the error in the output line.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "check the health of all appliances with the default thresholds",
+				Command:     "sdpctl appliance health",
+			},
+			{
+				Description: "check the Gateways with custom thresholds",
+				Command:     "sdpctl appliance health --include function=gateway --min-controllers 0 --warn-cpu 80 --crit-cpu 95 --warn-disk 85 --crit-disk 95",
+			},
 		},
 	}
 
 	ApplianceLogsDoc = CommandDoc{
 		Short: "Download zip bundle with logs",
-		Long:  `Download a zip bundle with all logs from a appliance`,
+		Long: `Download a zip bundle with all logs from a appliance.
+
+Use '--include' or '--exclude' to download the logs from all matching appliances at the same time. Each bundle is saved
+in a directory named after the appliance, and a summary with the path, size and status of each download is printed when
+all downloads are done. The number of simultaneous downloads is limited by '--throttle'.
+
+Use '--extract' to extract the journal files from the bundles right after the download, in the same way as
+'sdpctl appliance extract-logs'.`,
 		Examples: []ExampleDoc{
 			{
 				Description: "Default logs command",
@@ -298,12 +476,56 @@ NOTE: Although the '--include' and '--exclude' flags are provided as options her
 				Description: "Default logs to specific path",
 				Command:     "sdpctl appliance logs a68c1f69-534d-4060-a052-b223d42bac2c --path /tmp",
 			},
+			{
+				Description: "Download the logs from all Gateways in a site, three at a time",
+				Command:     "sdpctl appliance logs --include function=gateway,site=Default --throttle 3 --path /tmp/logs",
+			},
+			{
+				Description: "Download and extract the logs from all appliances except the Controllers",
+				Command:     "sdpctl appliance logs --exclude function=controller --extract",
+			},
+		},
+	}
+
+	ApplianceLogsTimelineDoc = CommandDoc{
+		Short: "Merge the logs from several log bundles into one timeline",
+		Long: `Reads the journal files from several log bundles, for example from a Controller, a Gateway and a LogForwarder,
+and writes all entries ordered by time to stdout. Each entry is labeled with the name of the bundle it comes from.
+
+If the clocks of the appliances differ, use '--offset' to correct the timestamps of a bundle. The offset is added
+to the timestamps of the bundle before the entries are merged and filtered.
+
+The same filters and output formats as 'sdpctl appliance extract-logs' are supported.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "Merge the logs from a Controller and a Gateway",
+				Command:     "sdpctl appliance logs timeline controller.zip gateway.zip > timeline.log",
+			},
+			{
+				Description: "Correct a Gateway clock that is 2.5 seconds ahead and only include a time range",
+				Command:     "sdpctl appliance logs timeline controller.zip gateway.zip --offset gateway.zip=-2.5s --since '2024-03-10 14:00' --until '2024-03-10 14:15'",
+			},
+			{
+				Description: "Merge the logs as Elastic Common Schema documents",
+				Command:     "sdpctl appliance logs timeline controller.zip gateway.zip logforwarder.zip --format ecs > timeline.ndjson",
+			},
 		},
 	}
 
 	ApplianceExtractLogsDoc = CommandDoc{
 		Short: "Unpacks binary log files from a log bundle",
-		Long:  `Unpacks journald binary log files from a log bundle .zip file, creating log files grouped by daemon`,
+		Long: `Unpacks journald binary log files from a log bundle .zip file, creating log files grouped by daemon.
+
+The journal entries can be filtered with '--since', '--until', '--identifier', '--priority', '--grep' and '--hostname'.
+All filters must match for an entry to be written. Timestamps without a time zone are in the local time zone, like
+journalctl, use RFC3339 such as '2024-03-10T14:00:00Z' for UTC.
+
+The entries are written as journalctl-like text by default. Use '--format jsonl' to keep all journal fields,
+'--format csv' with '--fields' to select the columns or '--format ecs' for Elastic Common Schema documents.
+With '--merge', all entries are written to a single journal file instead of one file per daemon in logs_by_daemon/.
+
+The journal files are processed in parallel, use '--workers' to limit the number of journal files
+that are extracted and read at the same time.`,
 		Examples: []ExampleDoc{
 			{
 				Description: "Extract logs command",
@@ -313,6 +535,22 @@ NOTE: Although the '--include' and '--exclude' flags are provided as options her
 				Description: "Extract logs to specific path",
 				Command:     "sdpctl extract-logs controller.zip --path /tmp",
 			},
+			{
+				Description: "Extract warnings and errors from sshd and the session daemon within a time range",
+				Command:     "sdpctl extract-logs controller.zip --since '2024-03-10 14:00' --until '2024-03-10 16:00' --identifier sshd --identifier 'cz-.*' --priority warning",
+			},
+			{
+				Description: "Extract entries with a message matching a regular expression",
+				Command:     "sdpctl extract-logs controller.zip --grep 'session [0-9]+ rejected'",
+			},
+			{
+				Description: "Extract all journal entries into a single file for ingestion into a log platform",
+				Command:     "sdpctl extract-logs controller.zip --format ecs --merge",
+			},
+			{
+				Description: "Extract selected journal fields as CSV",
+				Command:     "sdpctl extract-logs controller.zip --format csv --fields _SOURCE_REALTIME_TIMESTAMP,_HOSTNAME,SYSLOG_IDENTIFIER,MESSAGE",
+			},
 		},
 	}
 
@@ -402,4 +640,82 @@ The command will accept one or more hostnames or ID:s of Controllers that will b
 			},
 		},
 	}
+	ApplianceGroupRootDoc = CommandDoc{
+		Short: "Manage named appliance groups",
+		Long: `Manage named appliance groups stored in the current profile. An appliance group is a saved combination of the
+'--include', '--exclude' and '--filter' flags, which can then be used with any appliance command using '--group <name>'.
+If the command is also given '--include' or '--exclude', a keyword used in both matches either value, and a '--filter'
+expression must match together with the expression of the group.
+
+Groups can be exported to a JSON file and imported from one, to share them with other operators.`,
+	}
+	ApplianceGroupAddDoc = CommandDoc{
+		Short: "Add a named appliance group",
+		Long: `Add a named appliance group to the current profile. The group is defined by the '--include', '--exclude' and
+'--filter' flags, using the same syntax as the other appliance commands. Use '--force' to replace an existing group.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "add a group with the Gateways in a site",
+				Command:     "sdpctl appliance group add stockholm-gateways --include function=gateway,site=<site-id>",
+			},
+			{
+				Description: "add a group using a filter expression",
+				Command:     `sdpctl appliance group add canaries --filter 'tag("canary") && !function("controller")'`,
+			},
+			{
+				Description: "use the group with another appliance command",
+				Command:     "sdpctl appliance upgrade prepare --image <path-to-image> --group canaries",
+			},
+		},
+	}
+	ApplianceGroupListDoc = CommandDoc{
+		Short: "List the named appliance groups",
+		Long:  `List the named appliance groups in the current profile.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "list the appliance groups",
+				Command:     "sdpctl appliance group list",
+				Output: `Name                  Include                               Exclude    Filter
+----                  -------                               -------    ------
+canaries                                                               tag("canary") && !function("controller")
+stockholm-gateways    function=gateway,site=<site-id>`,
+			},
+		},
+	}
+	ApplianceGroupDeleteDoc = CommandDoc{
+		Short: "Delete named appliance groups",
+		Long:  `Delete one or more named appliance groups from the current profile.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "delete an appliance group",
+				Command:     "sdpctl appliance group delete canaries",
+			},
+		},
+	}
+	ApplianceGroupExportDoc = CommandDoc{
+		Short: "Export named appliance groups to a JSON file",
+		Long: `Export the named appliance groups of the current profile as JSON, either all of them or the groups given as
+arguments. The output can be imported with 'sdpctl appliance group import'.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "export all appliance groups to a file",
+				Command:     "sdpctl appliance group export --file groups.json",
+			},
+			{
+				Description: "print a single appliance group",
+				Command:     "sdpctl appliance group export canaries",
+			},
+		},
+	}
+	ApplianceGroupImportDoc = CommandDoc{
+		Short: "Import named appliance groups from a JSON file",
+		Long: `Import named appliance groups from a JSON file created by 'sdpctl appliance group export'. The import fails if a
+group already exists in the current profile, unless '--force' is used to replace it.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "import the appliance groups shared by a teammate",
+				Command:     "sdpctl appliance group import groups.json",
+			},
+		},
+	}
 )
@@ -0,0 +1,50 @@
+package docs
+
+var (
+	AuthDocs = CommandDoc{
+		Short: "Show and manage the sign in to the Collective",
+		Long:  `Show the status of the sign in to the Collective and sign out of it.`,
+	}
+	AuthStatusDocs = CommandDoc{
+		Short: "Show the status of the sign in to the Collective",
+		Long: `Show the profile, the Controller URL, the identity provider, the username and when the bearer token expires,
+together with the peer API version and where the bearer token is stored. The token storage is 'keyring' for the
+system keyring, 'env' for the SDPCTL_BEARER environment variable, 'config' for the configuration file and 'none'
+if there is no token. The username is fetched from the Controller if the token is valid.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "show the sign in status",
+				Command:     "sdpctl auth status",
+				Output: `Profile:          production
+URL:              https://controller.devops:8443/admin
+Provider:         local
+Username:         admin
+Token expires:    2026-10-19T10:12:40+02:00
+API version:      20
+Token storage:    keyring`,
+			},
+			{
+				Description: "show the sign in status in JSON format",
+				Command:     "sdpctl auth status --json",
+			},
+		},
+	}
+	AuthLogoutDocs = CommandDoc{
+		Short: "Sign out of the Collective",
+		Long: `Sign out of the Collective by removing the bearer token and the stored credentials from the keyring and the
+configuration file. The next command will require a new sign in.
+
+For OpenID Connect providers, the refresh token is also revoked with the identity provider if it supports token
+revocation. The Controller has no API to revoke the bearer token, so a copy of the token is valid until it expires.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "sign out of the current profile",
+				Command:     "sdpctl auth logout",
+			},
+			{
+				Description: "sign out of all profiles",
+				Command:     "sdpctl auth logout --all-profiles",
+			},
+		},
+	}
+)
@@ -5,7 +5,23 @@ var (
 		Short: "Configure your Collective",
 		Long: `Setup a configuration file towards your Collective to be able to interact with the collective. By default, the configuration file
 will be created in a default directory in depending on your system. This can be overridden by setting the 'SDPCTL_CONFIG_DIR' environment variable.
-See 'sdpctl help environment' for more information on using environment variables.`,
+See 'sdpctl help environment' for more information on using environment variables.
+
+To keep the password in a secret manager instead of the system keyring, set '--credential-process' to a command that prints
+the credentials as JSON, for example {"username": "admin", "password": "secret"}. An "otp" value is used as the one-time password
+if the sign in requires one.
+
+Service accounts that sign in without a TTY can use '--totp' to enroll the one-time password secret on the first sign in.
+The secret is stored in the keyring, like the password, and one-time passwords are generated from it on each sign in.
+The secret can also be set with the 'SDPCTL_TOTP_SECRET' environment variable. If the secret can't be stored, for example
+with SDPCTL_NO_KEYRING, the sign in fails with the secret, which must then be set with 'SDPCTL_TOTP_SECRET'.
+If the generated one-time password is rejected, the code of the closest time step is tried once, to tolerate clock skew.
+Rejected codes count towards the lockout for invalid one-time passwords on the Controller.
+
+If the Controller is behind a proxy that requires mutual TLS, set '--client-cert' to a PEM or PKCS#12 client certificate,
+and '--client-key' to the PEM private key if it's in a separate file. The passphrase of an encrypted PKCS#8 key or PKCS#12
+file is prompted for, or read from the 'SDPCTL_CLIENT_KEY_PASSPHRASE' environment variable. Keys encrypted with the legacy
+OpenSSL format, with a 'Proc-Type: 4,ENCRYPTED' header, must be converted to PKCS#8 with 'openssl pkcs8 -topk8'.`,
 		Examples: []ExampleDoc{
 			{
 				Description: "basic configuration command",
@@ -19,6 +35,22 @@ See 'sdpctl help environment' for more information on using environment variable
 				Description: "configure sdpctl using a custom certificate file",
 				Command:     "sdpctl configure --pem=/path/to/pem",
 			},
+			{
+				Description: "configure sdpctl and pin the public key of the Controller certificate",
+				Command:     "sdpctl configure company.controller.com --pem=/path/to/pem --pin",
+			},
+			{
+				Description: "configure sdpctl with a client certificate for a proxy that requires mutual TLS",
+				Command:     "sdpctl configure company.controller.com --client-cert=/path/to/client.pem --client-key=/path/to/client.key",
+			},
+			{
+				Description: "configure sdpctl to get the credentials from a script that reads them from a secret manager",
+				Command:     "sdpctl configure company.controller.com --credential-process='/usr/local/bin/sdpctl-credentials production'",
+			},
+			{
+				Description: "configure a service account that generates its one-time passwords",
+				Command:     "sdpctl configure company.controller.com --totp",
+			},
 			{
 				Description: "configure using a custom confiuration directory",
 				Command:     "SDPCTL_CONFIG_DIR=/path/config/dir sdpctl configure",
@@ -28,12 +60,61 @@ See 'sdpctl help environment' for more information on using environment variable
 	ConfigureSigninDocs = CommandDoc{
 		Short: "Sign in and authenticate to Collective",
 		Long: `Sign in to the Collective using the configuration file created by the 'sdpctl configure' command.
-This will fetch a token on valid authentication which will be valid for 24 hours and stored in the configuration.`,
+This will fetch a token on valid authentication which will be valid for 24 hours and stored in the configuration.
+
+OpenID Connect providers are signed in to with a browser on this machine. If no browser can be opened, for example
+over SSH, or if '--device-code' is used, a verification URL and a code are printed instead, to complete the sign in
+with a browser on another device. Set 'SDPCTL_OIDC_DEVICE_CODE=true' to always use the device code.`,
 		Examples: []ExampleDoc{
 			{
 				Description: "default sign in command",
 				Command:     "sdpctl configure signin",
 			},
+			{
+				Description: "sign in to an OpenID Connect provider from a machine without a browser",
+				Command:     "sdpctl configure signin --device-code",
+			},
+		},
+	}
+	ConfigureTrustUpdateDocs = CommandDoc{
+		Short: "Review and accept a new Controller certificate",
+		Long: `Connect to the Controller and compare the certificate it presents with the pinned certificate and the trusted
+certificate added with '--pem'. If the certificate has been rotated, the changes and the public key of the new
+certificate are shown, and the new certificate is pinned once accepted.
+
+The new certificate must be reviewed before it's accepted. Without a prompt, for example in scripts, the expected public
+key must be given with '--expected-pin sha256/<base64 hash>', obtained from a trusted source such as the Controller
+administrator. A certificate presented by the Controller is never trusted on its own. If the new certificate is signed
+by another CA, the CA certificate must be given with '--pem'.
+
+Certificate pinning is enabled with 'sdpctl configure --pin'. When the Controller presents a certificate with another
+public key than the pinned one, commands fail, or only print a warning if the profile was configured with '--pin-mode=warn'.`,
+		Examples: []ExampleDoc{
+			{
+				Description: "review a rotated Controller certificate",
+				Command:     "sdpctl configure trust-update",
+				Output: `The certificate presented by controller.company.com has changed:
+  [Subject]
+	controller.company.com
+  [Issuer]
+-	Company CA 2023
++	Company CA 2025
+  [Serial Number]
+-	4096
++	8192
+...
+Public key: sha256/jiQAaUh4+tE9sY9mQSUjm5Ex4SVyBbXBM69NSCcbnUs=
+
+Do you want to continue? (y/N)`,
+			},
+			{
+				Description: "trust a Controller certificate signed by a new CA",
+				Command:     "sdpctl configure trust-update --pem=/path/to/company-ca-2025.pem",
+			},
+			{
+				Description: "accept the new certificate without prompting, if its public key matches the announced one",
+				Command:     "sdpctl configure trust-update --no-interactive --expected-pin=sha256/jiQAaUh4+tE9sY9mQSUjm5Ex4SVyBbXBM69NSCcbnUs=",
+			},
 		},
 	}
 )
@@ -17,6 +17,10 @@ var (
 				Description: "print list in JSON format",
 				Command:     "sdpctl device list --json",
 			},
+			{
+				Description: "print the username and hostname of each device, without headers",
+				Command:     "sdpctl device list --columns username,hostname --no-headers",
+			},
 		},
 	}
 	DeviceRevokeDoc = CommandDoc{
@@ -18,6 +18,10 @@ var (
 				Description: "output in JSON format",
 				Command:     "sdpctl service-users list --json",
 			},
+			{
+				Description: "output in YAML format",
+				Command:     "sdpctl service-users list --output yaml",
+			},
 		},
 	}
 	ServiceUsersGet = &CommandDoc{
@@ -0,0 +1,52 @@
+package factory
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/appgate/sdpctl/pkg/configuration"
+	log "github.com/sirupsen/logrus"
+)
+
+// clientCertificates are the client certificates loaded by the factory, so the passphrase of an encrypted key
+// is only asked for once, even though every HTTP client gets its own transport
+type clientCertificates struct {
+	mu    sync.Mutex
+	certs map[string]*tls.Certificate
+}
+
+// clientCertificate returns the client certificate for mutual TLS configured in cfg, or nil if there is none
+func (f *Factory) clientCertificate(cfg *configuration.Config) (*tls.Certificate, error) {
+	if len(cfg.ClientCertificate) == 0 {
+		return nil, nil
+	}
+	if f.clientCerts == nil {
+		return f.loadClientCertificate(cfg)
+	}
+	f.clientCerts.mu.Lock()
+	defer f.clientCerts.mu.Unlock()
+	key := cfg.ClientCertificate + "\x00" + cfg.ClientKey
+	if cert, ok := f.clientCerts.certs[key]; ok {
+		return cert, nil
+	}
+	cert, err := f.loadClientCertificate(cfg)
+	if err != nil {
+		return nil, err
+	}
+	f.clientCerts.certs[key] = cert
+	return cert, nil
+}
+
+func (f *Factory) loadClientCertificate(cfg *configuration.Config) (*tls.Certificate, error) {
+	cert, err := configuration.LoadClientCertificate(cfg.ClientCertificate, cfg.ClientKey, configuration.ClientKeyPassphrase(f.CanPrompt()))
+	if err != nil {
+		return nil, fmt.Errorf("could not load client certificate %s: %w", cfg.ClientCertificate, err)
+	}
+	// the proxy rejects an expired certificate, which is easier to understand with a warning in the log
+	if err := configuration.CheckClientCertificate(cert, time.Now()); err != nil {
+		log.WithError(err).WithField("path", cfg.ClientCertificate).Warn("invalid client certificate")
+	}
+	return cert, nil
+}
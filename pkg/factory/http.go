@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sync"
 
 	"github.com/appgate/sdpctl/pkg/api"
 	"github.com/appgate/sdpctl/pkg/cmdutil"
@@ -39,6 +40,9 @@ type Factory struct {
 	ServiceUsers   func(c *configuration.Config) (*serviceusers.ServiceUsersAPI, error)
 	DockerRegistry func(s string) (*url.URL, error)
 	BaseURL        func() string
+	TokenSource    *TokenSource // refreshes the bearer token when it expires, if set
+	clientCerts    *clientCertificates
+	pinWarning     *sync.Once // the certificate pin mismatch warning is only printed once per command
 	userAgent      string
 	Config         *configuration.Config
 	IOOutWriter    io.Writer
@@ -55,6 +59,8 @@ func New(appVersion string, config *configuration.Config) *Factory {
 	f := &Factory{}
 	f.Config = config
 	f.userAgent = "sdpctl/" + appVersion
+	f.clientCerts = &clientCertificates{certs: make(map[string]*tls.Certificate)}
+	f.pinWarning = &sync.Once{}
 	f.HTTPTransport = httpTransport(f)       // depends on config
 	f.HTTPClient = httpClientFunc(f)         // depends on config
 	f.CustomHTTPClient = customHTTPClient(f) // depends on config
@@ -72,6 +78,15 @@ func New(appVersion string, config *configuration.Config) *Factory {
 	return f
 }
 
+// WithConfig returns a copy of the factory with clients for cfg, for example to make requests with the configuration
+// of another profile. The copy doesn't refresh the bearer token.
+func (f *Factory) WithConfig(cfg *configuration.Config) *Factory {
+	c := f.withoutTokenSource()
+	c.Config = cfg
+	c.BaseURL = BaseURL(c)
+	return c
+}
+
 func (f *Factory) DisablePrompt(v bool) {
 	f.neverPrompt = v
 }
@@ -152,12 +167,23 @@ func httpTransport(f *Factory) func() (*http.Transport, error) {
 				return nil, fmt.Errorf("unable to append cert %s", cfg.PemFilePath)
 			}
 		}
+		tlsConfig := &tls.Config{
+			InsecureSkipVerify: cfg.Insecure,
+			RootCAs:            rootCAs,
+		}
+		cert, err := f.clientCertificate(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if cert != nil {
+			tlsConfig.Certificates = []tls.Certificate{*cert}
+		}
+		if len(cfg.PinnedSPKI) > 0 {
+			tlsConfig.VerifyConnection = f.verifyPin(cfg)
+		}
 		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: cfg.Insecure,
-				RootCAs:            rootCAs,
-			},
-			Proxy: proxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+			Proxy:           proxyFromEnvironment,
 		}
 
 		return tr, nil
@@ -201,7 +227,7 @@ func customHTTPClient(f *Factory) func() (*http.Client, error) {
 			token:               fmt.Sprintf("Bearer %s", token),
 			accept:              fmt.Sprintf("application/vnd.appgate.peer-v%d+json", cfg.Version),
 			useragent:           f.userAgent,
-			underlyingTransport: parentTransport,
+			underlyingTransport: f.tokenTransport(parentTransport),
 		}
 		return client, nil
 	}
@@ -214,7 +240,7 @@ func httpClientFunc(f *Factory) func() (*http.Client, error) {
 			return nil, err
 		}
 		c := &http.Client{
-			Transport: tr,
+			Transport: f.tokenTransport(tr),
 		}
 		return c, nil
 	}
@@ -84,6 +84,31 @@ func TestHttpTransportTLSFromConfig(t *testing.T) {
 	}
 }
 
+func TestHttpTransportClientCertificate(t *testing.T) {
+	t.Setenv(configuration.ClientKeyPassphraseEnv, "secret")
+	f := New("0.0.0", &configuration.Config{
+		ClientCertificate: "../configuration/testdata/client.p12",
+	})
+	tr, err := f.HTTPTransport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.TLSClientConfig.Certificates) != 1 {
+		t.Fatalf("expected a client certificate, got %d", len(tr.TLSClientConfig.Certificates))
+	}
+	// the certificate is loaded once per factory, so the passphrase isn't needed again
+	t.Setenv(configuration.ClientKeyPassphraseEnv, "wrong")
+	if _, err := f.HTTPTransport(); err != nil {
+		t.Fatalf("expected the cached client certificate, got %s", err)
+	}
+
+	f.Config.ClientCertificate = "../configuration/testdata/client.pem"
+	f.Config.ClientKey = "../configuration/testdata/other.key"
+	if _, err := f.HTTPTransport(); err == nil {
+		t.Fatal("expected an error for a key that doesn't match the client certificate")
+	}
+}
+
 func TestNew(t *testing.T) {
 	type args struct {
 		appVersion string
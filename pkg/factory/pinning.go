@@ -0,0 +1,98 @@
+package factory
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/appgate/sdpctl/pkg/configuration"
+	log "github.com/sirupsen/logrus"
+)
+
+// PresentedCertificate is the certificate chain presented by the Controller
+type PresentedCertificate struct {
+	Chain []*x509.Certificate
+	// VerifyError is why the chain isn't trusted with the system certificates and pem_base64, nil if it is
+	VerifyError error
+}
+
+// Leaf returns the certificate of the Controller
+func (p *PresentedCertificate) Leaf() *x509.Certificate {
+	return p.Chain[0]
+}
+
+// verifyPin returns a tls.Config.VerifyConnection function that checks the certificate of the Controller against
+// the pinned public key. Other hosts, such as a docker registry, are not pinned.
+func (f *Factory) verifyPin(cfg *configuration.Config) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		host, err := cfg.GetHost()
+		if err != nil {
+			return nil
+		}
+		// the server name is only set for host names, not IP addresses
+		if len(cs.ServerName) > 0 && !strings.EqualFold(cs.ServerName, host) || len(cs.ServerName) == 0 && net.ParseIP(host) == nil {
+			return nil
+		}
+		err = cfg.VerifyPin(cs)
+		if err == nil || cfg.PinMode != configuration.PinModeWarn {
+			return err
+		}
+		log.WithError(err).Warn("certificate pin mismatch")
+		if f.pinWarning != nil && f.StdErr != nil {
+			f.pinWarning.Do(func() {
+				fmt.Fprintf(f.StdErr, "[warning] %s\n", err)
+			})
+		}
+		return nil
+	}
+}
+
+// PeerCertificates connects to the Controller and returns the certificate chain it presents. The chain is returned
+// even if it isn't trusted or doesn't match the pin, so a rotated certificate can be reviewed.
+func (f *Factory) PeerCertificates(ctx context.Context) (*PresentedCertificate, error) {
+	u, err := url.Parse(f.BaseURL())
+	if err != nil {
+		return nil, err
+	}
+	tr, err := f.HTTPTransport()
+	if err != nil {
+		return nil, err
+	}
+	roots := tr.TLSClientConfig.RootCAs
+	var chain []*x509.Certificate
+	tr.TLSClientConfig.InsecureSkipVerify = true
+	tr.TLSClientConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		chain = cs.PeerCertificates
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := (&http.Client{Transport: tr}).Do(req)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if len(chain) == 0 {
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New("the Controller did not present a TLS certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+	_, verifyErr := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       u.Hostname(),
+		Intermediates: intermediates,
+	})
+	return &PresentedCertificate{Chain: chain, VerifyError: verifyErr}, nil
+}
@@ -0,0 +1,112 @@
+package factory
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/appgate/sdpctl/pkg/configuration"
+)
+
+func TestPeerCertificates(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	f := New("0.0.0", &configuration.Config{URL: ts.URL})
+	presented, err := f.PeerCertificates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !presented.Leaf().Equal(ts.Certificate()) {
+		t.Errorf("expected the certificate of the test server, got %s", presented.Leaf().Subject)
+	}
+	if presented.VerifyError == nil {
+		t.Error("expected the test server certificate to be untrusted")
+	}
+
+	pem := base64.StdEncoding.EncodeToString(ts.Certificate().Raw)
+	f = New("0.0.0", &configuration.Config{URL: ts.URL, PemBase64: &pem})
+	presented, err = f.PeerCertificates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presented.VerifyError != nil {
+		t.Errorf("expected the test server certificate to be trusted, got %s", presented.VerifyError)
+	}
+}
+
+func TestHttpTransportPinning(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	pem := base64.StdEncoding.EncodeToString(ts.Certificate().Raw)
+	// a pin that doesn't match the public key of the test server
+	otherPin := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	tests := []struct {
+		name        string
+		pin         string
+		mode        string
+		wantErr     bool
+		wantWarning bool
+	}{
+		{
+			name: "matching pin",
+			pin:  configuration.SPKIPin(ts.Certificate()),
+			mode: configuration.PinModeFail,
+		},
+		{
+			name:    "pin mismatch",
+			pin:     otherPin,
+			mode:    configuration.PinModeFail,
+			wantErr: true,
+		},
+		{
+			name:        "pin mismatch in warn mode",
+			pin:         otherPin,
+			mode:        configuration.PinModeWarn,
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New("0.0.0", &configuration.Config{
+				URL:        ts.URL,
+				PemBase64:  &pem,
+				PinnedSPKI: tt.pin,
+				PinMode:    tt.mode,
+			})
+			stderr := &bytes.Buffer{}
+			f.StdErr = stderr
+			tr, err := f.HTTPTransport()
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: tr}
+			for i := 0; i < 2; i++ {
+				resp, err := client.Get(ts.URL)
+				if resp != nil {
+					resp.Body.Close()
+				}
+				if tt.wantErr {
+					if !errors.Is(err, configuration.ErrCertificatePinMismatch) {
+						t.Fatalf("got error %v, want %v", err, configuration.ErrCertificatePinMismatch)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				// new connection for the next request
+				tr.CloseIdleConnections()
+			}
+			if got := strings.Count(stderr.String(), "[warning]"); tt.wantWarning && got != 1 || !tt.wantWarning && got != 0 {
+				t.Errorf("got %d warnings\n%s", got, stderr)
+			}
+		})
+	}
+}
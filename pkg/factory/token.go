@@ -0,0 +1,171 @@
+package factory
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// tokenExpiryMargin is how long before the bearer token expires it's refreshed
+const tokenExpiryMargin = 5 * time.Minute
+
+// tokenRetryInterval is how long to wait before trying again after a refresh before the expiry failed
+const tokenRetryInterval = time.Minute
+
+// TokenSource provides the bearer token for the requests to the Controller and signs in again when the token has
+// expired, so commands that run for longer than the token lifetime, such as 'appliance upgrade complete', don't
+// fail halfway through. The APIClient, CustomHTTPClient and Appliance all share the TokenSource of the factory.
+type TokenSource struct {
+	factory *Factory
+	// signin authenticates and updates the bearer token in the configuration, using the stored credentials,
+	// the OpenID Connect refresh token or a prompt
+	signin func(f *Factory) error
+	mu     sync.Mutex
+	// retryAt is set when a refresh before the expiry failed, to not try again for each request
+	retryAt time.Time
+}
+
+// NewTokenSource returns a TokenSource that refreshes the bearer token with signin. signin is called with a factory
+// whose clients don't use the TokenSource.
+func NewTokenSource(f *Factory, signin func(f *Factory) error) *TokenSource {
+	return &TokenSource{
+		factory: f,
+		signin:  signin,
+	}
+}
+
+// Token returns the current bearer token, and refreshes it first if it's about to expire
+func (ts *TokenSource) Token() (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	cfg := ts.factory.Config
+	token, err := cfg.GetBearTokenHeaderValue()
+	if err != nil {
+		return "", err
+	}
+	expiresAt, err := cfg.ExpiresAtTime()
+	if err != nil || time.Now().Before(ts.retryAt) || time.Until(expiresAt) > tokenExpiryMargin {
+		return token, nil
+	}
+	if err := ts.refresh(); err != nil {
+		log.WithError(err).Warn("failed to refresh the bearer token before it expires")
+		ts.retryAt = time.Now().Add(tokenRetryInterval)
+		return token, nil
+	}
+	return cfg.GetBearTokenHeaderValue()
+}
+
+// Refresh signs in again and returns the new bearer token, unless the token has already been refreshed by another
+// request since stale was used.
+func (ts *TokenSource) Refresh(stale string) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	cfg := ts.factory.Config
+	if token, err := cfg.GetBearTokenHeaderValue(); err == nil && token != stale {
+		return token, nil
+	}
+	if err := ts.refresh(); err != nil {
+		return "", err
+	}
+	ts.retryAt = time.Time{}
+	return cfg.GetBearTokenHeaderValue()
+}
+
+func (ts *TokenSource) refresh() error {
+	cfg := ts.factory.Config
+	// the token has expired or been revoked, so it must not be reused by signin
+	expiresAt := cfg.ExpiresAt
+	cfg.ExpiresAt = nil
+	log.Info("bearer token expired, signing in again")
+	if err := ts.signin(ts.factory.withoutTokenSource()); err != nil {
+		// keep the expiry, so the next request tries again once the token is rejected
+		if cfg.ExpiresAt == nil {
+			cfg.ExpiresAt = expiresAt
+		}
+		return err
+	}
+	return nil
+}
+
+// withoutTokenSource returns a copy of the factory with clients that don't use the TokenSource, which is used to
+// sign in without refreshing the token recursively.
+func (f *Factory) withoutTokenSource() *Factory {
+	c := *f
+	c.TokenSource = nil
+	c.HTTPTransport = httpTransport(&c)
+	c.HTTPClient = httpClientFunc(&c)
+	c.CustomHTTPClient = customHTTPClient(&c)
+	c.APIClient = apiClientFunc(&c)
+	c.Appliance = applianceFunc(&c)
+	c.Device = deviceFunc(&c)
+	c.ServiceUsers = serviceUsersFunc(&c)
+	return &c
+}
+
+// tokenTransport sets the bearer token from the TokenSource on the requests to the Controller, and retries a
+// request once with a new token if the Controller responds with 401 Unauthorized.
+type tokenTransport struct {
+	source              *TokenSource
+	baseURL             func() string
+	underlyingTransport http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") || !t.isController(req.URL) {
+		return t.underlyingTransport.RoundTrip(req)
+	}
+	token, err := t.source.Token()
+	if err != nil {
+		return t.underlyingTransport.RoundTrip(req)
+	}
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+token)
+	resp, err := t.underlyingTransport.RoundTrip(r)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	// requests with a body that can't be read again, such as file uploads, can't be retried
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	newToken, err := t.source.Refresh(token)
+	if err != nil {
+		log.WithError(err).Warn("failed to refresh the bearer token")
+		return resp, nil
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	retry.Header.Set("Authorization", "Bearer "+newToken)
+	resp.Body.Close()
+	return t.underlyingTransport.RoundTrip(retry)
+}
+
+func (t *tokenTransport) isController(u *url.URL) bool {
+	base, err := url.Parse(t.baseURL())
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, base.Host)
+}
+
+// tokenTransport returns rt with the bearer token of the TokenSource, if the factory has one
+func (f *Factory) tokenTransport(rt http.RoundTripper) http.RoundTripper {
+	if f.TokenSource == nil {
+		return rt
+	}
+	return &tokenTransport{
+		source:              f.TokenSource,
+		baseURL:             BaseURL(f),
+		underlyingTransport: rt,
+	}
+}
@@ -0,0 +1,200 @@
+package factory
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appgate/sdp-api-client-go/api/v24/openapi"
+	"github.com/appgate/sdpctl/pkg/configuration"
+)
+
+const (
+	expiredToken = "expired-bearer-token"
+	freshToken   = "fresh-bearer-token"
+)
+
+func expiresAt(d time.Duration) *string {
+	return openapi.PtrString(time.Now().Round(0).Add(d).String())
+}
+
+func TestTokenSourceRefresh(t *testing.T) {
+	tests := []struct {
+		name        string
+		expiresAt   *string
+		method      string
+		body        string
+		signinErr   error
+		wantStatus  int
+		wantSignins int
+		wantBodies  []string
+	}{
+		{
+			name:        "refresh on unauthorized",
+			expiresAt:   expiresAt(time.Hour),
+			method:      http.MethodGet,
+			wantStatus:  http.StatusOK,
+			wantSignins: 1,
+			wantBodies:  []string{"", ""},
+		},
+		{
+			name:        "retry request body",
+			expiresAt:   expiresAt(time.Hour),
+			method:      http.MethodPost,
+			body:        `{"name": "controller"}`,
+			wantStatus:  http.StatusOK,
+			wantSignins: 1,
+			wantBodies:  []string{`{"name": "controller"}`, `{"name": "controller"}`},
+		},
+		{
+			name:        "refresh before expiry",
+			expiresAt:   expiresAt(time.Minute),
+			method:      http.MethodGet,
+			wantStatus:  http.StatusOK,
+			wantSignins: 1,
+			wantBodies:  []string{""},
+		},
+		{
+			name:        "signin fails",
+			expiresAt:   expiresAt(time.Hour),
+			method:      http.MethodGet,
+			signinErr:   fmt.Errorf("No TTY present, and missing required environment variables to authenticate"),
+			wantStatus:  http.StatusUnauthorized,
+			wantSignins: 1,
+			wantBodies:  []string{""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies := []string{}
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(b))
+				if v := r.Header.Values("Authorization"); len(v) != 1 || v[0] != "Bearer "+freshToken {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer svr.Close()
+
+			f := New("test", &configuration.Config{
+				URL:         svr.URL,
+				BearerToken: openapi.PtrString(expiredToken),
+				ExpiresAt:   tt.expiresAt,
+			})
+			signins := 0
+			f.TokenSource = NewTokenSource(f, func(sf *Factory) error {
+				signins++
+				if sf.TokenSource != nil {
+					t.Error("expected signin to use a factory without the token source")
+				}
+				if tt.signinErr != nil {
+					return tt.signinErr
+				}
+				sf.Config.BearerToken = openapi.PtrString(freshToken)
+				sf.Config.ExpiresAt = expiresAt(24 * time.Hour)
+				return nil
+			})
+
+			client, err := f.CustomHTTPClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body io.Reader
+			if len(tt.body) > 0 {
+				body = strings.NewReader(tt.body)
+			}
+			req, err := http.NewRequest(tt.method, svr.URL+"/admin/appliances", body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if signins != tt.wantSignins {
+				t.Errorf("got %d sign ins, want %d", signins, tt.wantSignins)
+			}
+			if strings.Join(bodies, "|") != strings.Join(tt.wantBodies, "|") {
+				t.Errorf("got request bodies %q, want %q", bodies, tt.wantBodies)
+			}
+		})
+	}
+}
+
+func TestTokenSourceRefreshFailure(t *testing.T) {
+	expires := expiresAt(time.Minute)
+	f := New("test", &configuration.Config{
+		BearerToken: openapi.PtrString(expiredToken),
+		ExpiresAt:   expires,
+	})
+	signins := 0
+	f.TokenSource = NewTokenSource(f, func(sf *Factory) error {
+		signins++
+		return fmt.Errorf("controller unreachable")
+	})
+	for i := 0; i < 2; i++ {
+		token, err := f.TokenSource.Token()
+		if err != nil || token != expiredToken {
+			t.Fatalf("Token() = %s, %v, want the current token", token, err)
+		}
+	}
+	if signins != 1 {
+		t.Errorf("got %d sign ins, want 1 within the retry interval", signins)
+	}
+	if f.Config.ExpiresAt == nil || *f.Config.ExpiresAt != *expires {
+		t.Fatalf("expected the expiry to be kept after a failed refresh, got %v", f.Config.ExpiresAt)
+	}
+	f.TokenSource.retryAt = time.Now().Add(-time.Second)
+	if _, err := f.TokenSource.Token(); err != nil {
+		t.Fatal(err)
+	}
+	if signins != 2 {
+		t.Errorf("got %d sign ins, want another try after the retry interval", signins)
+	}
+}
+
+func TestTokenSourceOtherHosts(t *testing.T) {
+	var authorization string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer other.Close()
+
+	f := New("test", &configuration.Config{
+		URL:         "https://controller.appgate.test:8443/admin",
+		BearerToken: openapi.PtrString(expiredToken),
+		ExpiresAt:   expiresAt(time.Minute),
+	})
+	f.TokenSource = NewTokenSource(f, func(sf *Factory) error {
+		t.Error("did not expect a sign in for requests to other hosts")
+		return nil
+	})
+	client, err := f.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, other.URL+"/v2/appgate/tags/list", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer registry-token")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if authorization != "Bearer registry-token" {
+		t.Errorf("got Authorization %q, want the registry token", authorization)
+	}
+}
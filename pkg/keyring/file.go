@@ -0,0 +1,313 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/appgate/sdpctl/pkg/filesystem"
+	zkeyring "github.com/zalando/go-keyring"
+)
+
+const (
+	// keyringPassphraseEnv is the passphrase used to derive the key of the encrypted file keyring
+	keyringPassphraseEnv = "SDPCTL_KEYRING_PASSPHRASE"
+	// keyringKeyFileEnv is the path to a file with the key of the encrypted file keyring, used when
+	// no passphrase is set
+	keyringKeyFileEnv = "SDPCTL_KEYRING_KEYFILE"
+
+	fileKeyringName = "keyring.json"
+	keyFileName     = "keyring.key"
+
+	// pbkdf2Iterations as recommended by OWASP for PBKDF2-HMAC-SHA256
+	pbkdf2Iterations = 600000
+	keySize          = 32
+)
+
+// ErrKeyFilePermissions is returned when the key file of the encrypted file keyring can be read by other users
+var ErrKeyFilePermissions = errors.New("keyring: key file must only be readable by its owner (chmod 600)")
+
+// ErrNoFileKeyringKey is returned when the encrypted file keyring is used without a passphrase or key file
+var ErrNoFileKeyringKey = fmt.Errorf("keyring: set %s or create a key file to use the encrypted file keyring", keyringPassphraseEnv)
+
+// encryptedFile is the content of the encrypted file keyring. Each secret is encrypted with AES-256-GCM
+// and stored as nonce|ciphertext, with the name of the secret as additional data.
+type encryptedFile struct {
+	Salt    []byte            `json:"salt"`
+	Secrets map[string][]byte `json:"secrets"`
+}
+
+var (
+	// fileKeyringMu serializes read-modify-write of the encrypted file within the process
+	fileKeyringMu sync.Mutex
+
+	// systemKeyringProbe checks once per process if the system keyring is reachable
+	systemKeyringProbe     sync.Once
+	systemKeyringAvailable bool
+
+	// derivedKeys caches the keys derived from the passphrase, since PBKDF2 is slow by design
+	derivedKeys = map[[sha256.Size]byte][]byte{}
+)
+
+func fileKeyringPath() string {
+	return filepath.Join(filesystem.ConfigDir(), fileKeyringName)
+}
+
+func keyFilePath() string {
+	if v := os.Getenv(keyringKeyFileEnv); len(v) > 0 {
+		return v
+	}
+	return filepath.Join(filesystem.ConfigDir(), keyFileName)
+}
+
+// hasFileKeyringKey reports if a passphrase or key file is available for the encrypted file keyring
+func hasFileKeyringKey() bool {
+	if len(os.Getenv(keyringPassphraseEnv)) > 0 {
+		return true
+	}
+	_, err := os.Stat(keyFilePath())
+	return err == nil
+}
+
+// useFileKeyring reports if the secrets are stored in the encrypted file instead of the system keyring.
+// In auto mode, the encrypted file is used when the system keyring is unavailable, for example on a headless
+// host without a Secret Service, and a passphrase or key file has been set up.
+func useFileKeyring() bool {
+	if len(os.Getenv("SDPCTL_NO_KEYRING")) > 0 {
+		return false
+	}
+	switch backend {
+	case BackendFile:
+		return true
+	case BackendSystem:
+		return false
+	}
+	if !hasFileKeyringKey() {
+		return false
+	}
+	systemKeyringProbe.Do(func() {
+		_, err := getSecret(format(keyringService, "probe"))
+		systemKeyringAvailable = err == nil || errors.Is(err, zkeyring.ErrNotFound)
+	})
+	return !systemKeyringAvailable
+}
+
+// fileKeyringKey returns the encryption key, derived from the passphrase or read from the key file.
+// The key file is created when the encrypted file keyring has been selected explicitly.
+func fileKeyringKey(salt []byte) ([]byte, error) {
+	if passphrase := os.Getenv(keyringPassphraseEnv); len(passphrase) > 0 {
+		id := sha256.Sum256(append([]byte(passphrase), salt...))
+		if key, ok := derivedKeys[id]; ok {
+			return key, nil
+		}
+		key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, keySize)
+		if err != nil {
+			return nil, err
+		}
+		derivedKeys[id] = key
+		return key, nil
+	}
+	path := keyFilePath()
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) && backend == BackendFile {
+		if err := createKeyFile(path); err != nil {
+			return nil, err
+		}
+		info, err = os.Stat(path)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoFileKeyringKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("%w: %s", ErrKeyFilePermissions, path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("keyring: key file %s is empty", path)
+	}
+	key := sha256.Sum256(b)
+	return key[:], nil
+}
+
+func createKeyFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(key); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readEncryptedFile() (*encryptedFile, error) {
+	content := &encryptedFile{Secrets: map[string][]byte{}}
+	b, err := os.ReadFile(fileKeyringPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return content, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, content); err != nil {
+		return nil, fmt.Errorf("keyring: invalid file %s: %w", fileKeyringPath(), err)
+	}
+	if content.Secrets == nil {
+		content.Secrets = map[string][]byte{}
+	}
+	return content, nil
+}
+
+// writeEncryptedFile replaces the encrypted file atomically, so a failed write never leaves it truncated
+func writeEncryptedFile(content *encryptedFile) error {
+	b, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	path := fileKeyringPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), fileKeyringName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func fileGetSecret(key string) (string, error) {
+	fileKeyringMu.Lock()
+	defer fileKeyringMu.Unlock()
+	content, err := readEncryptedFile()
+	if err != nil {
+		return "", err
+	}
+	sealed, ok := content.Secrets[key]
+	if !ok {
+		return "", zkeyring.ErrNotFound
+	}
+	k, err := fileKeyringKey(content.Salt)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(k)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("keyring: invalid secret in %s", fileKeyringPath())
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("keyring: could not decrypt %s, wrong passphrase or key file", fileKeyringPath())
+	}
+	return string(plain), nil
+}
+
+func fileSetSecret(key, value string) error {
+	fileKeyringMu.Lock()
+	defer fileKeyringMu.Unlock()
+	content, err := readEncryptedFile()
+	if err != nil {
+		return err
+	}
+	if len(content.Salt) == 0 {
+		content.Salt = make([]byte, 16)
+		if _, err := rand.Read(content.Salt); err != nil {
+			return err
+		}
+	}
+	k, err := fileKeyringKey(content.Salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(k)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	content.Secrets[key] = gcm.Seal(nonce, nonce, []byte(value), []byte(key))
+	return writeEncryptedFile(content)
+}
+
+func fileDeleteSecret(key string) error {
+	fileKeyringMu.Lock()
+	defer fileKeyringMu.Unlock()
+	content, err := readEncryptedFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := content.Secrets[key]; !ok {
+		return zkeyring.ErrNotFound
+	}
+	delete(content.Secrets, key)
+	return writeEncryptedFile(content)
+}
+
+// loadSecret, storeSecret and removeSecret use the encrypted file or the system keyring, see useFileKeyring
+
+func loadSecret(key string) (string, error) {
+	if useFileKeyring() {
+		return fileGetSecret(key)
+	}
+	return getSecret(key)
+}
+
+func storeSecret(key, value string) error {
+	if useFileKeyring() {
+		return fileSetSecret(key, value)
+	}
+	return setSecret(key, value)
+}
+
+func removeSecret(key string) error {
+	if useFileKeyring() {
+		return fileDeleteSecret(key)
+	}
+	return deleteSecret(key)
+}
@@ -0,0 +1,160 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package keyring
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	zkeyring "github.com/zalando/go-keyring"
+)
+
+func setupFileKeyring(t *testing.T, name string) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("SDPCTL_CONFIG_DIR", dir)
+	if err := SetBackend(name); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		backend = BackendAuto
+		systemKeyringProbe = sync.Once{}
+	})
+	return dir
+}
+
+func TestFileKeyringPassphrase(t *testing.T) {
+	dir := setupFileKeyring(t, BackendFile)
+	t.Setenv(keyringPassphraseEnv, "correct horse battery staple")
+	prefix := "productioncontroller.devops"
+	if err := SetBearer(prefix, "abc123456789"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := os.LookupEnv("SDPCTL_BEARER"); ok {
+		t.Fatal("expected the bearer token in the encrypted file, not in the environment")
+	}
+	got, err := GetBearer(prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "abc123456789" {
+		t.Errorf("GetBearer() = %q, want abc123456789", got)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, fileKeyringName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "abc123456789") {
+		t.Error("expected the bearer token to be encrypted")
+	}
+	info, err := os.Stat(filepath.Join(dir, fileKeyringName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("keyring file permissions = %o, want 600", perm)
+	}
+
+	t.Setenv(keyringPassphraseEnv, "wrong passphrase")
+	if _, err := GetBearer(prefix); err == nil {
+		t.Error("expected an error with the wrong passphrase")
+	}
+
+	t.Setenv(keyringPassphraseEnv, "correct horse battery staple")
+	if err := ClearCredentials(prefix); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fileGetSecret(format(prefix, bearer)); !errors.Is(err, zkeyring.ErrNotFound) {
+		t.Errorf("expected the bearer token to be removed, got %v", err)
+	}
+}
+
+func TestFileKeyringKeyFile(t *testing.T) {
+	dir := setupFileKeyring(t, BackendFile)
+	if err := SetPassword("prefix", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, keyFileName))
+	if err != nil {
+		t.Fatalf("expected a key file to be created, %s", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("key file permissions = %o, want 600", perm)
+	}
+	got, err := GetPassword("prefix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "secret" {
+		t.Errorf("GetPassword() = %q, want secret", got)
+	}
+
+	if err := os.Chmod(filepath.Join(dir, keyFileName), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetPassword("prefix"); !errors.Is(err, ErrKeyFilePermissions) {
+		t.Errorf("GetPassword() error = %v, want %v", err, ErrKeyFilePermissions)
+	}
+}
+
+func TestFileKeyringAuto(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyringErr error
+		passphrase string
+		want       bool
+	}{
+		{
+			name: "system keyring available",
+			want: false,
+		},
+		{
+			name:       "system keyring available with passphrase",
+			passphrase: "passphrase",
+			want:       false,
+		},
+		{
+			name:       "secret service missing",
+			keyringErr: errors.New("The name " + secretMissing + " .service files"),
+			passphrase: "passphrase",
+			want:       true,
+		},
+		{
+			name:       "secret service missing without passphrase",
+			keyringErr: errors.New("The name " + secretMissing + " .service files"),
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupFileKeyring(t, "")
+			zkeyring.MockInit()
+			if tt.keyringErr != nil {
+				zkeyring.MockInitWithError(tt.keyringErr)
+			}
+			t.Setenv(keyringPassphraseEnv, tt.passphrase)
+			if got := useFileKeyring(); got != tt.want {
+				t.Errorf("useFileKeyring() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetBackend(t *testing.T) {
+	t.Cleanup(func() {
+		backend = BackendAuto
+	})
+	for _, name := range []string{"", BackendAuto, BackendSystem, BackendFile} {
+		if err := SetBackend(name); err != nil {
+			t.Errorf("SetBackend(%q) error = %v", name, err)
+		}
+	}
+	if err := SetBackend("kwallet"); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
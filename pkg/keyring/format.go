@@ -16,6 +16,7 @@ const (
 	username       = "username"
 	bearer         = "bearer"
 	refreshToken   = "refreshToken"
+	totpSecret     = "totpSecret"
 )
 
 // ErrKeyringTimeOut is returned when the keyring operation takes too long to complete.
@@ -24,6 +25,32 @@ var ErrKeyringTimeOut = errors.New("keyring: timeout")
 // keyringTimeout max time for a keyring syscall
 var keyringTimeout = time.Second * 5
 
+const (
+	// BackendAuto uses the system keyring, or the encrypted file on Linux when the system keyring is unavailable
+	// and a passphrase or key file is present
+	BackendAuto = "auto"
+	// BackendSystem always uses the system keyring
+	BackendSystem = "system"
+	// BackendFile uses an encrypted file in the configuration directory, only supported on Linux
+	BackendFile = "file"
+)
+
+// backend is the keyring backend selected with SetBackend
+var backend = BackendAuto
+
+// SetBackend selects the keyring backend, an empty name is the same as BackendAuto
+func SetBackend(name string) error {
+	switch name {
+	case "":
+		backend = BackendAuto
+	case BackendAuto, BackendSystem, BackendFile:
+		backend = name
+	default:
+		return fmt.Errorf("keyring: unknown backend %q, expected one of %s, %s or %s", name, BackendAuto, BackendSystem, BackendFile)
+	}
+	return nil
+}
+
 func runWithTimeout(task func() error) error {
 	if len(os.Getenv("SDPCTL_NO_KEYRING")) > 0 {
 		return nil
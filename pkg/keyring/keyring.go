@@ -21,8 +21,8 @@ const (
 // ClearCredentials removes any existing items in the keychain,
 // it will ignore if not found errors
 func ClearCredentials(prefix string) error {
-	for _, k := range []string{username, password} {
-		if err := deleteSecret(format(prefix, k)); err != nil {
+	for _, k := range []string{username, password, refreshToken} {
+		if err := removeSecret(format(prefix, k)); err != nil {
 			if !errors.Is(err, zkeyring.ErrNotFound) {
 				return err
 			}
@@ -38,11 +38,11 @@ func GetPassword(prefix string) (string, error) {
 	if v, ok := os.LookupEnv("SDPCTL_PASSWORD"); ok {
 		return v, nil
 	}
-	return getSecret(format(prefix, password))
+	return loadSecret(format(prefix, password))
 }
 
 func SetPassword(prefix, secret string) error {
-	err := setSecret(format(prefix, password), secret)
+	err := storeSecret(format(prefix, password), secret)
 	if err != nil && strings.Contains(err.Error(), secretMissing) {
 		return os.Setenv("SDPCTL_PASSWORD", secret)
 	}
@@ -53,7 +53,7 @@ func GetBearer(prefix string) (string, error) {
 	if v, ok := os.LookupEnv("SDPCTL_BEARER"); ok {
 		return v, nil
 	}
-	v, err := getSecret(format(prefix, bearer))
+	v, err := loadSecret(format(prefix, bearer))
 	if err != nil {
 		return "", fmt.Errorf("Could not retrieve bearer token for %s configuration, run 'sdpctl configure login' or set SDPCTL_BEARER %w", prefix, err)
 	}
@@ -61,14 +61,14 @@ func GetBearer(prefix string) (string, error) {
 }
 
 func SetBearer(prefix, secret string) error {
-	if err := setSecret(format(prefix, bearer), secret); err != nil {
+	if err := storeSecret(format(prefix, bearer), secret); err != nil {
 		return os.Setenv("SDPCTL_BEARER", secret)
 	}
 	return nil
 }
 
 func DeleteBearer(prefix string) error {
-	if err := deleteSecret(format(prefix, bearer)); err != nil {
+	if err := removeSecret(format(prefix, bearer)); err != nil {
 		if err != zkeyring.ErrNotFound {
 			return err
 		}
@@ -80,15 +80,28 @@ func DeleteBearer(prefix string) error {
 }
 
 func GetRefreshToken(prefix string) (string, error) {
-	return getSecret(format(prefix, refreshToken))
+	return loadSecret(format(prefix, refreshToken))
 }
 
 func SetRefreshToken(prefix, secret string) error {
-	return setSecret(format(prefix, refreshToken), secret)
+	return storeSecret(format(prefix, refreshToken), secret)
+}
+
+// GetTOTPSecret returns the seed used to generate one-time passwords, it's not removed by ClearCredentials since
+// the Controller only hands it out once
+func GetTOTPSecret(prefix string) (string, error) {
+	if v, ok := os.LookupEnv("SDPCTL_TOTP_SECRET"); ok {
+		return v, nil
+	}
+	return loadSecret(format(prefix, totpSecret))
+}
+
+func SetTOTPSecret(prefix, secret string) error {
+	return storeSecret(format(prefix, totpSecret), secret)
 }
 
 func SetUsername(prefix, secret string) error {
-	err := setSecret(format(prefix, username), secret)
+	err := storeSecret(format(prefix, username), secret)
 	if err != nil && strings.Contains(err.Error(), secretMissing) {
 		return os.Setenv("SDPCTL_USERNAME", secret)
 	}
@@ -99,5 +112,5 @@ func GetUsername(prefix string) (string, error) {
 	if v, ok := os.LookupEnv("SDPCTL_USERNAME"); ok {
 		return v, nil
 	}
-	return getSecret(format(prefix, username))
+	return loadSecret(format(prefix, username))
 }
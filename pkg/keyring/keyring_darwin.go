@@ -31,7 +31,7 @@ func deleteSecretKey(prefix, name string) error {
 // ClearCredentials removes any existing items in the keychain,
 // it will ignore if not found errors
 func ClearCredentials(prefix string) error {
-	for _, k := range []string{username, password} {
+	for _, k := range []string{username, password, refreshToken} {
 		if err := deleteSecretKey(prefix, k); err != nil {
 			return err
 		}
@@ -183,6 +183,23 @@ func GetRefreshToken(prefix string) (string, error) {
 	return token, nil
 }
 
+// GetTOTPSecret returns the seed used to generate one-time passwords, it's not removed by ClearCredentials since
+// the Controller only hands it out once
+func GetTOTPSecret(prefix string) (string, error) {
+	if v, ok := os.LookupEnv("SDPCTL_TOTP_SECRET"); ok {
+		return v, nil
+	}
+	secret, err := QueryKeychain(format(prefix, totpSecret))
+	if err != nil {
+		return "", fmt.Errorf("Failed to get TOTP secret from keychain: %s", err)
+	}
+	return secret, nil
+}
+
+func SetTOTPSecret(prefix, secret string) error {
+	return AddKeychain(format(prefix, totpSecret), secret)
+}
+
 func SetRefreshToken(prefix, secret string) error {
 	err := AddKeychain(format(prefix, refreshToken), secret)
 	if err != nil {
@@ -42,6 +42,7 @@ func TestClearCredentials(t *testing.T) {
 		username = "user"
 		password = "password"
 		bearer   = "somebearer"
+		refresh  = "somerefreshtoken"
 	)
 	if err := SetUsername(prefix, username); err != nil {
 		t.Error("TEST FAIL: failed to set username", err)
@@ -52,6 +53,9 @@ func TestClearCredentials(t *testing.T) {
 	if err := SetBearer(prefix, bearer); err != nil {
 		t.Error("TEST FAIL: failed to set bearer", err)
 	}
+	if err := SetRefreshToken(prefix, refresh); err != nil {
+		t.Error("TEST FAIL: failed to set refresh token", err)
+	}
 
 	if err := ClearCredentials(prefix); err != nil {
 		t.Fatalf("failed to clear credentials %s", err)
@@ -66,4 +70,7 @@ func TestClearCredentials(t *testing.T) {
 	if _, err := GetBearer(prefix); err == nil {
 		t.Error("TEST FAIL: failed to remove bearer", err)
 	}
+	if _, err := GetRefreshToken(prefix); err == nil {
+		t.Error("TEST FAIL: failed to remove refresh token", err)
+	}
 }
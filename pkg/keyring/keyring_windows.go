@@ -34,6 +34,9 @@ func ClearCredentials(prefix string) error {
 	if err := DeleteBearer(prefix); err != nil {
 		return err
 	}
+	if err := deleteSecretFile(refreshToken, prefix); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -132,6 +135,19 @@ func DeleteBearer(prefix string) error {
 	return nil
 }
 
+// GetTOTPSecret returns the seed used to generate one-time passwords, it's not removed by ClearCredentials since
+// the Controller only hands it out once
+func GetTOTPSecret(prefix string) (string, error) {
+	if v, ok := os.LookupEnv("SDPCTL_TOTP_SECRET"); ok {
+		return v, nil
+	}
+	return getSecret(format(prefix, totpSecret))
+}
+
+func SetTOTPSecret(prefix, secret string) error {
+	return setSecret(format(prefix, totpSecret), secret)
+}
+
 func GetRefreshToken(prefix string) (string, error) {
 	return getSecretFile(refreshToken, prefix)
 }
@@ -0,0 +1,220 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next activation time after t
+type Schedule interface {
+	Next(t time.Time) time.Time
+	String() string
+}
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Interval is a fixed duration schedule, such as --every 24h
+type Interval struct {
+	Duration time.Duration
+}
+
+// Every returns a schedule that activates with a fixed interval
+func Every(d time.Duration) (*Interval, error) {
+	if d < time.Minute {
+		return nil, fmt.Errorf("%w: interval must be at least 1 minute, got %s", ErrInvalidSchedule, d)
+	}
+	return &Interval{Duration: d}, nil
+}
+
+func (i *Interval) Next(t time.Time) time.Time {
+	return t.Add(i.Duration)
+}
+
+func (i *Interval) String() string {
+	return "every " + i.Duration.String()
+}
+
+// Cron is a standard 5 field cron expression
+// minute hour day-of-month month day-of-week
+type Cron struct {
+	expr                         string
+	minute, hour, dom, month     uint64
+	dow                          uint64
+	domRestricted, dowRestricted bool
+}
+
+type bounds struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteBounds = bounds{name: "minute", min: 0, max: 59}
+	hourBounds   = bounds{name: "hour", min: 0, max: 23}
+	domBounds    = bounds{name: "day of month", min: 1, max: 31}
+	monthBounds  = bounds{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = bounds{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression, for example '0 2 * * *'.
+// Supports '*', ranges '1-5', steps '*/15' and lists '1,2,3', month and weekday names
+// and the descriptors @yearly, @monthly, @weekly, @daily and @hourly.
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	spec := expr
+	if v, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = v
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields in cron expression %q, got %d", ErrInvalidSchedule, expr, len(fields))
+	}
+	c := &Cron{expr: expr}
+	var err error
+	if c.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseField(fields[2], domBounds); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, err
+	}
+	// 7 is an alias for sunday
+	if c.dow&(1<<7) > 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	c.domRestricted = fields[2] != "*"
+	c.dowRestricted = fields[4] != "*"
+	return c, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var result uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			s, err := strconv.Atoi(stepPart)
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("%w: invalid step %q in %s field", ErrInvalidSchedule, stepPart, b.name)
+			}
+			step = s
+		}
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = b.min, b.max
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = b.value(from); err != nil {
+				return 0, err
+			}
+			if end, err = b.value(to); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := b.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			start, end = v, v
+			if hasStep {
+				end = b.max
+			}
+		}
+		if start > end {
+			return 0, fmt.Errorf("%w: invalid range %q in %s field", ErrInvalidSchedule, rangePart, b.name)
+		}
+		for i := start; i <= end; i += step {
+			result |= 1 << uint(i)
+		}
+	}
+	return result, nil
+}
+
+func (b bounds) value(s string) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid value %q in %s field", ErrInvalidSchedule, s, b.name)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("%w: value %d out of range %d-%d in %s field", ErrInvalidSchedule, v, b.min, b.max, b.name)
+	}
+	return v, nil
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) > 0
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := has(c.dom, t.Day())
+	dowMatch := has(c.dow, int(t.Weekday()))
+	// Same as vixie cron, if both day of month and day of week are restricted
+	// the day matches if either of them matches.
+	if c.domRestricted && c.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Next returns the first activation time after t, in the location of t.
+// A zero time is returned if the expression can't be satisfied, for example '0 0 30 2 *'
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !has(c.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(c.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(c.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *Cron) String() string {
+	return c.expr
+}
@@ -0,0 +1,117 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2024, time.January, 31, 13, 37, 20, 0, time.UTC)
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{
+			name: "every minute",
+			expr: "* * * * *",
+			want: time.Date(2024, time.January, 31, 13, 38, 0, 0, time.UTC),
+		},
+		{
+			name: "daily at 02:00",
+			expr: "0 2 * * *",
+			want: time.Date(2024, time.February, 1, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "every 15 minutes",
+			expr: "*/15 * * * *",
+			want: time.Date(2024, time.January, 31, 13, 45, 0, 0, time.UTC),
+		},
+		{
+			name: "weekdays range",
+			expr: "30 8 * * mon-fri",
+			want: time.Date(2024, time.February, 1, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "sunday as 7",
+			expr: "0 0 * * 7",
+			want: time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			expr: "0 12 29 feb *",
+			want: time.Date(2024, time.February, 29, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "list of hours",
+			expr: "0 6,18 * * *",
+			want: time.Date(2024, time.January, 31, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month or day of week",
+			expr: "0 0 15 * sat",
+			want: time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "descriptor",
+			expr: "@monthly",
+			want: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron() unexpected error %s", err)
+			}
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+	}
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseCron(expr); !errors.Is(err, ErrInvalidSchedule) {
+				t.Errorf("ParseCron(%q) expected ErrInvalidSchedule, got %v", expr, err)
+			}
+		})
+	}
+}
+
+func TestCronNextUnsatisfiable(t *testing.T) {
+	c, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron() unexpected error %s", err)
+	}
+	if got := c.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next() = %s, want zero time", got)
+	}
+}
+
+func TestEvery(t *testing.T) {
+	if _, err := Every(time.Second); !errors.Is(err, ErrInvalidSchedule) {
+		t.Fatalf("Every() expected ErrInvalidSchedule, got %v", err)
+	}
+	i, err := Every(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Every() unexpected error %s", err)
+	}
+	from := time.Date(2024, time.January, 31, 13, 37, 0, 0, time.UTC)
+	if got, want := i.Next(from), from.Add(24*time.Hour); !got.Equal(want) {
+		t.Errorf("Next() = %s, want %s", got, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync/atomic"
 	"time"
 
 	"github.com/vbauerster/mpb/v8"
@@ -114,6 +115,50 @@ func (p *Progress) FileDownloadProgress(name, endMsg string, size int64, width i
 	return bar.ProxyReader(reader)
 }
 
+// ProcessTracker tracks a file that is read and then processed item by item
+type ProcessTracker struct {
+	bar   *mpb.Bar
+	items atomic.Int64
+}
+
+// Increment increments the number of processed items
+func (t *ProcessTracker) Increment() {
+	t.items.Add(1)
+}
+
+// Done completes the tracker when the processing is finished
+func (t *ProcessTracker) Done() {
+	t.bar.SetTotal(-1, true)
+}
+
+// Abort aborts the tracker, for example when the file could not be processed
+func (t *ProcessTracker) Abort() {
+	t.bar.Abort(false)
+}
+
+// FileProcessProgress shows the bytes read from reader, followed by the number of items processed,
+// such as the entries parsed from a file after it has been extracted. Unlike FileDownloadProgress,
+// the bar is not completed when all bytes have been read, but when Done is called on the tracker.
+func (p *Progress) FileProcessProgress(name, itemName, endMsg string, size int64, reader io.Reader) (io.ReadCloser, *ProcessTracker) {
+	t := &ProcessTracker{}
+	t.bar = p.pc.New(0,
+		mpb.SpinnerStyle(SpinnerStyle...),
+		mpb.BarWidth(1),
+		mpb.BarFillerOnComplete(Check),
+		mpb.AppendDecorators(
+			decor.Name(name, decor.WC{W: len(name) + 1}),
+			decor.CountersKibiByte("% .2f / % .2f"),
+			decor.Name(" | "),
+			decor.Any(func(s decor.Statistics) string {
+				return fmt.Sprintf("%d %s", t.items.Load(), itemName)
+			}),
+			decor.OnComplete(decor.Name(""), " "+endMsg),
+		),
+	)
+	t.bar.SetTotal(size, false)
+	return t.bar.ProxyReader(reader), t
+}
+
 // Complete will complete all currently active trackers and wait for them to finish before returning
 func (p *Progress) Complete() {
 	for _, t := range p.trackers {
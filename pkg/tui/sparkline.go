@@ -0,0 +1,39 @@
+package tui
+
+import "strings"
+
+// Sparkline renders the values as a line of at most width characters from SparklineStyle,
+// scaled between the lowest and highest value. If there are more values than width,
+// consecutive values are averaged.
+func Sparkline(values []float64, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		buckets := make([]float64, width)
+		for i := range buckets {
+			start, end := i*len(values)/width, (i+1)*len(values)/width
+			sum := 0.0
+			for _, v := range values[start:end] {
+				sum += v
+			}
+			buckets[i] = sum / float64(end-start)
+		}
+		values = buckets
+	}
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low = min(low, v)
+		high = max(high, v)
+	}
+	var b strings.Builder
+	levels := len(SparklineStyle) - 1
+	for _, v := range values {
+		level := 0
+		if high > low {
+			level = int((v - low) / (high - low) * float64(levels))
+		}
+		b.WriteString(SparklineStyle[level])
+	}
+	return b.String()
+}
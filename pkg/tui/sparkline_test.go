@@ -0,0 +1,26 @@
+//go:build !windows
+
+package tui
+
+import "testing"
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		width  int
+		want   string
+	}{
+		{name: "empty", values: nil, width: 10, want: ""},
+		{name: "flat", values: []float64{5, 5, 5}, width: 10, want: "▁▁▁"},
+		{name: "rising", values: []float64{0, 1, 2, 3, 4, 5, 6, 7}, width: 10, want: "▁▂▃▄▅▆▇█"},
+		{name: "averaged", values: []float64{0, 0, 7, 7}, width: 2, want: "▁█"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sparkline(tt.values, tt.width); got != tt.want {
+				t.Errorf("Sparkline() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,7 +4,8 @@
 package tui
 
 var (
-	SpinnerStyle []string = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	SpinnerStyle   []string = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	SparklineStyle []string = []string{"▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
 
 	Check string = "✓"
 	Cross string = "⨯"
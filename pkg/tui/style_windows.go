@@ -6,6 +6,8 @@ package tui
 var (
 	// SpinnerStyle for Windows has no special unicode characters, to support cmd.exe out-of-the-box.
 	SpinnerStyle []string = []string{"-", "\\", "|", "/"}
+	// SparklineStyle for Windows has no block characters, for the same reason.
+	SparklineStyle []string = []string{"_", ".", "-", "~", "=", "*", "#"}
 
 	Check string = "[COMPLETE]"
 	Cross string = "[ERROR]"
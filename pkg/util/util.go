@@ -111,10 +111,26 @@ func NormalizeURL(u string) (*url.URL, error) {
 	return url, nil
 }
 
+// FilterExpressionKey is the key in the filter returned by ParseFilteringFlags that holds the '--filter' expression,
+// as filter[FilterExpressionKey][FilterExpressionKey]
+const FilterExpressionKey = "filter"
+
+// ParseFilteringFlags returns the filter from the '--include', '--exclude' and '--filter' flags added to a copy of
+// defaultFilter, and the '--order-by' and '--descending' flags. defaultFilter is not modified, since it's often a
+// package level default.
 func ParseFilteringFlags(flags *pflag.FlagSet, defaultFilter map[string]map[string]string) (map[string]map[string]string, []string, bool) {
-	result := defaultFilter
+	result := make(map[string]map[string]string, len(defaultFilter))
+	for k, v := range defaultFilter {
+		result[k] = make(map[string]string, len(v))
+		for f, value := range v {
+			result[k][f] = value
+		}
+	}
 
 	for v := range result {
+		if v == FilterExpressionKey {
+			continue
+		}
 		if arg, err := flags.GetStringToString(v); err == nil {
 			if len(arg) > 0 {
 				for f, value := range arg {
@@ -126,6 +142,10 @@ func ParseFilteringFlags(flags *pflag.FlagSet, defaultFilter map[string]map[stri
 		}
 	}
 
+	if expression, err := flags.GetString(FilterExpressionKey); err == nil && len(expression) > 0 {
+		result[FilterExpressionKey] = map[string]string{FilterExpressionKey: expression}
+	}
+
 	orderBy, _ := flags.GetStringSlice("order-by")
 	descending, _ := flags.GetBool("descending")
 
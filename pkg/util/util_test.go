@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/go-version"
+	"github.com/spf13/pflag"
 )
 
 func TestIsValidURL(t *testing.T) {
@@ -237,3 +238,33 @@ func TestDockerTagVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFilteringFlags(t *testing.T) {
+	defaultFilter := map[string]map[string]string{
+		"include": {},
+		"exclude": {"function": "portal"},
+	}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringToString("include", map[string]string{}, "")
+	flags.StringToString("exclude", map[string]string{}, "")
+	flags.String(FilterExpressionKey, "", "")
+	if err := flags.Parse([]string{"--include", "name=gateway", "--filter", "cpu > 80"}); err != nil {
+		t.Fatal(err)
+	}
+	got, _, _ := ParseFilteringFlags(flags, defaultFilter)
+	want := map[string]map[string]string{
+		"include":           {"name": "gateway"},
+		"exclude":           {"function": "portal"},
+		FilterExpressionKey: {FilterExpressionKey: "cpu > 80"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseFilteringFlags() = %v, want %v", got, want)
+	}
+	unchanged := map[string]map[string]string{
+		"include": {},
+		"exclude": {"function": "portal"},
+	}
+	if !reflect.DeepEqual(defaultFilter, unchanged) {
+		t.Errorf("expected the default filter to be unchanged, got %v", defaultFilter)
+	}
+}